@@ -0,0 +1,196 @@
+package assets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Asset is the canonical name of an asset, which is its Kraken altname such as "XBT" or "EUR".
+type Asset string
+
+// legacyNames are the REST names of assets that were listed before Kraken dropped the X and Z prefixes.
+var legacyNames = map[Asset]string{
+	"XBT": "XXBT",
+	"ETH": "XETH",
+	"LTC": "XLTC",
+	"XRP": "XXRP",
+	"XLM": "XXLM",
+	"XMR": "XXMR",
+	"ZEC": "XZEC",
+	"ETC": "XETC",
+	"REP": "XREP",
+	"MLN": "XMLN",
+	"XDG": "XXDG",
+	"USD": "ZUSD",
+	"EUR": "ZEUR",
+	"GBP": "ZGBP",
+	"JPY": "ZJPY",
+	"CAD": "ZCAD",
+	"AUD": "ZAUD",
+}
+
+// v2Names are the names used by the v2 websocket where they differ from the altname.
+var v2Names = map[Asset]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// restPairNames are the REST names of pairs that do not follow the rules of Pair.RESTName().
+var restPairNames = map[Pair]string{
+	{Base: "USDT", Quote: "USD"}: "USDTZUSD",
+}
+
+var byName = make(map[string]Asset)
+var byLegacyName = make(map[string]Asset)
+var byRESTPairName = make(map[string]Pair)
+
+// quoteNames are the altnames of common quote assets, longest first, used to split pair names without separator.
+var quoteNames []string
+
+func init() {
+	for asset, name := range legacyNames {
+		byName[name] = asset
+		byLegacyName[name] = asset
+	}
+	for asset, name := range v2Names {
+		byName[name] = asset
+	}
+	for pair, name := range restPairNames {
+		byRESTPairName[name] = pair
+	}
+
+	quotes := []Asset{"USD", "EUR", "GBP", "JPY", "CAD", "AUD", "CHF", "XBT", "ETH", "USDT", "USDC", "DAI", "DOT"}
+	for _, quote := range quotes {
+		quoteNames = append(quoteNames, string(quote))
+	}
+
+	sort.Slice(quoteNames, func(i, j int) bool {
+		if len(quoteNames[i]) == len(quoteNames[j]) {
+			return quoteNames[i] < quoteNames[j]
+		}
+		return len(quoteNames[i]) > len(quoteNames[j])
+	})
+}
+
+// ParseAsset accepts the REST name ("XXBT"), the altname ("XBT") and the v2 name ("BTC") of an asset.
+func ParseAsset(name string) Asset {
+	name = strings.ToUpper(name)
+
+	if asset, ok := byName[name]; ok {
+		return asset
+	}
+	return Asset(name)
+}
+
+// Name returns the altname, which the v1 websocket uses.
+func (asset Asset) Name() string {
+	return string(asset)
+}
+
+// RESTName returns the name used as key in REST results, such as "XXBT".
+func (asset Asset) RESTName() string {
+	if name, ok := legacyNames[asset]; ok {
+		return name
+	}
+	return string(asset)
+}
+
+// V2Name returns the name used by the v2 websocket, such as "BTC".
+func (asset Asset) V2Name() string {
+	if name, ok := v2Names[asset]; ok {
+		return name
+	}
+	return string(asset)
+}
+
+func (asset Asset) String() string {
+	return asset.Name()
+}
+
+// Pair is the canonical form of a pair, it can be used as map key.
+type Pair struct {
+	Base  Asset
+	Quote Asset
+}
+
+func NewPair(base string, quote string) Pair {
+	return Pair{Base: ParseAsset(base), Quote: ParseAsset(quote)}
+}
+
+// ParsePair accepts websocket names ("XBT/USD"), v2 names ("BTC/USD"), REST names ("XXBTZUSD") and altnames ("XBTUSD").
+// Names without separator are split at a known quote asset, markets.Registry knows the names of all listed pairs.
+func ParsePair(name string) (Pair, error) {
+	if split := strings.Split(name, "/"); len(split) == 2 {
+		if split[0] == "" || split[1] == "" {
+			return Pair{}, fmt.Errorf("invalid pair %q", name)
+		}
+		return NewPair(split[0], split[1]), nil
+	}
+
+	upper := strings.ToUpper(name)
+	if pair, ok := byRESTPairName[upper]; ok {
+		return pair, nil
+	}
+
+	// a prefixed quote such as in XTZEUR is only a REST name if the base is prefixed as well
+	for legacy, quote := range byLegacyName {
+		if !strings.HasSuffix(upper, legacy) {
+			continue
+		}
+		if base, ok := byLegacyName[upper[:len(upper)-len(legacy)]]; ok {
+			return Pair{Base: base, Quote: quote}, nil
+		}
+	}
+
+	for _, quote := range quoteNames {
+		if len(upper) > len(quote) && strings.HasSuffix(upper, quote) {
+			return NewPair(upper[:len(upper)-len(quote)], quote), nil
+		}
+	}
+	return Pair{}, fmt.Errorf("invalid pair %q", name)
+}
+
+// WSName returns the name used by the v1 websocket, such as "XBT/USD".
+func (pair Pair) WSName() string {
+	return pair.Base.Name() + "/" + pair.Quote.Name()
+}
+
+// V2Name returns the name used by the v2 websocket, such as "BTC/USD".
+func (pair Pair) V2Name() string {
+	return pair.Base.V2Name() + "/" + pair.Quote.V2Name()
+}
+
+// Altname returns the name that REST requests accept, such as "XBTUSD".
+func (pair Pair) Altname() string {
+	return pair.Base.Name() + pair.Quote.Name()
+}
+
+// RESTName returns the name used as key in REST results, such as "XXBTZUSD". Only pairs of two legacy assets
+// have a prefixed name, for other pairs it is the altname with a few exceptions such as "USDTZUSD".
+func (pair Pair) RESTName() string {
+	if name, ok := restPairNames[pair]; ok {
+		return name
+	}
+
+	_, legacyBase := legacyNames[pair.Base]
+	_, legacyQuote := legacyNames[pair.Quote]
+
+	if legacyBase && legacyQuote {
+		return pair.Base.RESTName() + pair.Quote.RESTName()
+	}
+	return pair.Altname()
+}
+
+func (pair Pair) String() string {
+	return pair.WSName()
+}
+
+// WSNames returns the websocket names of pairs, for example for Subscribe.Pair.
+func WSNames(pairs ...Pair) []string {
+	names := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		names = append(names, pair.WSName())
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAsset(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected Asset
+	}{
+		{"XXBT", "XBT"},
+		{"XBT", "XBT"},
+		{"BTC", "XBT"},
+		{"btc", "XBT"},
+		{"ZEUR", "EUR"},
+		{"DOGE", "XDG"},
+		{"XXDG", "XDG"},
+		{"DOT", "DOT"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, ParseAsset(testCase.name), testCase.name)
+	}
+
+	assert.Equal(t, "XXBT", Asset("XBT").RESTName())
+	assert.Equal(t, "BTC", Asset("XBT").V2Name())
+	assert.Equal(t, "DOT", Asset("DOT").RESTName())
+}
+
+func TestParsePair(t *testing.T) {
+	xbtusd := Pair{Base: "XBT", Quote: "USD"}
+
+	testCases := []struct {
+		name     string
+		expected Pair
+	}{
+		{"XBT/USD", xbtusd},
+		{"BTC/USD", xbtusd},
+		{"XXBTZUSD", xbtusd},
+		{"XBTUSD", xbtusd},
+		{"XETHXXBT", Pair{Base: "ETH", Quote: "XBT"}},
+		{"ETHXBT", Pair{Base: "ETH", Quote: "XBT"}},
+		{"XBTUSDT", Pair{Base: "XBT", Quote: "USDT"}},
+		{"USDTZUSD", Pair{Base: "USDT", Quote: "USD"}},
+		{"DOTEUR", Pair{Base: "DOT", Quote: "EUR"}},
+		{"DOGE/USD", Pair{Base: "XDG", Quote: "USD"}},
+		// the suffix is a prefixed quote, but the base is not prefixed
+		{"XTZEUR", Pair{Base: "XTZ", Quote: "EUR"}},
+		{"XTZUSD", Pair{Base: "XTZ", Quote: "USD"}},
+		{"ZRXXBT", Pair{Base: "ZRX", Quote: "XBT"}},
+		{"XXDGXXBT", Pair{Base: "XDG", Quote: "XBT"}},
+	}
+
+	for _, testCase := range testCases {
+		pair, err := ParsePair(testCase.name)
+		assert.Nil(t, err, testCase.name)
+		assert.Equal(t, testCase.expected, pair, testCase.name)
+	}
+
+	for _, name := range []string{"", "XBT/", "FOOBAR", "USD"} {
+		_, err := ParsePair(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestPairNames(t *testing.T) {
+	testCases := []struct {
+		pair     Pair
+		ws       string
+		v2       string
+		altname  string
+		restName string
+	}{
+		{Pair{Base: "XBT", Quote: "USD"}, "XBT/USD", "BTC/USD", "XBTUSD", "XXBTZUSD"},
+		{Pair{Base: "ETH", Quote: "XBT"}, "ETH/XBT", "ETH/BTC", "ETHXBT", "XETHXXBT"},
+		{Pair{Base: "XBT", Quote: "CHF"}, "XBT/CHF", "BTC/CHF", "XBTCHF", "XBTCHF"},
+		{Pair{Base: "DOT", Quote: "EUR"}, "DOT/EUR", "DOT/EUR", "DOTEUR", "DOTEUR"},
+		{Pair{Base: "USDT", Quote: "USD"}, "USDT/USD", "USDT/USD", "USDTUSD", "USDTZUSD"},
+		{Pair{Base: "XTZ", Quote: "EUR"}, "XTZ/EUR", "XTZ/EUR", "XTZEUR", "XTZEUR"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.ws, testCase.pair.WSName())
+		assert.Equal(t, testCase.ws, testCase.pair.String())
+		assert.Equal(t, testCase.v2, testCase.pair.V2Name())
+		assert.Equal(t, testCase.altname, testCase.pair.Altname())
+		assert.Equal(t, testCase.restName, testCase.pair.RESTName())
+	}
+
+	assert.Equal(t, []string{"XBT/USD", "DOT/EUR"}, WSNames(NewPair("XXBT", "ZUSD"), NewPair("DOT", "EUR")))
+}
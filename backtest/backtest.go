@@ -0,0 +1,164 @@
+package backtest
+
+import (
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/lk16/kraken/fees"
+	"github.com/lk16/kraken/websocket"
+)
+
+// Event is a market data message as decoded by websocket.DecodeFrame, with the time it was received.
+type Event struct {
+	Time    time.Time
+	Message interface{}
+}
+
+// Strategy is called synchronously by Backtester, so a run is deterministic.
+type Strategy interface {
+	// OnMessage is called for every event, after resting orders were matched against it.
+	OnMessage(ctx *Context, message interface{})
+
+	// OnOrderUpdate is called when an order opens, fills, is canceled or is rejected.
+	OnOrderUpdate(ctx *Context, update OrderUpdate)
+}
+
+// LatencyModel returns the time between sending a request and its arrival at the simulated exchange.
+type LatencyModel func(random *rand.Rand) time.Duration
+
+func FixedLatency(latency time.Duration) LatencyModel {
+	return func(*rand.Rand) time.Duration {
+		return latency
+	}
+}
+
+// UniformLatency draws latencies uniformly between min and max from the seeded random source of the run.
+func UniformLatency(min time.Duration, max time.Duration) LatencyModel {
+	return func(random *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(random.Int63n(int64(max-min)))
+	}
+}
+
+// QueueModel decides how the volume ahead of a resting order shrinks when the book level loses volume.
+// Trades at the price of the order always consume the volume ahead first.
+type QueueModel int
+
+const (
+	// QueueBack assumes cancellations happen behind the order, so only trades move it forward.
+	QueueBack QueueModel = iota
+	// QueueProportional assumes cancellations are spread evenly over the level.
+	QueueProportional
+)
+
+type Option func(backtester *Backtester)
+
+// WithLatency sets the latency of order and cancel requests, which is zero by default.
+func WithLatency(model LatencyModel) Option {
+	return func(backtester *Backtester) {
+		backtester.latency = model
+	}
+}
+
+// WithSeed seeds the random source passed to the latency model.
+func WithSeed(seed int64) Option {
+	return func(backtester *Backtester) {
+		backtester.seed = seed
+	}
+}
+
+// WithQueueModel replaces the default QueueBack.
+func WithQueueModel(model QueueModel) Option {
+	return func(backtester *Backtester) {
+		backtester.queueModel = model
+	}
+}
+
+// WithFeeTiers replaces fees.DefaultTiers, tiers must be sorted by volume.
+func WithFeeTiers(tiers []fees.Tier) Option {
+	return func(backtester *Backtester) {
+		backtester.feeTiers = tiers
+	}
+}
+
+// WithTradedVolume sets the volume in the quote currency that was traded before, which selects the fee tier.
+func WithTradedVolume(volume float64) Option {
+	return func(backtester *Backtester) {
+		backtester.tradedVolume = volume
+	}
+}
+
+// WithInitialCash sets the quote currency balance at the start of a run.
+func WithInitialCash(cash float64) Option {
+	return func(backtester *Backtester) {
+		backtester.initialCash = cash
+	}
+}
+
+// Backtester replays market data through a Strategy and simulates the fills of its orders.
+//
+// Market and marketable limit orders take liquidity from the book when they arrive. Resting limit orders
+// join the back of their level and are filled as maker once trades consumed the volume ahead of them,
+// or when trades, candles or the book move through their price. All pairs are assumed to share a quote
+// currency, in which cash, fees and PnL are reported. Balances are not checked.
+type Backtester struct {
+	strategy     Strategy
+	latency      LatencyModel
+	seed         int64
+	queueModel   QueueModel
+	feeTiers     []fees.Tier
+	tradedVolume float64
+	initialCash  float64
+}
+
+func New(strategy Strategy, options ...Option) *Backtester {
+	backtester := &Backtester{
+		strategy: strategy,
+		latency:  FixedLatency(0),
+		seed:     1,
+		feeTiers: fees.DefaultTiers,
+	}
+
+	for _, option := range options {
+		option(backtester)
+	}
+	return backtester
+}
+
+// Run replays events, which must be sorted by time. Every run starts from the same simulated state and
+// random seed, so replaying the same events through a strategy in the same state gives the same Report.
+func (backtester *Backtester) Run(events []Event) Report {
+	run := newRun(backtester)
+	for _, event := range events {
+		run.handle(event)
+	}
+	return run.finish()
+}
+
+// RunRecording replays a plain or gzip compressed recording created with websocket.Recorder. Frames that can not
+// be decoded are skipped and counted in the report.
+func (backtester *Backtester) RunRecording(reader io.Reader) (Report, error) {
+	frames, err := websocket.ReadRecording(reader)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var skipped int
+
+	events := make([]Event, 0, len(frames))
+	for _, frame := range frames {
+		message, err := websocket.DecodeFrame([]byte(frame.Frame))
+		if err != nil {
+			skipped++
+			continue
+		}
+		events = append(events, Event{Time: frame.ReceivedAt, Message: message})
+	}
+
+	report := backtester.Run(events)
+	report.SkippedFrames = skipped
+	return report, nil
+}
@@ -0,0 +1,248 @@
+package backtest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStrategy struct {
+	onMessage     func(ctx *Context, message interface{})
+	onOrderUpdate func(ctx *Context, update OrderUpdate)
+	updates       []OrderUpdate
+}
+
+func (strategy *testStrategy) OnMessage(ctx *Context, message interface{}) {
+	if strategy.onMessage != nil {
+		strategy.onMessage(ctx, message)
+	}
+}
+
+func (strategy *testStrategy) OnOrderUpdate(ctx *Context, update OrderUpdate) {
+	strategy.updates = append(strategy.updates, update)
+	if strategy.onOrderUpdate != nil {
+		strategy.onOrderUpdate(ctx, update)
+	}
+}
+
+// orderOnce sends request when the first message is handled.
+func orderOnce(request Order) func(ctx *Context, message interface{}) {
+	sent := false
+	return func(ctx *Context, message interface{}) {
+		if !sent {
+			ctx.AddOrder(request)
+			sent = true
+		}
+	}
+}
+
+var testStart = time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func at(seconds float64) time.Time {
+	return testStart.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func testBook(bids []websocket.PriceLevel, asks []websocket.PriceLevel) websocket.Book {
+	return websocket.Book{ChannelID: 1, ChannelName: "book-10", Pair: "XBT/EUR", Data: websocket.BookData{Bids: bids, Asks: asks}}
+}
+
+func testBookUpdate(bids []websocket.PriceLevel) websocket.BookUpdate {
+	return websocket.BookUpdate{ChannelID: 1, ChannelName: "book-10", Pair: "XBT/EUR", Data: websocket.BookUpdateData{Bids: bids}}
+}
+
+func testTrade(price float64, volume float64, side string) websocket.Trade {
+	return websocket.Trade{Pair: "XBT/EUR", Data: []websocket.TradeData{
+		{Price: websocket.Float64String(price), Volume: websocket.Float64String(volume), Side: side},
+	}}
+}
+
+func testCandle(close float64) websocket.OHLC {
+	return websocket.OHLC{Pair: "XBT/EUR", Data: websocket.OHLCData{
+		Open:  websocket.Float64String(close),
+		High:  websocket.Float64String(close),
+		Low:   websocket.Float64String(close),
+		Close: websocket.Float64String(close),
+	}}
+}
+
+func TestQueuePositionAndLatency(t *testing.T) {
+	strategy := &testStrategy{onMessage: orderOnce(Order{Pair: "XBT/EUR", Side: "buy", Type: "limit", Price: 100, Volume: 1})}
+
+	report := New(strategy, WithLatency(FixedLatency(time.Second))).Run([]Event{
+		{Time: at(0), Message: testBook([]websocket.PriceLevel{{Price: 100, Volume: 2}}, []websocket.PriceLevel{{Price: 101, Volume: 1}})},
+		// the order did not arrive yet
+		{Time: at(0.5), Message: testTrade(100, 5, "s")},
+		// the order arrived behind 2 and is now behind 0.5
+		{Time: at(2), Message: testTrade(100, 1.5, "s")},
+		{Time: at(3), Message: testTrade(100, 1, "s")},
+		{Time: at(4), Message: testTrade(99, 5, "s")},
+	})
+
+	assert.Equal(t, 5, report.Events)
+	assert.Equal(t, 1, report.Orders)
+	assert.Equal(t, []Fill{
+		{OrderID: "OBTEST-00001-ORDERS", Pair: "XBT/EUR", Side: "buy", Price: 100, Volume: 0.5, Fee: 0.08, Maker: true, Time: at(3)},
+		{OrderID: "OBTEST-00001-ORDERS", Pair: "XBT/EUR", Side: "buy", Price: 100, Volume: 0.5, Fee: 0.08, Maker: true, Time: at(4)},
+	}, report.Fills)
+	assert.Equal(t, 1.0, report.FillRatio)
+	assert.InDelta(t, 0.16, report.Fees, 1e-9)
+	assert.Equal(t, map[string]float64{"XBT/EUR": 1}, report.Positions)
+
+	// valued at the mid price of 100.5
+	assert.InDelta(t, 0.34, report.PnL, 1e-9)
+
+	var statuses []OrderStatus
+	for _, update := range strategy.updates {
+		statuses = append(statuses, update.Order.Status)
+	}
+	assert.Equal(t, []OrderStatus{OrderOpen, OrderOpen, OrderFilled}, statuses)
+	assert.Equal(t, at(1), strategy.updates[0].Order.OpenedAt)
+}
+
+func TestQueueModels(t *testing.T) {
+	testCases := []struct {
+		name   string
+		model  QueueModel
+		filled float64
+	}{
+		{name: "back", model: QueueBack, filled: 0},
+		{name: "proportional", model: QueueProportional, filled: 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			strategy := &testStrategy{onMessage: orderOnce(Order{Pair: "XBT/EUR", Side: "buy", Type: "limit", Price: 100, Volume: 1})}
+
+			report := New(strategy, WithQueueModel(testCase.model)).Run([]Event{
+				{Time: at(0), Message: testBook([]websocket.PriceLevel{{Price: 100, Volume: 4}}, []websocket.PriceLevel{{Price: 101, Volume: 1}})},
+				// others join behind the order, then half of the level is canceled
+				{Time: at(1), Message: testBookUpdate([]websocket.PriceLevel{{Price: 100, Volume: 8}})},
+				{Time: at(2), Message: testBookUpdate([]websocket.PriceLevel{{Price: 100, Volume: 4}})},
+				{Time: at(3), Message: testTrade(100, 3, "s")},
+			})
+
+			assert.Equal(t, testCase.filled, report.FilledVolume)
+		})
+	}
+}
+
+func TestTakerFillsAndDrawdown(t *testing.T) {
+	strategy := &testStrategy{onMessage: orderOnce(Order{Pair: "XBT/EUR", Side: "buy", Type: "market", Volume: 1})}
+
+	report := New(strategy, WithInitialCash(1000)).Run([]Event{
+		{Time: at(0), Message: testCandle(100)},
+		{Time: at(60), Message: testCandle(90)},
+		{Time: at(120), Message: testCandle(95)},
+		{Time: at(180), Message: testCandle(110)},
+	})
+
+	assert.Len(t, report.Fills, 1)
+	assert.False(t, report.Fills[0].Maker)
+	assert.InDelta(t, 0.26, report.Fees, 1e-9)
+	assert.InDelta(t, 1009.74, report.FinalEquity, 1e-9)
+	assert.InDelta(t, 9.74, report.PnL, 1e-9)
+	assert.InDelta(t, 10.26, report.MaxDrawdown, 1e-9)
+	assert.InDelta(t, 1.026, report.MaxDrawdownPercent, 1e-9)
+	assert.Len(t, report.Equity, 4)
+}
+
+func TestOrderRejectionsAndCancel(t *testing.T) {
+	book := testBook([]websocket.PriceLevel{{Price: 100, Volume: 1}}, []websocket.PriceLevel{{Price: 101, Volume: 1}})
+
+	var restingID string
+	strategy := &testStrategy{
+		onMessage: orderOnce(Order{Pair: "XBT/EUR", Side: "buy", Type: "limit", Price: 101, Volume: 1, PostOnly: true}),
+		onOrderUpdate: func(ctx *Context, update OrderUpdate) {
+			switch update.Order.Status {
+			case OrderRejected:
+				restingID = ctx.AddOrder(Order{Pair: "XBT/EUR", Side: "buy", Type: "limit", Price: 99, Volume: 2})
+			case OrderOpen:
+				ctx.CancelOrder(update.Order.ID)
+			}
+		},
+	}
+
+	report := New(strategy, WithLatency(FixedLatency(time.Second))).Run([]Event{
+		{Time: at(0), Message: book},
+		{Time: at(5), Message: testTrade(98, 1, "s")},
+	})
+
+	assert.Equal(t, 2, report.Orders)
+	assert.Equal(t, 3.0, report.OrderedVolume)
+	assert.Equal(t, 0.0, report.FillRatio)
+
+	assert.Equal(t, OrderRejected, report.OrderList[0].Status)
+	assert.Equal(t, "post only order would cross", report.OrderList[0].Reason)
+
+	// the cancel was sent when the order opened at 2s and arrived before the trade
+	assert.Equal(t, restingID, report.OrderList[1].ID)
+	assert.Equal(t, OrderCanceled, report.OrderList[1].Status)
+	assert.Equal(t, at(2), report.OrderList[1].OpenedAt)
+}
+
+func TestRunRecordingIsDeterministic(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder := websocket.NewRecorder(&buffer)
+
+	frames := []string{
+		`[1,{"as":[["101.00000","1.00000000","1534614248.123678"]],"bs":[["100.00000","1.00000000","1534614248.765567"]]},"book-10","XBT/EUR"]`,
+		`[2,[["100.00000","0.50000000","1534614249.000000","s","l",""]],"trade","XBT/EUR"]`,
+		`[1,{"b":[["100.00000","0.50000000","1534614249.000000"]]},"book-10","XBT/EUR"]`,
+		`[2,[["99.50000","2.00000000","1534614250.000000","s","m",""]],"trade","XBT/EUR"]`,
+	}
+	for index, frame := range frames {
+		assert.Nil(t, recorder.Record("public", at(float64(index)), []byte(frame)))
+	}
+	assert.Nil(t, recorder.Close())
+
+	run := func() Report {
+		strategy := &testStrategy{onMessage: orderOnce(Order{Pair: "XBT/EUR", Side: "buy", Type: "limit", Price: 100, Volume: 1})}
+		backtester := New(strategy, WithLatency(UniformLatency(0, 500*time.Millisecond)), WithSeed(42))
+
+		report, err := backtester.RunRecording(bytes.NewReader(buffer.Bytes()))
+		assert.Nil(t, err)
+		return report
+	}
+
+	first := run()
+	second := run()
+
+	assert.Equal(t, 4, first.Events)
+	assert.Equal(t, 1.0, first.FilledVolume)
+	assert.Equal(t, first, second)
+
+	// frames that can not be decoded are skipped
+	assert.Nil(t, recorder.Record("public", at(4), []byte("not json")))
+	assert.Nil(t, recorder.Close())
+
+	report, err := New(&testStrategy{}).RunRecording(bytes.NewReader(buffer.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, report.Events)
+	assert.Equal(t, 1, report.SkippedFrames)
+}
+
+func TestCandleFillsAreCapped(t *testing.T) {
+	strategy := &testStrategy{onMessage: orderOnce(Order{Pair: "XBT/EUR", Side: "buy", Type: "limit", Price: 100, Volume: 1})}
+
+	candle := func(volume float64) websocket.OHLC {
+		ohlc := testCandle(99)
+		ohlc.Data.EndTime = websocket.UnixTime(at(60))
+		ohlc.Data.Volume = websocket.Float64String(volume)
+		return ohlc
+	}
+
+	report := New(strategy).Run([]Event{
+		{Time: at(0), Message: testBook([]websocket.PriceLevel{{Price: 100, Volume: 2}}, []websocket.PriceLevel{{Price: 101, Volume: 1}})},
+		// 2 is ahead of the order
+		{Time: at(1), Message: candle(2.5)},
+		// updates of the same candle only add the volume traded since
+		{Time: at(2), Message: candle(2.75)},
+	})
+
+	assert.Len(t, report.Fills, 2)
+	assert.InDelta(t, 0.5, report.Fills[0].Volume, 1e-9)
+	assert.InDelta(t, 0.25, report.Fills[1].Volume, 1e-9)
+}
@@ -0,0 +1,241 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/lk16/kraken/internal/matching"
+	"github.com/lk16/kraken/websocket"
+)
+
+func levelVolume(levels []websocket.PriceLevel, price float64) float64 {
+	for _, level := range levels {
+		if float64(level.Price) == price {
+			return float64(level.Volume)
+		}
+	}
+	return 0
+}
+
+// ownLevels returns the side of the book the order rests on.
+func ownLevels(book websocket.Book, buy bool) []websocket.PriceLevel {
+	if buy {
+		return book.Data.Bids
+	}
+	return book.Data.Asks
+}
+
+// open handles an order arriving at the exchange.
+func (run *run) open(order *order) {
+	order.Status = OrderOpen
+	order.OpenedAt = run.now
+
+	book, hasBook := run.books.Snapshot(order.Pair)
+
+	if order.Type == "market" {
+		if !hasBook {
+			price, ok := run.prices[order.Pair]
+			if !ok {
+				run.close(order, OrderRejected, "no liquidity")
+				return
+			}
+			run.fill(order, price, order.remaining(), false)
+			return
+		}
+
+		run.takeLiquidity(order, book)
+		if order.remaining() != 0 {
+			run.close(order, OrderCanceled, "insufficient liquidity")
+		}
+		return
+	}
+
+	if hasBook {
+		opposite := matching.Opposite(book, order.buy())
+
+		if len(opposite) != 0 && matching.Crossed(order.buy(), float64(opposite[0].Price), order.Price) {
+			if order.PostOnly {
+				run.close(order, OrderRejected, "post only order would cross")
+				return
+			}
+
+			run.takeLiquidity(order, book)
+			if order.remaining() == 0 {
+				return
+			}
+		}
+
+		order.queueAhead = levelVolume(ownLevels(book, order.buy()), order.Price)
+	}
+
+	run.update(order, nil)
+}
+
+func (run *run) takeLiquidity(order *order, book websocket.Book) {
+	run.liquidity.Take(order.Pair, book, order.buy(), order.Type == "market", order.Price, order.remaining(), func(price float64, volume float64) {
+		run.fill(order, price, volume, false)
+	})
+}
+
+// restingOrders returns the open limit orders of pair in time priority.
+func (run *run) restingOrders(pair string) []*order {
+	var resting []*order
+	for _, orderID := range run.orderIDs {
+		order := run.orders[orderID]
+		if order.Status == OrderOpen && order.Pair == pair && order.Type == "limit" {
+			resting = append(resting, order)
+		}
+	}
+	return resting
+}
+
+// bookChanged moves resting orders forward in their queue when their level lost volume, and fills them
+// when the other side of the book moved through their price. Before is nil for snapshots.
+func (run *run) bookChanged(pair string, before *websocket.Book) {
+	run.liquidity.Reset(pair)
+
+	resting := run.restingOrders(pair)
+	if len(resting) == 0 {
+		return
+	}
+
+	book, ok := run.books.Snapshot(pair)
+	if !ok {
+		return
+	}
+
+	for _, order := range resting {
+		volume := levelVolume(ownLevels(book, order.buy()), order.Price)
+
+		if before != nil && run.queueModel == QueueProportional {
+			previous := levelVolume(ownLevels(*before, order.buy()), order.Price)
+			if previous > 0 && volume < previous {
+				order.queueAhead *= volume / previous
+			}
+		}
+
+		if order.queueAhead > volume {
+			order.queueAhead = volume
+		}
+	}
+
+	// the book moving through a resting order fills it at its own price
+	for _, order := range resting {
+		run.liquidity.Take(pair, book, order.buy(), false, order.Price, order.remaining(), func(_ float64, volume float64) {
+			order.queueAhead = 0
+			run.fill(order, order.Price, volume, true)
+		})
+	}
+}
+
+// matchTrade fills resting orders in time priority. Trades at their price first consume the volume ahead
+// of them, trades through their price mean the level was cleared.
+func (run *run) matchTrade(pair string, trade websocket.TradeData) {
+	available := float64(trade.Volume)
+	tradePrice := float64(trade.Price)
+
+	hitsBids := matching.HitsBids(trade)
+
+	for _, order := range run.restingOrders(pair) {
+		if order.buy() != hitsBids {
+			continue
+		}
+
+		var fillable float64
+
+		switch {
+		case tradePrice == order.Price:
+			if order.queueAhead >= float64(trade.Volume) {
+				order.queueAhead -= float64(trade.Volume)
+				continue
+			}
+			fillable = float64(trade.Volume) - order.queueAhead
+			order.queueAhead = 0
+		case matching.Crossed(order.buy(), tradePrice, order.Price):
+			fillable = available
+			order.queueAhead = 0
+		default:
+			continue
+		}
+
+		if fillable > available {
+			fillable = available
+		}
+
+		volume := order.remaining()
+		if fillable < volume {
+			volume = fillable
+		}
+		if volume <= 0 {
+			continue
+		}
+		available -= volume
+
+		run.fill(order, order.Price, volume, true)
+	}
+}
+
+// matchCandle fills resting orders whose price the candle traded through with the volume traded since the previous
+// update of the candle, after the volume ahead of them.
+func (run *run) matchCandle(pair string, candle websocket.OHLCData) {
+	available := float64(candle.Volume)
+	if previous, ok := run.candles[pair]; ok && time.Time(previous.EndTime).Equal(time.Time(candle.EndTime)) {
+		available -= float64(previous.Volume)
+	}
+	run.candles[pair] = candle
+
+	for _, order := range run.restingOrders(pair) {
+		tradedThrough := (order.buy() && float64(candle.Low) < order.Price) || (!order.buy() && float64(candle.High) > order.Price)
+		if !tradedThrough {
+			continue
+		}
+
+		ahead := math.Min(order.queueAhead, available)
+		order.queueAhead -= ahead
+		available -= ahead
+
+		volume := math.Min(order.remaining(), available)
+		if volume <= 0 {
+			continue
+		}
+		available -= volume
+
+		run.fill(order, order.Price, volume, true)
+	}
+}
+
+func (run *run) fill(order *order, price float64, volume float64, maker bool) {
+	cost, fee := run.fees.Charge(price, volume, maker)
+
+	order.Executed += volume
+	order.Cost += cost
+	order.Fee += fee
+
+	if order.buy() {
+		run.positions[order.Pair] += volume
+		run.cash -= cost + fee
+	} else {
+		run.positions[order.Pair] -= volume
+		run.cash += cost - fee
+	}
+
+	run.report.FilledVolume += volume
+	run.report.Fees += fee
+
+	fill := &Fill{
+		OrderID: order.ID,
+		Pair:    order.Pair,
+		Side:    order.Side,
+		Price:   price,
+		Volume:  volume,
+		Fee:     fee,
+		Maker:   maker,
+		Time:    run.now,
+	}
+	run.report.Fills = append(run.report.Fills, *fill)
+
+	if order.remaining() == 0 {
+		order.Status = OrderFilled
+	}
+	run.update(order, fill)
+}
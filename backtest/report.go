@@ -0,0 +1,81 @@
+package backtest
+
+import "time"
+
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+// Report summarizes a run. All amounts are in the quote currency.
+type Report struct {
+	Start  time.Time
+	End    time.Time
+	Events int
+	// SkippedFrames is the number of recorded frames that could not be decoded.
+	SkippedFrames int
+
+	Orders        int
+	OrderedVolume float64
+	FilledVolume  float64
+	// FillRatio is the filled part of the ordered volume.
+	FillRatio float64
+	Fees      float64
+
+	InitialCash float64
+	FinalEquity float64
+	// PnL is the change in equity after fees, with open positions valued at their mark price.
+	PnL float64
+	// MaxDrawdown is the largest fall of equity from a preceding peak.
+	MaxDrawdown        float64
+	MaxDrawdownPercent float64
+
+	Positions map[string]float64
+	// Equity holds a point for every event that changed the equity.
+	Equity    []EquityPoint
+	OrderList []Order
+	Fills     []Fill
+}
+
+func (run *run) recordEquity() {
+	equity := run.equity()
+
+	points := run.report.Equity
+	if len(points) == 0 || points[len(points)-1].Equity != equity {
+		run.report.Equity = append(points, EquityPoint{Time: run.now, Equity: equity})
+	}
+
+	if equity > run.equityPeak {
+		run.equityPeak = equity
+	}
+
+	drawdown := run.equityPeak - equity
+	if drawdown > run.report.MaxDrawdown {
+		run.report.MaxDrawdown = drawdown
+		if run.equityPeak > 0 {
+			run.report.MaxDrawdownPercent = 100 * drawdown / run.equityPeak
+		}
+	}
+}
+
+func (run *run) finish() Report {
+	report := run.report
+
+	if report.OrderedVolume > 0 {
+		report.FillRatio = report.FilledVolume / report.OrderedVolume
+	}
+
+	report.InitialCash = run.initialCash
+	report.FinalEquity = run.equity()
+	report.PnL = report.FinalEquity - run.initialCash
+
+	report.Positions = make(map[string]float64)
+	for pair, position := range run.positions {
+		report.Positions[pair] = position
+	}
+
+	for _, orderID := range run.orderIDs {
+		report.OrderList = append(report.OrderList, run.orders[orderID].Order)
+	}
+	return report
+}
@@ -0,0 +1,374 @@
+package backtest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/lk16/kraken/internal/matching"
+	"github.com/lk16/kraken/websocket"
+)
+
+type OrderStatus string
+
+const (
+	// OrderPending orders were sent, but did not arrive at the simulated exchange yet.
+	OrderPending  OrderStatus = "pending"
+	OrderOpen     OrderStatus = "open"
+	OrderFilled   OrderStatus = "closed"
+	OrderCanceled OrderStatus = "canceled"
+	OrderRejected OrderStatus = "rejected"
+)
+
+// Order is both the request passed to Context.AddOrder() and the state reported back.
+// Side is "buy" or "sell", Type is "market" or "limit".
+type Order struct {
+	ID       string
+	Pair     string
+	Side     string
+	Type     string
+	Price    float64
+	Volume   float64
+	PostOnly bool
+
+	Status   OrderStatus
+	Reason   string
+	Executed float64
+	Cost     float64
+	Fee      float64
+	SentAt   time.Time
+	OpenedAt time.Time
+}
+
+func (order *Order) AveragePrice() float64 {
+	if order.Executed == 0 {
+		return 0
+	}
+	return order.Cost / order.Executed
+}
+
+// Fill is a single execution of an order, Fee is in the quote currency.
+type Fill struct {
+	OrderID string
+	Pair    string
+	Side    string
+	Price   float64
+	Volume  float64
+	Fee     float64
+	Maker   bool
+	Time    time.Time
+}
+
+// OrderUpdate holds the order after a change, Fill is set if the change was an execution.
+type OrderUpdate struct {
+	Order Order
+	Fill  *Fill
+}
+
+type order struct {
+	Order
+	queueAhead float64
+}
+
+func (order *order) buy() bool {
+	return order.Side == "buy"
+}
+
+func (order *order) remaining() float64 {
+	remaining := order.Volume - order.Executed
+	if remaining <= order.Volume*1e-9 {
+		return 0
+	}
+	return remaining
+}
+
+// action is an order or cancel request on its way to the simulated exchange.
+type action struct {
+	at       time.Time
+	sequence int
+	orderID  string
+	cancel   bool
+}
+
+type run struct {
+	*Backtester
+
+	context      *Context
+	random       *rand.Rand
+	now          time.Time
+	books        *websocket.BookManager
+	liquidity    *matching.Liquidity
+	candles      map[string]websocket.OHLCData
+	prices       map[string]float64
+	actions      []action
+	lastSequence int
+	orders       map[string]*order
+	orderIDs     []string
+	lastOrderID  int
+	updates      []OrderUpdate
+	positions    map[string]float64
+	cash         float64
+	fees         matching.Fees
+	equityPeak   float64
+	report       Report
+}
+
+func newRun(backtester *Backtester) *run {
+	run := &run{
+		Backtester: backtester,
+		random:     rand.New(rand.NewSource(backtester.seed)),
+		books:      websocket.NewBookManager(nil, 0),
+		liquidity:  matching.NewLiquidity(),
+		candles:    make(map[string]websocket.OHLCData),
+		prices:     make(map[string]float64),
+		orders:     make(map[string]*order),
+		positions:  make(map[string]float64),
+		cash:       backtester.initialCash,
+		fees:       matching.Fees{Tiers: backtester.feeTiers, Volume: backtester.tradedVolume},
+		equityPeak: backtester.initialCash,
+	}
+	run.context = &Context{run: run}
+	return run
+}
+
+func (run *run) handle(event Event) {
+	run.advance(event.Time)
+	run.now = event.Time
+
+	if run.report.Events == 0 {
+		run.report.Start = event.Time
+	}
+	run.report.Events++
+	run.report.End = event.Time
+
+	switch message := event.Message.(type) {
+	case websocket.Book:
+		if run.books.HandleAt(message, event.Time) == nil {
+			run.bookChanged(message.Pair, nil)
+		}
+	case websocket.BookUpdate:
+		before, ok := run.books.Snapshot(message.Pair)
+		if run.books.HandleAt(message, event.Time) == nil && ok {
+			run.bookChanged(message.Pair, &before)
+		}
+	case websocket.Trade:
+		for _, trade := range message.Data {
+			run.prices[message.Pair] = float64(trade.Price)
+			run.matchTrade(message.Pair, trade)
+		}
+	case websocket.OHLC:
+		run.prices[message.Pair] = float64(message.Data.Close)
+		run.matchCandle(message.Pair, message.Data)
+	}
+
+	run.advance(run.now)
+	run.strategy.OnMessage(run.context, event.Message)
+	run.advance(run.now)
+	run.recordEquity()
+}
+
+// advance executes the requests arriving at the exchange until the given time and delivers the resulting
+// order updates, which may send new requests.
+func (run *run) advance(until time.Time) {
+	for {
+		if len(run.actions) != 0 && !run.actions[0].at.After(until) {
+			action := run.actions[0]
+			run.actions = run.actions[1:]
+			run.execute(action)
+			continue
+		}
+
+		if len(run.updates) != 0 {
+			update := run.updates[0]
+			run.updates = run.updates[1:]
+			run.strategy.OnOrderUpdate(run.context, update)
+			continue
+		}
+		return
+	}
+}
+
+func (run *run) execute(action action) {
+	if action.at.After(run.now) {
+		run.now = action.at
+	}
+
+	order, ok := run.orders[action.orderID]
+	if !ok {
+		return
+	}
+
+	if !action.cancel {
+		run.open(order)
+		return
+	}
+
+	if order.Status == OrderOpen {
+		run.close(order, OrderCanceled, "")
+	}
+}
+
+func (run *run) schedule(orderID string, cancel bool) {
+	run.lastSequence++
+	scheduled := action{
+		at:       run.now.Add(run.latency(run.random)),
+		sequence: run.lastSequence,
+		orderID:  orderID,
+		cancel:   cancel,
+	}
+
+	run.actions = append(run.actions, scheduled)
+	sort.SliceStable(run.actions, func(i, j int) bool {
+		if run.actions[i].at.Equal(run.actions[j].at) {
+			return run.actions[i].sequence < run.actions[j].sequence
+		}
+		return run.actions[i].at.Before(run.actions[j].at)
+	})
+}
+
+func (run *run) update(order *order, fill *Fill) {
+	run.updates = append(run.updates, OrderUpdate{Order: order.Order, Fill: fill})
+}
+
+func (run *run) close(order *order, status OrderStatus, reason string) {
+	order.Status = status
+	order.Reason = reason
+	run.update(order, nil)
+}
+
+func validate(request Order) string {
+	switch {
+	case request.Side != "buy" && request.Side != "sell":
+		return fmt.Sprintf("invalid side %q", request.Side)
+	case request.Type != "market" && request.Type != "limit":
+		return fmt.Sprintf("invalid order type %q", request.Type)
+	case request.Volume <= 0:
+		return "volume must be positive"
+	case request.Type == "limit" && request.Price <= 0:
+		return "limit price must be positive"
+	}
+	return ""
+}
+
+// markPrice is the mid price of the book, or the last trade or candle close if there is no book.
+func (run *run) markPrice(pair string) (float64, bool) {
+	if book, ok := run.books.Snapshot(pair); ok && len(book.Data.Asks) != 0 && len(book.Data.Bids) != 0 {
+		return (float64(book.Data.Asks[0].Price) + float64(book.Data.Bids[0].Price)) / 2, true
+	}
+
+	price, ok := run.prices[pair]
+	return price, ok
+}
+
+func (run *run) equity() float64 {
+	pairs := make([]string, 0, len(run.positions))
+	for pair := range run.positions {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	equity := run.cash
+	for _, pair := range pairs {
+		if price, ok := run.markPrice(pair); ok {
+			equity += run.positions[pair] * price
+		}
+	}
+	return equity
+}
+
+// Context gives a Strategy access to the simulated exchange during a run.
+type Context struct {
+	run *run
+}
+
+// Now returns the time of the event or arriving request being handled.
+func (ctx *Context) Now() time.Time {
+	return ctx.run.now
+}
+
+// Book returns a copy of the current book of pair.
+func (ctx *Context) Book(pair string) (websocket.Book, bool) {
+	return ctx.run.books.Snapshot(pair)
+}
+
+// MarkPrice returns the price at which positions in pair are valued.
+func (ctx *Context) MarkPrice(pair string) (float64, bool) {
+	return ctx.run.markPrice(pair)
+}
+
+// Position returns the base currency volume held in pair, which is negative for short positions.
+func (ctx *Context) Position(pair string) float64 {
+	return ctx.run.positions[pair]
+}
+
+// Cash returns the quote currency balance.
+func (ctx *Context) Cash() float64 {
+	return ctx.run.cash
+}
+
+// Equity returns the cash plus the positions valued at their mark price.
+func (ctx *Context) Equity() float64 {
+	return ctx.run.equity()
+}
+
+// AddOrder sends an order, only Pair, Side, Type, Price, Volume and PostOnly of request are used.
+// The order arrives at the exchange after the latency of the run, its ID is returned right away.
+func (ctx *Context) AddOrder(request Order) string {
+	run := ctx.run
+
+	run.lastOrderID++
+	sent := &order{Order: Order{
+		ID:       fmt.Sprintf("OBTEST-%05d-ORDERS", run.lastOrderID),
+		Pair:     request.Pair,
+		Side:     request.Side,
+		Type:     request.Type,
+		Price:    request.Price,
+		Volume:   request.Volume,
+		PostOnly: request.PostOnly,
+		Status:   OrderPending,
+		SentAt:   run.now,
+	}}
+
+	run.orders[sent.ID] = sent
+	run.orderIDs = append(run.orderIDs, sent.ID)
+	run.report.Orders++
+	run.report.OrderedVolume += sent.Volume
+
+	if reason := validate(sent.Order); reason != "" {
+		run.close(sent, OrderRejected, reason)
+		return sent.ID
+	}
+
+	run.schedule(sent.ID, false)
+	return sent.ID
+}
+
+// CancelOrder sends a cancel request, which arrives at the exchange after the latency of the run.
+// Orders that were filled before it arrives stay filled.
+func (ctx *Context) CancelOrder(orderID string) {
+	if _, ok := ctx.run.orders[orderID]; ok {
+		ctx.run.schedule(orderID, true)
+	}
+}
+
+// Order returns the current state of an order.
+func (ctx *Context) Order(orderID string) (Order, bool) {
+	order, ok := ctx.run.orders[orderID]
+	if !ok {
+		return Order{}, false
+	}
+	return order.Order, true
+}
+
+// OpenOrders returns the pending and open orders in the order they were sent.
+func (ctx *Context) OpenOrders() []Order {
+	var open []Order
+	for _, orderID := range ctx.run.orderIDs {
+		order := ctx.run.orders[orderID]
+		if order.Status == OrderPending || order.Status == OrderOpen {
+			open = append(open, order.Order)
+		}
+	}
+	return open
+}
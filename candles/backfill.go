@@ -0,0 +1,113 @@
+package candles
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/rest"
+)
+
+const tradesPageSize = 1000
+
+var ohlcIntervals = []int{1, 5, 15, 30, 60, 240, 1440, 10080, 21600}
+
+type HistorySource interface {
+	OHLC(pair string, interval int, since time.Time) (rest.OHLCResult, error)
+	Trades(pair string, since time.Time) (rest.TradesResult, error)
+}
+
+func (builder *Builder) ohlcInterval() (int, bool) {
+	if builder.kind != timeBars {
+		return 0, false
+	}
+
+	for _, interval := range ohlcIntervals {
+		if time.Duration(interval)*time.Minute == builder.interval {
+			return interval, true
+		}
+	}
+	return 0, false
+}
+
+// Backfill returns the closed candles of pair since the given time, the last one stays open for live trades.
+// The OHLC endpoint only returns the last 720 candles, other sizes page through the Trades endpoint.
+func (builder *Builder) Backfill(source HistorySource, pair string, since time.Time) ([]Candle, error) {
+	restPair := strings.ReplaceAll(pair, "/", "")
+	if parsed, err := assets.ParsePair(pair); err == nil {
+		restPair = parsed.Altname()
+	}
+
+	if interval, ok := builder.ohlcInterval(); ok {
+		return builder.backfillOHLC(source, pair, restPair, interval, since)
+	}
+	return builder.backfillTrades(source, pair, restPair, since)
+}
+
+func (builder *Builder) backfillTrades(source HistorySource, pair string, restPair string, since time.Time) ([]Candle, error) {
+	var events []interface{}
+
+	cursor := since
+	for {
+		result, err := source.Trades(restPair, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("could not get trades: %w", err)
+		}
+
+		for _, trade := range result.Trades {
+			events = append(events, builder.AddTrade(pair, float64(trade.Price), float64(trade.Volume), time.Time(trade.Time))...)
+		}
+
+		if len(result.Trades) < tradesPageSize || !result.Last.After(cursor) {
+			break
+		}
+		cursor = result.Last
+	}
+
+	closed := make([]Candle, 0, len(events))
+	for _, event := range events {
+		closed = append(closed, event.(CandleClosed).Candle)
+	}
+	return closed, nil
+}
+
+func (builder *Builder) backfillOHLC(source HistorySource, pair string, restPair string, interval int, since time.Time) ([]Candle, error) {
+	result, err := source.OHLC(restPair, interval, since)
+	if err != nil {
+		return nil, fmt.Errorf("could not get OHLC: %w", err)
+	}
+
+	var closed []Candle
+	for index, ohlc := range result.Candles {
+		start := time.Time(ohlc.Time)
+
+		// the candle in progress is rebuilt from its trades, which include any live trades already added
+		if index == len(result.Candles)-1 {
+			builder.mutex.Lock()
+			delete(builder.candles, pair)
+			delete(builder.lastTrades, pair)
+			builder.mutex.Unlock()
+
+			current, err := builder.backfillTrades(source, pair, restPair, start)
+			if err != nil {
+				return nil, err
+			}
+			return append(closed, current...), nil
+		}
+
+		closed = append(closed, Candle{
+			Pair:   pair,
+			Start:  start,
+			End:    start.Add(builder.interval),
+			Open:   float64(ohlc.Open),
+			High:   float64(ohlc.High),
+			Low:    float64(ohlc.Low),
+			Close:  float64(ohlc.Close),
+			Volume: float64(ohlc.Volume),
+			VWAP:   float64(ohlc.VWAP),
+			Count:  ohlc.Count,
+		})
+	}
+	return closed, nil
+}
@@ -0,0 +1,190 @@
+package candles
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+)
+
+type barKind int
+
+const (
+	timeBars barKind = iota
+	volumeBars
+	tickBars
+)
+
+// Builder aggregates trades into candles of a fixed duration, volume or number of trades.
+type Builder struct {
+	mutex       sync.Mutex
+	kind        barKind
+	interval    time.Duration
+	volume      float64
+	trades      int64
+	candles     map[string]*Candle
+	lastTrades  map[string]time.Time
+	closedUntil map[string]time.Time
+}
+
+func newBuilder(kind barKind) *Builder {
+	return &Builder{
+		kind:        kind,
+		candles:     make(map[string]*Candle),
+		lastTrades:  make(map[string]time.Time),
+		closedUntil: make(map[string]time.Time),
+	}
+}
+
+// SizeError is returned by the constructors for candle sizes that are not positive.
+type SizeError struct {
+	Size string
+}
+
+func (err SizeError) Error() string {
+	return fmt.Sprintf("non-positive candle size %s", err.Size)
+}
+
+func NewTimeBuilder(interval time.Duration) (*Builder, error) {
+	if interval <= 0 {
+		return nil, SizeError{Size: interval.String()}
+	}
+
+	builder := newBuilder(timeBars)
+	builder.interval = interval
+	return builder, nil
+}
+
+func NewVolumeBuilder(volume float64) (*Builder, error) {
+	if volume <= 0 {
+		return nil, SizeError{Size: fmt.Sprintf("%v volume", volume)}
+	}
+
+	builder := newBuilder(volumeBars)
+	builder.volume = volume
+	return builder, nil
+}
+
+func NewTickBuilder(trades int64) (*Builder, error) {
+	if trades <= 0 {
+		return nil, SizeError{Size: fmt.Sprintf("%d trades", trades)}
+	}
+
+	builder := newBuilder(tickBars)
+	builder.trades = trades
+	return builder, nil
+}
+
+func (builder *Builder) Handle(rawMessage interface{}) []interface{} {
+	message, ok := rawMessage.(websocket.Trade)
+	if !ok {
+		return nil
+	}
+
+	var events []interface{}
+	for _, trade := range message.Data {
+		events = append(events, builder.AddTrade(message.Pair, float64(trade.Price), float64(trade.Volume), time.Time(trade.Time))...)
+	}
+	return events
+}
+
+// AddTrade ignores trades older than the last trade of pair, so live trades overlapping a Backfill() count once.
+func (builder *Builder) AddTrade(pair string, price float64, volume float64, at time.Time) []interface{} {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	if last, ok := builder.lastTrades[pair]; ok && at.Before(last) {
+		return nil
+	}
+	if at.Before(builder.closedUntil[pair]) {
+		return nil
+	}
+	builder.lastTrades[pair] = at
+
+	var events []interface{}
+
+	candle := builder.candles[pair]
+
+	if builder.kind == timeBars {
+		start := alignStart(at, builder.interval)
+		if candle != nil && !candle.Start.Equal(start) {
+			events = append(events, builder.close(pair))
+			candle = nil
+		}
+
+		if candle == nil {
+			candle = &Candle{Pair: pair, Start: start, End: start.Add(builder.interval)}
+			builder.candles[pair] = candle
+		}
+
+		candle.add(price, volume)
+		return events
+	}
+
+	if candle == nil {
+		candle = &Candle{Pair: pair, Start: at}
+		builder.candles[pair] = candle
+	}
+
+	candle.add(price, volume)
+	candle.End = at
+
+	if (builder.kind == volumeBars && candle.Volume >= builder.volume) || (builder.kind == tickBars && candle.Count >= builder.trades) {
+		events = append(events, builder.close(pair))
+	}
+	return events
+}
+
+// alignStart aligns to multiples of interval since the Unix epoch, as Kraken does. Truncate would align to the zero
+// time, which differs for weekly intervals.
+func alignStart(at time.Time, interval time.Duration) time.Time {
+	offset := at.UnixNano() % int64(interval)
+	if offset < 0 {
+		offset += int64(interval)
+	}
+	return at.Add(-time.Duration(offset))
+}
+
+func (builder *Builder) close(pair string) CandleClosed {
+	candle := builder.candles[pair]
+	delete(builder.candles, pair)
+	return CandleClosed{Pair: pair, Interval: builder.interval, Candle: *candle}
+}
+
+// CloseUntil closes the time candles that ended at or before now, they otherwise stay open until the next trade.
+func (builder *Builder) CloseUntil(now time.Time) []interface{} {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	if builder.kind != timeBars {
+		return nil
+	}
+
+	var pairs []string
+	for pair, candle := range builder.candles {
+		if !candle.End.After(now) {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Strings(pairs)
+
+	var events []interface{}
+	for _, pair := range pairs {
+		builder.closedUntil[pair] = builder.candles[pair].End
+		events = append(events, builder.close(pair))
+	}
+	return events
+}
+
+func (builder *Builder) Current(pair string) (Candle, bool) {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	candle, ok := builder.candles[pair]
+	if !ok {
+		return Candle{}, false
+	}
+	return *candle, true
+}
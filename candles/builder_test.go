@@ -0,0 +1,227 @@
+package candles
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustBuilder(builder *Builder, err error) *Builder {
+	if err != nil {
+		panic(err)
+	}
+	return builder
+}
+
+func unix(seconds int) time.Time {
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+func closedCandles(events []interface{}) []Candle {
+	var candles []Candle
+	for _, event := range events {
+		candles = append(candles, event.(CandleClosed).Candle)
+	}
+	return candles
+}
+
+type testTrade struct {
+	price  float64
+	volume float64
+	second int
+}
+
+func TestBuilder(t *testing.T) {
+	trades := []testTrade{{10, 1, 1}, {12, 1, 2}, {9, 2, 4}, {11, 1, 6}, {13, 3, 7}}
+
+	testCases := []struct {
+		name     string
+		builder  *Builder
+		expected []Candle
+		current  Candle
+	}{
+		{
+			name:    "time",
+			builder: mustBuilder(NewTimeBuilder(5 * time.Second)),
+			expected: []Candle{
+				{Pair: "XBT/EUR", Start: unix(0), End: unix(5), Open: 10, High: 12, Low: 9, Close: 9, Volume: 4, VWAP: 10, Count: 3},
+			},
+			current: Candle{Pair: "XBT/EUR", Start: unix(5), End: unix(10), Open: 11, High: 13, Low: 11, Close: 13, Volume: 4, VWAP: 12.5, Count: 2},
+		},
+		{
+			name:    "volume",
+			builder: mustBuilder(NewVolumeBuilder(2)),
+			expected: []Candle{
+				{Pair: "XBT/EUR", Start: unix(1), End: unix(2), Open: 10, High: 12, Low: 10, Close: 12, Volume: 2, VWAP: 11, Count: 2},
+				{Pair: "XBT/EUR", Start: unix(4), End: unix(4), Open: 9, High: 9, Low: 9, Close: 9, Volume: 2, VWAP: 9, Count: 1},
+				{Pair: "XBT/EUR", Start: unix(6), End: unix(7), Open: 11, High: 13, Low: 11, Close: 13, Volume: 4, VWAP: 12.5, Count: 2},
+			},
+		},
+		{
+			name:    "tick",
+			builder: mustBuilder(NewTickBuilder(3)),
+			expected: []Candle{
+				{Pair: "XBT/EUR", Start: unix(1), End: unix(4), Open: 10, High: 12, Low: 9, Close: 9, Volume: 4, VWAP: 10, Count: 3},
+			},
+			current: Candle{Pair: "XBT/EUR", Start: unix(6), End: unix(7), Open: 11, High: 13, Low: 11, Close: 13, Volume: 4, VWAP: 12.5, Count: 2},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var events []interface{}
+			for _, trade := range trades {
+				events = append(events, testCase.builder.AddTrade("XBT/EUR", trade.price, trade.volume, unix(trade.second))...)
+			}
+			assert.Equal(t, testCase.expected, closedCandles(events))
+
+			current, ok := testCase.builder.Current("XBT/EUR")
+			assert.Equal(t, testCase.current.Count != 0, ok)
+			assert.Equal(t, testCase.current, current)
+		})
+	}
+}
+
+func TestBuilderHandleAndCloseUntil(t *testing.T) {
+	builder := mustBuilder(NewTimeBuilder(time.Minute))
+
+	events := builder.Handle(websocket.Trade{Pair: "XBT/EUR", Data: []websocket.TradeData{
+		{Price: 100, Volume: 1, Time: websocket.UnixTime(unix(10))},
+		{Price: 101, Volume: 1, Time: websocket.UnixTime(unix(20))},
+	}})
+	assert.Empty(t, events)
+
+	// older trades are ignored
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 50, 1, unix(15)))
+	assert.Empty(t, builder.Handle(websocket.HeartBeat{}))
+
+	assert.Empty(t, builder.CloseUntil(unix(59)))
+	events = builder.CloseUntil(unix(60))
+	assert.Equal(t, []Candle{
+		{Pair: "XBT/EUR", Start: unix(0), End: unix(60), Open: 100, High: 101, Low: 100, Close: 101, Volume: 2, VWAP: 100.5, Count: 2},
+	}, closedCandles(events))
+
+	_, ok := builder.Current("XBT/EUR")
+	assert.False(t, ok)
+
+	// a late trade of the closed candle does not open it again
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 102, 1, unix(30)))
+	_, ok = builder.Current("XBT/EUR")
+	assert.False(t, ok)
+
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 103, 1, unix(60)))
+	current, _ := builder.Current("XBT/EUR")
+	assert.Equal(t, unix(60), current.Start)
+}
+
+type fakeHistory struct {
+	ohlc       rest.OHLCResult
+	tradePages []rest.TradesResult
+	since      []time.Time
+	pairs      []string
+}
+
+func (history *fakeHistory) OHLC(pair string, interval int, since time.Time) (rest.OHLCResult, error) {
+	history.pairs = append(history.pairs, pair)
+	return history.ohlc, nil
+}
+
+func (history *fakeHistory) Trades(pair string, since time.Time) (rest.TradesResult, error) {
+	history.pairs = append(history.pairs, pair)
+	history.since = append(history.since, since)
+
+	page := history.tradePages[0]
+	history.tradePages = history.tradePages[1:]
+	return page, nil
+}
+
+func TestBackfillOHLC(t *testing.T) {
+	history := &fakeHistory{ohlc: rest.OHLCResult{Candles: []rest.OHLC{
+		{Time: rest.UnixTime(unix(0)), Open: 10, High: 12, Low: 9, Close: 11, VWAP: 10.5, Volume: 3, Count: 4},
+		{Time: rest.UnixTime(unix(60)), Open: 11, High: 11, Low: 11, Close: 11, VWAP: 11, Volume: 1, Count: 1},
+	}}, tradePages: []rest.TradesResult{
+		{Last: unix(65), Trades: []rest.PublicTrade{{Price: 11, Volume: 1, Time: rest.UnixTime(unix(65))}}},
+	}}
+
+	builder := mustBuilder(NewTimeBuilder(time.Minute))
+	// a live trade received before the backfill is part of the trades of the candle in progress
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 11, 1, unix(65)))
+
+	closed, err := builder.Backfill(history, "XBT/EUR", unix(0))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"XBTEUR", "XBTEUR"}, history.pairs)
+	assert.Equal(t, []time.Time{unix(60)}, history.since)
+	assert.Equal(t, []Candle{
+		{Pair: "XBT/EUR", Start: unix(0), End: unix(60), Open: 10, High: 12, Low: 9, Close: 11, Volume: 3, VWAP: 10.5, Count: 4},
+	}, closed)
+
+	// live trades continue the candle that was in progress, older ones are ignored
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 14, 1, unix(64)))
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 13, 1, unix(70)))
+	events := builder.AddTrade("XBT/EUR", 12, 1, unix(125))
+	assert.Equal(t, []Candle{
+		{Pair: "XBT/EUR", Start: unix(60), End: unix(120), Open: 11, High: 13, Low: 11, Close: 13, Volume: 2, VWAP: 12, Count: 2},
+	}, closedCandles(events))
+}
+
+func TestBackfillWeekly(t *testing.T) {
+	// Thursday 2021-03-04, weeks start on Thursdays since the Unix epoch
+	const week = 1614816000
+
+	history := &fakeHistory{ohlc: rest.OHLCResult{Candles: []rest.OHLC{
+		{Time: rest.UnixTime(unix(week)), Open: 10, High: 10, Low: 10, Close: 10, VWAP: 10, Volume: 1, Count: 1},
+	}}, tradePages: []rest.TradesResult{
+		{Last: unix(week + 10), Trades: []rest.PublicTrade{{Price: 10, Volume: 1, Time: rest.UnixTime(unix(week + 10))}}},
+	}}
+
+	builder := mustBuilder(NewTimeBuilder(7 * 24 * time.Hour))
+	closed, err := builder.Backfill(history, "XBT/EUR", unix(week))
+	assert.Nil(t, err)
+	assert.Empty(t, closed)
+
+	assert.Empty(t, builder.AddTrade("XBT/EUR", 12, 2, unix(week+86400)))
+	current, ok := builder.Current("XBT/EUR")
+	assert.True(t, ok)
+	assert.Equal(t, Candle{Pair: "XBT/EUR", Start: unix(week), End: unix(week + 604800), Open: 10, High: 12, Low: 10, Close: 12, Volume: 3, VWAP: 34.0 / 3, Count: 2}, current)
+}
+
+func TestBackfillTrades(t *testing.T) {
+	firstPage := rest.TradesResult{Last: unix(2)}
+	for index := 0; index < tradesPageSize; index++ {
+		firstPage.Trades = append(firstPage.Trades, rest.PublicTrade{Price: 10, Volume: 0.001, Time: rest.UnixTime(unix(1))})
+	}
+
+	history := &fakeHistory{tradePages: []rest.TradesResult{
+		firstPage,
+		{Last: unix(8), Trades: []rest.PublicTrade{
+			{Price: 20, Volume: 1, Time: rest.UnixTime(unix(8))},
+		}},
+	}}
+
+	builder := mustBuilder(NewTimeBuilder(5 * time.Second))
+	closed, err := builder.Backfill(history, "XBT/EUR", unix(0))
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{unix(0), unix(2)}, history.since)
+
+	assert.Len(t, closed, 1)
+	assert.Equal(t, int64(tradesPageSize), closed[0].Count)
+	assert.InDelta(t, 1, closed[0].Volume, 1e-9)
+
+	current, ok := builder.Current("XBT/EUR")
+	assert.True(t, ok)
+	assert.Equal(t, 20.0, current.Close)
+}
+
+func TestNonPositiveSize(t *testing.T) {
+	_, err := NewTimeBuilder(0)
+	assert.Equal(t, SizeError{Size: "0s"}, err)
+
+	_, err = NewVolumeBuilder(-1)
+	assert.EqualError(t, err, "non-positive candle size -1 volume")
+
+	_, err = NewTickBuilder(0)
+	assert.EqualError(t, err, "non-positive candle size 0 trades")
+}
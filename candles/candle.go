@@ -0,0 +1,45 @@
+package candles
+
+import "time"
+
+// Candle is an OHLCV bar, Start and End are the times of the first and last trade for volume and tick candles.
+type Candle struct {
+	Pair   string
+	Start  time.Time
+	End    time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	VWAP   float64
+	Count  int64
+}
+
+type CandleClosed struct {
+	Pair     string
+	Interval time.Duration
+	Candle   Candle
+}
+
+func (candle *Candle) add(price float64, volume float64) {
+	if candle.Count == 0 {
+		candle.Open = price
+		candle.High = price
+		candle.Low = price
+	}
+
+	if price > candle.High {
+		candle.High = price
+	}
+	if price < candle.Low {
+		candle.Low = price
+	}
+	candle.Close = price
+
+	if total := candle.Volume + volume; total > 0 {
+		candle.VWAP = (candle.VWAP*candle.Volume + price*volume) / total
+	}
+	candle.Volume += volume
+	candle.Count++
+}
@@ -0,0 +1,172 @@
+package candles
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+)
+
+// SeriesKey identifies the candles of a pair for an interval of the ohlc channel.
+type SeriesKey struct {
+	Pair     string
+	Interval time.Duration
+}
+
+type series struct {
+	current    *Candle
+	history    *Ring
+	lastClosed time.Time
+}
+
+// OHLCTracker detects when candles of the ohlc channel are final. Kraken sends the candle in progress on every
+// trade, a candle is closed when an update for a later interval arrives or CloseUntil() is called after its end.
+type OHLCTracker struct {
+	mutex  sync.RWMutex
+	size   int
+	series map[SeriesKey]*series
+}
+
+// NewOHLCTracker keeps the last size closed candles of every pair and interval.
+func NewOHLCTracker(size int) *OHLCTracker {
+	return &OHLCTracker{
+		size:   size,
+		series: make(map[SeriesKey]*series),
+	}
+}
+
+// channelInterval returns the interval of channels such as "ohlc-5", which are in minutes.
+func channelInterval(channelName string) time.Duration {
+	split := strings.Split(channelName, "-")
+	if len(split) != 2 {
+		return time.Minute
+	}
+
+	minutes, err := strconv.Atoi(split[1])
+	if err != nil {
+		return time.Minute
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// FromOHLC converts a candle of the ohlc channel. Kraken resends the candle in progress on every trade,
+// use an OHLCTracker to only get final candles.
+func FromOHLC(pair string, interval time.Duration, data websocket.OHLCData) Candle {
+	end := time.Time(data.EndTime)
+
+	return Candle{
+		Pair:   pair,
+		Start:  end.Add(-interval),
+		End:    end,
+		Open:   float64(data.Open),
+		High:   float64(data.High),
+		Low:    float64(data.Low),
+		Close:  float64(data.Close),
+		Volume: float64(data.Volume),
+		VWAP:   float64(data.VolumeWeightedPrice),
+		Count:  data.Count,
+	}
+}
+
+// Handle processes a message received from Listen() and returns the resulting CandleClosed events.
+// Messages other than OHLC are ignored, as are updates for an interval before the current one or one that was closed.
+func (tracker *OHLCTracker) Handle(rawMessage interface{}) []interface{} {
+	message, ok := rawMessage.(websocket.OHLC)
+	if !ok {
+		return nil
+	}
+
+	key := SeriesKey{Pair: message.Pair, Interval: channelInterval(message.ChannelName)}
+	candle := FromOHLC(key.Pair, key.Interval, message.Data)
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracked, ok := tracker.series[key]
+	if !ok {
+		tracked = &series{history: NewRing(tracker.size)}
+		tracker.series[key] = tracked
+	}
+
+	if !candle.End.After(tracked.lastClosed) {
+		return nil
+	}
+
+	var events []interface{}
+
+	if tracked.current != nil {
+		if candle.End.Before(tracked.current.End) {
+			return nil
+		}
+
+		if candle.End.After(tracked.current.End) {
+			events = append(events, tracker.close(key, tracked))
+		}
+	}
+
+	tracked.current = &candle
+	return events
+}
+
+func (tracker *OHLCTracker) close(key SeriesKey, tracked *series) CandleClosed {
+	candle := *tracked.current
+	tracked.current = nil
+	tracked.lastClosed = candle.End
+	tracked.history.Push(candle)
+
+	return CandleClosed{Pair: key.Pair, Interval: key.Interval, Candle: candle}
+}
+
+// CloseUntil closes the candles that ended at or before now, which would otherwise stay open until the next trade
+// of their pair.
+func (tracker *OHLCTracker) CloseUntil(now time.Time) []interface{} {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	var keys []SeriesKey
+	for key, tracked := range tracker.series {
+		if tracked.current != nil && !tracked.current.End.After(now) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Pair == keys[j].Pair {
+			return keys[i].Interval < keys[j].Interval
+		}
+		return keys[i].Pair < keys[j].Pair
+	})
+
+	var events []interface{}
+	for _, key := range keys {
+		events = append(events, tracker.close(key, tracker.series[key]))
+	}
+	return events
+}
+
+// Current returns the candle in progress of pair for interval.
+func (tracker *OHLCTracker) Current(pair string, interval time.Duration) (Candle, bool) {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	tracked, ok := tracker.series[SeriesKey{Pair: pair, Interval: interval}]
+	if !ok || tracked.current == nil {
+		return Candle{}, false
+	}
+	return *tracked.current, true
+}
+
+// History returns the last closed candles of pair for interval, oldest first.
+func (tracker *OHLCTracker) History(pair string, interval time.Duration) []Candle {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	tracked, ok := tracker.series[SeriesKey{Pair: pair, Interval: interval}]
+	if !ok {
+		return nil
+	}
+	return tracked.history.Candles()
+}
@@ -0,0 +1,92 @@
+package candles
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func testOHLC(endSecond int, close float64, volume float64) websocket.OHLC {
+	return websocket.OHLC{
+		ChannelName: "ohlc-1",
+		Pair:        "XBT/EUR",
+		Data: websocket.OHLCData{
+			Time:    websocket.UnixTime(unix(endSecond - 30)),
+			EndTime: websocket.UnixTime(unix(endSecond)),
+			Open:    10,
+			High:    websocket.Float64String(close),
+			Low:     10,
+			Close:   websocket.Float64String(close),
+			Volume:  websocket.Float64String(volume),
+			Count:   int64(volume),
+		},
+	}
+}
+
+func TestOHLCTracker(t *testing.T) {
+	tracker := NewOHLCTracker(2)
+
+	assert.Empty(t, tracker.Handle(testOHLC(60, 11, 1)))
+	assert.Empty(t, tracker.Handle(testOHLC(60, 12, 2)))
+	assert.Empty(t, tracker.Handle(websocket.HeartBeat{}))
+
+	current, ok := tracker.Current("XBT/EUR", time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, unix(0), current.Start)
+	assert.Equal(t, 12.0, current.Close)
+
+	// the first update of the next interval finalizes the candle
+	events := tracker.Handle(testOHLC(120, 13, 1))
+	assert.Equal(t, []interface{}{CandleClosed{
+		Pair:     "XBT/EUR",
+		Interval: time.Minute,
+		Candle:   Candle{Pair: "XBT/EUR", Start: unix(0), End: unix(60), Open: 10, High: 12, Low: 10, Close: 12, Volume: 2, Count: 2},
+	}}, events)
+
+	// late updates of a closed interval are ignored
+	assert.Empty(t, tracker.Handle(testOHLC(60, 20, 3)))
+
+	assert.Empty(t, tracker.CloseUntil(unix(119)))
+	assert.Len(t, tracker.CloseUntil(unix(120)), 1)
+	_, ok = tracker.Current("XBT/EUR", time.Minute)
+	assert.False(t, ok)
+
+	// a late update does not open the candle again
+	assert.Empty(t, tracker.Handle(testOHLC(120, 15, 2)))
+	_, ok = tracker.Current("XBT/EUR", time.Minute)
+	assert.False(t, ok)
+
+	tracker.Handle(testOHLC(240, 14, 1))
+	tracker.CloseUntil(unix(240))
+
+	var closes []float64
+	for _, candle := range tracker.History("XBT/EUR", time.Minute) {
+		closes = append(closes, candle.Close)
+	}
+	assert.Equal(t, []float64{13, 14}, closes)
+	assert.Empty(t, tracker.History("XBT/EUR", 5*time.Minute))
+}
+
+func TestRing(t *testing.T) {
+	ring := NewRing(3)
+
+	_, ok := ring.Last()
+	assert.False(t, ok)
+
+	for index := 1; index <= 5; index++ {
+		ring.Push(Candle{Close: float64(index)})
+	}
+
+	assert.Equal(t, 3, ring.Len())
+	assert.Equal(t, []Candle{{Close: 3}, {Close: 4}, {Close: 5}}, ring.Candles())
+
+	last, ok := ring.Last()
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, last.Close)
+
+	empty := NewRing(0)
+	empty.Push(Candle{})
+	assert.Equal(t, 0, empty.Len())
+}
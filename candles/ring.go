@@ -0,0 +1,49 @@
+package candles
+
+// Ring keeps the most recent candles up to a fixed size.
+type Ring struct {
+	candles []Candle
+	start   int
+	size    int
+}
+
+func NewRing(size int) *Ring {
+	return &Ring{candles: make([]Candle, size)}
+}
+
+// Push adds candle, replacing the oldest one if the ring is full.
+func (ring *Ring) Push(candle Candle) {
+	if len(ring.candles) == 0 {
+		return
+	}
+
+	if ring.size < len(ring.candles) {
+		ring.candles[(ring.start+ring.size)%len(ring.candles)] = candle
+		ring.size++
+		return
+	}
+
+	ring.candles[ring.start] = candle
+	ring.start = (ring.start + 1) % len(ring.candles)
+}
+
+func (ring *Ring) Len() int {
+	return ring.size
+}
+
+// Candles returns a copy of the candles, oldest first.
+func (ring *Ring) Candles() []Candle {
+	candles := make([]Candle, ring.size)
+	for index := range candles {
+		candles[index] = ring.candles[(ring.start+index)%len(ring.candles)]
+	}
+	return candles
+}
+
+// Last returns the most recent candle.
+func (ring *Ring) Last() (Candle, bool) {
+	if ring.size == 0 {
+		return Candle{}, false
+	}
+	return ring.candles[(ring.start+ring.size-1)%len(ring.candles)], true
+}
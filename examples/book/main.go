@@ -13,27 +13,26 @@ func main() {
 		panic(err)
 	}
 
-	subscribe := websocket.Subscribe{
-		Pair:         []string{"XRP/EUR"},
-		Subscription: websocket.Subscription{Name: "book"},
-	}
+	manager := websocket.NewBookManager(client, 10)
 
-	if err = client.Send(subscribe); err != nil {
+	if err = manager.Subscribe([]string{"XRP/EUR", "XBT/EUR"}); err != nil {
 		panic(err)
 	}
 
-	var book websocket.Book
-
 	for rawMessage := range client.Listen() {
 		switch message := rawMessage.(type) {
 		case websocket.SubscriptionStatus, websocket.SystemStatus, websocket.HeartBeat, websocket.Pong:
 			// do nothing
-		case websocket.Book:
-			book = message
-			book.PrintTop(10)
-		case websocket.BookUpdate:
-			book.Update(message)
-			book.PrintTop(10)
+		case websocket.Book, websocket.BookUpdate:
+			if err := manager.Handle(message); err != nil {
+				log.Printf("%s", err.Error())
+				continue
+			}
+
+			book, ok := manager.Snapshot("XRP/EUR")
+			if ok {
+				book.PrintTop(10)
+			}
 		case error:
 			log.Fatalf("got err %T %s", message, message.Error())
 		default:
@@ -0,0 +1,331 @@
+package fees
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+)
+
+const Window = 30 * 24 * time.Hour
+
+type Source interface {
+	AssetPairs() (map[string]rest.AssetPair, error)
+	TradeVolume(pairs ...string) (rest.TradeVolume, error)
+}
+
+type Converter func(amount float64, asset assets.Asset) (converted float64, ok bool)
+
+type Option func(model *Model)
+
+// WithConverter counts trades in other quote currencies than the fee volume currency towards the traded volume.
+func WithConverter(converter Converter) Option {
+	return func(model *Model) {
+		model.converter = converter
+	}
+}
+
+type UnknownPairError struct {
+	Pair string
+}
+
+func (err UnknownPairError) Error() string {
+	return fmt.Sprintf("no fee schedule for pair %s", err.Pair)
+}
+
+type Estimate struct {
+	Pair         assets.Pair
+	Cost         float64
+	MakerPercent float64
+	TakerPercent float64
+	Maker        float64
+	Taker        float64
+}
+
+type TierChanged struct {
+	Pair     assets.Pair
+	Volume   float64
+	Previous Tier
+	Current  Tier
+}
+
+type FeeDiscrepancy struct {
+	TradeID       string
+	Trade         websocket.OwnTrade
+	ExpectedMaker float64
+	ExpectedTaker float64
+	Actual        float64
+}
+
+type volumeEntry struct {
+	time   time.Time
+	volume float64
+}
+
+// Model adds own trades to the volume of the TradeVolume endpoint. Kraken does not report when that volume leaves
+// the 30 day window, so SetTradeVolume() should be called periodically.
+type Model struct {
+	mutex      sync.RWMutex
+	now        func() time.Time
+	converter  Converter
+	currency   assets.Asset
+	schedules  map[assets.Pair]Schedule
+	volume     float64
+	volumeTime time.Time
+	trades     []volumeEntry
+	ownTrades  *websocket.OwnTradeStore
+}
+
+func New(options ...Option) *Model {
+	model := &Model{
+		now:       time.Now,
+		currency:  "USD",
+		schedules: make(map[assets.Pair]Schedule),
+		ownTrades: websocket.NewOwnTradeStore(),
+	}
+
+	for _, option := range options {
+		option(model)
+	}
+	return model
+}
+
+func Load(source Source, options ...Option) (*Model, error) {
+	pairs, err := source.AssetPairs()
+	if err != nil {
+		return nil, fmt.Errorf("could not get asset pairs: %w", err)
+	}
+
+	volume, err := source.TradeVolume()
+	if err != nil {
+		return nil, fmt.Errorf("could not get trade volume: %w", err)
+	}
+
+	model := New(options...)
+	model.Update(pairs)
+	model.SetTradeVolume(volume, model.now())
+	return model, nil
+}
+
+func (model *Model) Update(pairs map[string]rest.AssetPair) {
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	for _, pair := range pairs {
+		model.schedules[pair.Canonical()] = NewSchedule(pair)
+	}
+}
+
+// SetTradeVolume drops the tracked trades until at, as they are part of volume.
+func (model *Model) SetTradeVolume(volume rest.TradeVolume, at time.Time) {
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	if volume.Currency != "" {
+		model.currency = assets.ParseAsset(volume.Currency)
+	}
+	model.volume = float64(volume.Volume)
+	model.volumeTime = at
+
+	var trades []volumeEntry
+	for _, entry := range model.trades {
+		if entry.time.After(at) {
+			trades = append(trades, entry)
+		}
+	}
+	model.trades = trades
+}
+
+func (model *Model) Volume(now time.Time) float64 {
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
+	return model.volumeAt(now)
+}
+
+func (model *Model) volumeAt(now time.Time) float64 {
+	volume := model.volume
+	for _, entry := range model.trades {
+		if entry.time.After(now.Add(-Window)) && !entry.time.After(now) {
+			volume += entry.volume
+		}
+	}
+	return volume
+}
+
+func (model *Model) schedule(pair string) (assets.Pair, Schedule, error) {
+	parsed, err := assets.ParsePair(pair)
+	if err != nil {
+		return assets.Pair{}, Schedule{}, err
+	}
+
+	schedule, ok := model.schedules[parsed]
+	if !ok {
+		return assets.Pair{}, Schedule{}, UnknownPairError{Pair: pair}
+	}
+	return parsed, schedule, nil
+}
+
+func (model *Model) Rates(pair string) (maker float64, taker float64, err error) {
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
+	_, schedule, err := model.schedule(pair)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	volume := model.volumeAt(model.now())
+	return Percent(schedule.Tiers, volume, true), Percent(schedule.Tiers, volume, false), nil
+}
+
+// NextTier returns the next tier of pair and the volume still to be traded to reach it.
+func (model *Model) NextTier(pair string) (next Tier, remaining float64, ok bool, err error) {
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
+	_, schedule, err := model.schedule(pair)
+	if err != nil {
+		return Tier{}, 0, false, err
+	}
+
+	volume := model.volumeAt(model.now())
+	index, _ := schedule.tier(volume)
+	if index+1 >= len(schedule.Tiers) {
+		return Tier{}, 0, false, nil
+	}
+
+	next = schedule.Tiers[index+1]
+	return next, next.Volume - volume, true, nil
+}
+
+func (model *Model) Estimate(order websocket.AddOrder, marketPrice float64) (Estimate, error) {
+	price := marketPrice
+	if order.OrderType != "market" && order.Price != "" {
+		var err error
+		if price, err = strconv.ParseFloat(order.Price, 64); err != nil {
+			return Estimate{}, fmt.Errorf("invalid price %q: %w", order.Price, err)
+		}
+	}
+
+	volume, err := strconv.ParseFloat(order.Volume, 64)
+	if err != nil {
+		return Estimate{}, fmt.Errorf("invalid volume %q: %w", order.Volume, err)
+	}
+
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
+	pair, schedule, err := model.schedule(order.Pair)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	tradedVolume := model.volumeAt(model.now())
+
+	estimate := Estimate{
+		Pair:         pair,
+		Cost:         price * volume,
+		MakerPercent: Percent(schedule.Tiers, tradedVolume, true),
+		TakerPercent: Percent(schedule.Tiers, tradedVolume, false),
+	}
+
+	estimate.Maker = estimate.Cost * estimate.MakerPercent / 100
+	estimate.Taker = estimate.Cost * estimate.TakerPercent / 100
+	return estimate, nil
+}
+
+func (model *Model) convert(amount float64, asset assets.Asset) (float64, bool) {
+	if asset == model.currency {
+		return amount, true
+	}
+
+	if model.converter == nil {
+		return 0, false
+	}
+	return model.converter(amount, asset)
+}
+
+func (model *Model) pruned(now time.Time) []volumeEntry {
+	var trades []volumeEntry
+	for _, entry := range model.trades {
+		if entry.time.After(now.Add(-Window)) {
+			trades = append(trades, entry)
+		}
+	}
+	return trades
+}
+
+func (model *Model) Handle(rawMessage interface{}) []interface{} {
+	message, ok := rawMessage.(websocket.OwnTrades)
+	if !ok {
+		return nil
+	}
+
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	var events []interface{}
+	for _, entry := range model.ownTrades.Add(message) {
+		events = append(events, model.addTrade(entry.TradeID, entry.Trade)...)
+	}
+	return events
+}
+
+func (model *Model) addTrade(id string, trade websocket.OwnTrade) []interface{} {
+	tradeTime := time.Time(trade.Time)
+
+	if !tradeTime.After(model.volumeTime) {
+		return nil
+	}
+
+	pair, schedule, err := model.schedule(trade.Pair)
+	if err != nil {
+		return nil
+	}
+
+	var events []interface{}
+
+	before := model.volumeAt(tradeTime)
+	cost := float64(trade.Cost)
+
+	expectedMaker := cost * Percent(schedule.Tiers, before, true) / 100
+	expectedTaker := cost * Percent(schedule.Tiers, before, false) / 100
+	actual := float64(trade.Fee)
+
+	matches := math.Abs(actual-expectedTaker) <= schedule.tolerance()
+	if !strings.HasPrefix(trade.OrderType, "market") {
+		matches = matches || math.Abs(actual-expectedMaker) <= schedule.tolerance()
+	}
+
+	if !matches {
+		events = append(events, FeeDiscrepancy{
+			TradeID:       id,
+			Trade:         trade,
+			ExpectedMaker: expectedMaker,
+			ExpectedTaker: expectedTaker,
+			Actual:        actual,
+		})
+	}
+
+	volume, ok := model.convert(cost, pair.Quote)
+	if !ok {
+		return events
+	}
+	model.trades = append(model.pruned(tradeTime), volumeEntry{time: tradeTime, volume: volume})
+
+	after := model.volumeAt(tradeTime)
+
+	previousIndex, previous := schedule.tier(before)
+	currentIndex, current := schedule.tier(after)
+	if previousIndex != currentIndex {
+		events = append(events, TierChanged{Pair: pair, Volume: after, Previous: previous, Current: current})
+	}
+	return events
+}
@@ -0,0 +1,137 @@
+package fees
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/mockserver"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+const volumeTime = 1614859200
+
+func loadTestModel(t *testing.T, options ...Option) *Model {
+	server, err := mockserver.NewRESTServer("key", "c2VjcmV0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client := rest.NewClient(rest.WithURL(server.URL()))
+	assert.Nil(t, client.SetAuth("key", "c2VjcmV0"))
+
+	model, err := Load(client, options...)
+	assert.Nil(t, err)
+
+	model.now = func() time.Time { return time.Unix(volumeTime, 0) }
+	model.SetTradeVolume(rest.TradeVolume{Currency: "ZUSD", Volume: 45000}, time.Unix(volumeTime, 0))
+	return model
+}
+
+func TestPercent(t *testing.T) {
+	assert.Equal(t, 0.26, Percent(DefaultTiers, 0, false))
+	assert.Equal(t, 0.16, Percent(DefaultTiers, 49999, true))
+	assert.Equal(t, 0.14, Percent(DefaultTiers, 50000, true))
+	assert.Equal(t, 0.10, Percent(DefaultTiers, 20000000, false))
+}
+
+func TestNewSchedule(t *testing.T) {
+	schedule := NewSchedule(rest.AssetPair{
+		CostDecimals: 2,
+		Fees:         []rest.FeeLevel{{0, 0.26}, {50000, 0.24}},
+		FeesMaker:    []rest.FeeLevel{{0, 0.16}, {10000, 0.15}, {50000, 0.14}},
+	})
+
+	assert.Equal(t, Schedule{CostDecimals: 2, Tiers: []Tier{
+		{Volume: 0, Maker: 0.16, Taker: 0.26},
+		{Volume: 10000, Maker: 0.15, Taker: 0.26},
+		{Volume: 50000, Maker: 0.14, Taker: 0.24},
+	}}, schedule)
+
+	takerOnly := NewSchedule(rest.AssetPair{Fees: []rest.FeeLevel{{0, 0.2}}})
+	assert.Equal(t, []Tier{{Volume: 0, Maker: 0.2, Taker: 0.2}}, takerOnly.Tiers)
+}
+
+func TestModelEstimate(t *testing.T) {
+	model := loadTestModel(t)
+
+	maker, taker, err := model.Rates("XBT/EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.16, maker)
+	assert.Equal(t, 0.26, taker)
+
+	estimate, err := model.Estimate(websocket.AddOrder{Pair: "XBT/EUR", OrderType: "limit", Price: "30000.0", Volume: "0.5"}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, assets.Pair{Base: "XBT", Quote: "EUR"}, estimate.Pair)
+	assert.Equal(t, 15000.0, estimate.Cost)
+	assert.InDelta(t, 24, estimate.Maker, 1e-9)
+	assert.InDelta(t, 39, estimate.Taker, 1e-9)
+
+	estimate, err = model.Estimate(websocket.AddOrder{Pair: "XXBTZEUR", OrderType: "market", Volume: "0.1"}, 20000)
+	assert.Nil(t, err)
+	assert.InDelta(t, 5.2, estimate.Taker, 1e-9)
+
+	_, err = model.Estimate(websocket.AddOrder{Pair: "ETH/EUR", OrderType: "market", Volume: "1"}, 2000)
+	assert.Equal(t, UnknownPairError{Pair: "ETH/EUR"}, err)
+
+	_, err = model.Estimate(websocket.AddOrder{Pair: "XBT/EUR", OrderType: "limit", Price: "30000.0", Volume: "x"}, 0)
+	assert.Error(t, err)
+
+	next, remaining, ok, err := model.NextTier("XBT/EUR")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Tier{Volume: 50000, Maker: 0.14, Taker: 0.24}, next)
+	assert.Equal(t, 5000.0, remaining)
+}
+
+func TestModelHandle(t *testing.T) {
+	// EUR is counted at a rate of 1 for simplicity
+	model := loadTestModel(t, WithConverter(func(amount float64, asset assets.Asset) (float64, bool) {
+		return amount, asset == "EUR"
+	}))
+
+	assert.Empty(t, model.Handle(websocket.HeartBeat{}))
+
+	events := model.Handle(websocket.OwnTrades{Trades: websocket.OwnTradeList{
+		// part of the baseline volume
+		{TradeID: "TOLD", Trade: websocket.OwnTrade{Pair: "XBT/EUR", OrderType: "limit", Cost: 1000, Fee: 99, Time: websocket.UnixTime(time.Unix(volumeTime-10, 0))}},
+		// maker fee at the first tier
+		{TradeID: "TMAKER", Trade: websocket.OwnTrade{Pair: "XBT/EUR", OrderType: "limit", Cost: 15000, Fee: 24, Time: websocket.UnixTime(time.Unix(volumeTime+10, 0))}},
+		// taker fee at the second tier, but a market order paying the maker fee is not expected
+		{TradeID: "TMARKET", Trade: websocket.OwnTrade{Pair: "XBT/EUR", OrderType: "market", Cost: 1000, Fee: 1.4, Time: websocket.UnixTime(time.Unix(volumeTime+20, 0))}},
+	}})
+
+	assert.Equal(t, []interface{}{
+		TierChanged{
+			Pair:     assets.Pair{Base: "XBT", Quote: "EUR"},
+			Volume:   60000,
+			Previous: Tier{Volume: 0, Maker: 0.16, Taker: 0.26},
+			Current:  Tier{Volume: 50000, Maker: 0.14, Taker: 0.24},
+		},
+		FeeDiscrepancy{
+			TradeID:       "TMARKET",
+			Trade:         websocket.OwnTrade{Pair: "XBT/EUR", OrderType: "market", Cost: 1000, Fee: 1.4, Time: websocket.UnixTime(time.Unix(volumeTime+20, 0))},
+			ExpectedMaker: 1.4,
+			ExpectedTaker: 2.4,
+			Actual:        1.4,
+		},
+	}, events)
+
+	// trades are only counted once
+	assert.Empty(t, model.Handle(websocket.OwnTrades{Trades: websocket.OwnTradeList{
+		{TradeID: "TMAKER", Trade: websocket.OwnTrade{Pair: "XBT/EUR", OrderType: "limit", Cost: 15000, Fee: 24, Time: websocket.UnixTime(time.Unix(volumeTime+10, 0))}},
+	}}))
+
+	assert.Equal(t, 61000.0, model.Volume(time.Unix(volumeTime+30, 0)))
+	assert.Equal(t, 46000.0, model.Volume(time.Unix(volumeTime+10, 0).Add(Window)))
+
+	model.now = func() time.Time { return time.Unix(volumeTime+30, 0) }
+	maker, _, err := model.Rates("XBT/EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.14, maker)
+
+	// a new baseline includes the tracked trades
+	model.SetTradeVolume(rest.TradeVolume{Currency: "ZUSD", Volume: 61000}, time.Unix(volumeTime+30, 0))
+	assert.Equal(t, 61000.0, model.Volume(time.Unix(volumeTime+30, 0)))
+}
@@ -0,0 +1,109 @@
+package fees
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lk16/kraken/rest"
+)
+
+type Tier struct {
+	Volume float64
+	Maker  float64
+	Taker  float64
+}
+
+// DefaultTiers are the Kraken spot fee tiers.
+var DefaultTiers = []Tier{
+	{Volume: 0, Maker: 0.16, Taker: 0.26},
+	{Volume: 50000, Maker: 0.14, Taker: 0.24},
+	{Volume: 100000, Maker: 0.12, Taker: 0.22},
+	{Volume: 250000, Maker: 0.10, Taker: 0.20},
+	{Volume: 500000, Maker: 0.08, Taker: 0.18},
+	{Volume: 1000000, Maker: 0.06, Taker: 0.16},
+	{Volume: 2500000, Maker: 0.04, Taker: 0.14},
+	{Volume: 5000000, Maker: 0.02, Taker: 0.12},
+	{Volume: 10000000, Maker: 0.00, Taker: 0.10},
+}
+
+func Percent(tiers []Tier, volume float64, maker bool) float64 {
+	var percent float64
+	for _, tier := range tiers {
+		if volume < tier.Volume {
+			break
+		}
+
+		percent = tier.Taker
+		if maker {
+			percent = tier.Maker
+		}
+	}
+	return percent
+}
+
+type Schedule struct {
+	Tiers        []Tier
+	CostDecimals int
+}
+
+func percentAt(levels []rest.FeeLevel, volume float64) float64 {
+	var percent float64
+	for _, level := range levels {
+		if volume < level[0] {
+			break
+		}
+		percent = level[1]
+	}
+	return percent
+}
+
+// NewSchedule merges the taker and maker fee levels, pairs without maker levels charge makers the taker fee.
+func NewSchedule(pair rest.AssetPair) Schedule {
+	makerLevels := pair.FeesMaker
+	if len(makerLevels) == 0 {
+		makerLevels = pair.Fees
+	}
+
+	seen := make(map[float64]bool)
+
+	var volumes []float64
+	for _, levels := range [][]rest.FeeLevel{pair.Fees, makerLevels} {
+		for _, level := range levels {
+			if !seen[level[0]] {
+				seen[level[0]] = true
+				volumes = append(volumes, level[0])
+			}
+		}
+	}
+	sort.Float64s(volumes)
+
+	schedule := Schedule{CostDecimals: pair.CostDecimals}
+	for _, volume := range volumes {
+		schedule.Tiers = append(schedule.Tiers, Tier{
+			Volume: volume,
+			Maker:  percentAt(makerLevels, volume),
+			Taker:  percentAt(pair.Fees, volume),
+		})
+	}
+	return schedule
+}
+
+func (schedule Schedule) tier(volume float64) (int, Tier) {
+	index := -1
+	for i, tier := range schedule.Tiers {
+		if volume < tier.Volume {
+			break
+		}
+		index = i
+	}
+
+	if index < 0 {
+		return index, Tier{}
+	}
+	return index, schedule.Tiers[index]
+}
+
+// tolerance is half a unit of the last decimal of the fee.
+func (schedule Schedule) tolerance() float64 {
+	return 0.5*math.Pow10(-schedule.CostDecimals) + 1e-9
+}
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type Response struct {
+	Result     string    `json:"result"`
+	Error      string    `json:"error"`
+	ServerTime time.Time `json:"serverTime"`
+}
+
+type Ticker struct {
+	Symbol       string    `json:"symbol"`
+	Tag          string    `json:"tag"`
+	Pair         string    `json:"pair"`
+	Last         float64   `json:"last"`
+	LastTime     time.Time `json:"lastTime"`
+	LastSize     float64   `json:"lastSize"`
+	MarkPrice    float64   `json:"markPrice"`
+	Bid          float64   `json:"bid"`
+	BidSize      float64   `json:"bidSize"`
+	Ask          float64   `json:"ask"`
+	AskSize      float64   `json:"askSize"`
+	Vol24h       float64   `json:"vol24h"`
+	OpenInterest float64   `json:"openInterest"`
+	FundingRate  float64   `json:"fundingRate"`
+	Suspended    bool      `json:"suspended"`
+}
+
+type Instrument struct {
+	Symbol        string    `json:"symbol"`
+	Type          string    `json:"type"`
+	Underlying    string    `json:"underlying"`
+	TickSize      float64   `json:"tickSize"`
+	ContractSize  float64   `json:"contractSize"`
+	Tradeable     bool      `json:"tradeable"`
+	MaxPosition   float64   `json:"maxPositionSize"`
+	LastTradingAt time.Time `json:"lastTradingTime"`
+	Tag           string    `json:"tag"`
+}
+
+type PriceLevel struct {
+	Price float64
+	Size  float64
+}
+
+func (level *PriceLevel) UnmarshalJSON(bytes []byte) error {
+	slice := []interface{}{
+		&level.Price,
+		&level.Size,
+	}
+	return json.Unmarshal(bytes, &slice)
+}
+
+type OrderBook struct {
+	Bids []PriceLevel `json:"bids"`
+	Asks []PriceLevel `json:"asks"`
+}
+
+type Account struct {
+	Type               string             `json:"type"`
+	Currency           string             `json:"currency"`
+	Balances           map[string]float64 `json:"balances"`
+	Auxiliary          map[string]float64 `json:"auxiliary"`
+	MarginRequirements map[string]float64 `json:"marginRequirements"`
+}
+
+type OpenPosition struct {
+	Side              string    `json:"side"`
+	Symbol            string    `json:"symbol"`
+	Price             float64   `json:"price"`
+	FillTime          time.Time `json:"fillTime"`
+	Size              float64   `json:"size"`
+	UnrealizedFunding float64   `json:"unrealizedFunding"`
+}
+
+type OpenOrder struct {
+	OrderID        string    `json:"order_id"`
+	ClientOrderID  string    `json:"cliOrdId"`
+	Symbol         string    `json:"symbol"`
+	Side           string    `json:"side"`
+	OrderType      string    `json:"orderType"`
+	LimitPrice     float64   `json:"limitPrice"`
+	StopPrice      float64   `json:"stopPrice"`
+	UnfilledSize   float64   `json:"unfilledSize"`
+	FilledSize     float64   `json:"filledSize"`
+	ReceivedTime   time.Time `json:"receivedTime"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	Status         string    `json:"status"`
+	ReduceOnly     bool      `json:"reduceOnly"`
+}
+
+type Fill struct {
+	FillID        string    `json:"fill_id"`
+	OrderID       string    `json:"order_id"`
+	ClientOrderID string    `json:"cliOrdId"`
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"`
+	Size          float64   `json:"size"`
+	Price         float64   `json:"price"`
+	FillTime      time.Time `json:"fillTime"`
+	FillType      string    `json:"fillType"`
+}
+
+type SendOrder struct {
+	OrderType     string
+	Symbol        string
+	Side          string
+	Size          float64
+	LimitPrice    float64
+	StopPrice     float64
+	ClientOrderID string
+	ReduceOnly    bool
+}
+
+func (order SendOrder) values() url.Values {
+	data := url.Values{}
+	data.Set("orderType", order.OrderType)
+	data.Set("symbol", order.Symbol)
+	data.Set("side", order.Side)
+	data.Set("size", fmt.Sprintf("%v", order.Size))
+
+	if order.LimitPrice != 0 {
+		data.Set("limitPrice", fmt.Sprintf("%v", order.LimitPrice))
+	}
+	if order.StopPrice != 0 {
+		data.Set("stopPrice", fmt.Sprintf("%v", order.StopPrice))
+	}
+	if order.ClientOrderID != "" {
+		data.Set("cliOrdId", order.ClientOrderID)
+	}
+	if order.ReduceOnly {
+		data.Set("reduceOnly", "true")
+	}
+	return data
+}
+
+type OrderEvent struct {
+	Type   string          `json:"type"`
+	Reason string          `json:"reason"`
+	Order  json.RawMessage `json:"order"`
+}
+
+type SendStatus struct {
+	OrderID       string       `json:"order_id"`
+	ClientOrderID string       `json:"cliOrdId"`
+	Status        string       `json:"status"`
+	ReceivedTime  time.Time    `json:"receivedTime"`
+	OrderEvents   []OrderEvent `json:"orderEvents"`
+}
+
+type CancelStatus struct {
+	OrderID      string    `json:"order_id"`
+	Status       string    `json:"status"`
+	ReceivedTime time.Time `json:"receivedTime"`
+}
@@ -0,0 +1,294 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	APIUrl  = "https://futures.kraken.com"
+	APIPath = "/derivatives/api/v3"
+)
+
+type APIError struct {
+	Message string
+}
+
+func (err APIError) Error() string {
+	return fmt.Sprintf("kraken futures returned error: %s", err.Message)
+}
+
+type Client struct {
+	key           string
+	secret        string
+	decodedSecret []byte
+}
+
+func NewClient() *Client {
+	return &Client{}
+}
+
+func (client *Client) SetAuth(key string, secret string) error {
+	client.key = key
+	client.secret = secret
+
+	var err error
+	client.decodedSecret, err = base64.StdEncoding.DecodeString(client.secret)
+	return err
+}
+
+// sign computes the Authent header, endpointPath excludes the /derivatives prefix.
+func (client *Client) sign(endpointPath string, postData string, nonce string) string {
+	// note: calling Write() on a hash object cannot fail, the returned error is always nil
+
+	sha256State := sha256.New()
+	sha256State.Write([]byte(postData))
+	sha256State.Write([]byte(nonce))
+	sha256State.Write([]byte(endpointPath))
+
+	hmacState := hmac.New(sha512.New, client.decodedSecret)
+	hmacState.Write(sha256State.Sum(nil))
+
+	return base64.StdEncoding.EncodeToString(hmacState.Sum(nil))
+}
+
+func (client *Client) prepareRequest(httpMethod string, endpoint string, isPrivate bool, data url.Values) (*http.Request, error) {
+
+	if data == nil {
+		data = url.Values{}
+	}
+
+	urlPath := fmt.Sprintf("%s/%s", APIPath, endpoint)
+	encoded := data.Encode()
+
+	var request *http.Request
+	var err error
+
+	if httpMethod == "GET" {
+		requestURL := APIUrl + urlPath
+		if encoded != "" {
+			requestURL += "?" + encoded
+		}
+		request, err = http.NewRequest("GET", requestURL, nil)
+	} else {
+		request, err = http.NewRequest(httpMethod, APIUrl+urlPath, strings.NewReader(encoded))
+		if err == nil {
+			request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	if isPrivate {
+		nonce := fmt.Sprintf("%d", time.Now().UnixNano())
+		endpointPath := strings.TrimPrefix(urlPath, "/derivatives")
+
+		request.Header.Add("APIKey", client.key)
+		request.Header.Add("Nonce", nonce)
+		request.Header.Add("Authent", client.sign(endpointPath, encoded, nonce))
+	}
+	return request, nil
+}
+
+func parseResponse(response *http.Response, retType interface{}) error {
+	if response.Body == nil {
+		return fmt.Errorf("response body is nil")
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read response body (%s)", err.Error())
+	}
+
+	var status Response
+	if err = json.Unmarshal(body, &status); err != nil {
+		if response.StatusCode != 200 {
+			return fmt.Errorf("unexpected status code %d", response.StatusCode)
+		}
+		return fmt.Errorf("parsing JSON body failed: %w", err)
+	}
+
+	if status.Result != "success" {
+		return APIError{Message: status.Error}
+	}
+
+	if response.StatusCode != 200 {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	if retType == nil {
+		return nil
+	}
+
+	// futures responses have the result fields next to the status fields
+	if err = json.Unmarshal(body, retType); err != nil {
+		return fmt.Errorf("parsing JSON body failed: %w", err)
+	}
+	return nil
+}
+
+func (client *Client) request(httpMethod string, endpoint string, isPrivate bool, data url.Values, retType interface{}) error {
+	req, err := client.prepareRequest(httpMethod, endpoint, isPrivate, data)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error during request execution: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+	return parseResponse(resp, retType)
+}
+
+// Tickers - Get tickers of all contracts
+func (client *Client) Tickers() ([]Ticker, error) {
+	var response struct {
+		Tickers []Ticker `json:"tickers"`
+	}
+
+	if err := client.request("GET", "tickers", false, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Tickers, nil
+}
+
+// Instruments - Get specifications of all contracts
+func (client *Client) Instruments() ([]Instrument, error) {
+	var response struct {
+		Instruments []Instrument `json:"instruments"`
+	}
+
+	if err := client.request("GET", "instruments", false, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Instruments, nil
+}
+
+// OrderBook - Get the order book of a contract
+func (client *Client) OrderBook(symbol string) (OrderBook, error) {
+	var response struct {
+		OrderBook OrderBook `json:"orderBook"`
+	}
+
+	data := url.Values{}
+	data.Set("symbol", symbol)
+
+	if err := client.request("GET", "orderbook", false, data, &response); err != nil {
+		return response.OrderBook, err
+	}
+	return response.OrderBook, nil
+}
+
+// Accounts - Get balances and margin information per account
+func (client *Client) Accounts() (map[string]Account, error) {
+	var response struct {
+		Accounts map[string]Account `json:"accounts"`
+	}
+
+	if err := client.request("GET", "accounts", true, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Accounts, nil
+}
+
+// OpenPositions - Get open positions
+func (client *Client) OpenPositions() ([]OpenPosition, error) {
+	var response struct {
+		OpenPositions []OpenPosition `json:"openPositions"`
+	}
+
+	if err := client.request("GET", "openpositions", true, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.OpenPositions, nil
+}
+
+// OpenOrders - Get open orders
+func (client *Client) OpenOrders() ([]OpenOrder, error) {
+	var response struct {
+		OpenOrders []OpenOrder `json:"openOrders"`
+	}
+
+	if err := client.request("GET", "openorders", true, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.OpenOrders, nil
+}
+
+// Fills - Get recent fills, lastFillTime may be zero to get the most recent fills
+func (client *Client) Fills(lastFillTime time.Time) ([]Fill, error) {
+	var response struct {
+		Fills []Fill `json:"fills"`
+	}
+
+	data := url.Values{}
+	if !lastFillTime.IsZero() {
+		data.Set("lastFillTime", lastFillTime.UTC().Format(time.RFC3339Nano))
+	}
+
+	if err := client.request("GET", "fills", true, data, &response); err != nil {
+		return nil, err
+	}
+	return response.Fills, nil
+}
+
+// SendOrder - Place an order
+func (client *Client) SendOrder(order SendOrder) (SendStatus, error) {
+	var response struct {
+		SendStatus SendStatus `json:"sendStatus"`
+	}
+
+	if err := client.request("POST", "sendorder", true, order.values(), &response); err != nil {
+		return response.SendStatus, err
+	}
+	return response.SendStatus, nil
+}
+
+// CancelOrder - Cancel an order by order id or by client order id
+func (client *Client) CancelOrder(orderID string, clientOrderID string) (CancelStatus, error) {
+	var response struct {
+		CancelStatus CancelStatus `json:"cancelStatus"`
+	}
+
+	data := url.Values{}
+	if orderID != "" {
+		data.Set("order_id", orderID)
+	}
+	if clientOrderID != "" {
+		data.Set("cliOrdId", clientOrderID)
+	}
+
+	if err := client.request("POST", "cancelorder", true, data, &response); err != nil {
+		return response.CancelStatus, err
+	}
+	return response.CancelStatus, nil
+}
+
+// CancelAllOrders - Cancel all orders, or only those of symbol if it is not empty
+func (client *Client) CancelAllOrders(symbol string) (CancelStatus, error) {
+	var response struct {
+		CancelStatus CancelStatus `json:"cancelStatus"`
+	}
+
+	data := url.Values{}
+	if symbol != "" {
+		data.Set("symbol", symbol)
+	}
+
+	if err := client.request("POST", "cancelallorders", true, data, &response); err != nil {
+		return response.CancelStatus, err
+	}
+	return response.CancelStatus, nil
+}
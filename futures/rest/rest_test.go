@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	client := NewClient()
+	assert.Nil(t, client.SetAuth("key", "c2VjcmV0"))
+
+	signature := client.sign("/api/v3/orderbook", "symbol=PI_XBTUSD", "1")
+	assert.Equal(t, "GM+FEzpsnsjzDgwxh02wm2heOf5B5h15Cd8VGXkmwSTrMTCk+Kks3MBNLU04wYbWfQhN1pnDz4e4H9VuyqTXUA==", signature)
+}
+
+func TestPrepareRequest(t *testing.T) {
+	client := NewClient()
+	assert.Nil(t, client.SetAuth("key", "c2VjcmV0"))
+
+	request, err := client.prepareRequest("POST", "cancelorder", true, SendOrder{OrderType: "lmt", Symbol: "PI_XBTUSD", Side: "buy", Size: 1, LimitPrice: 100}.values())
+	assert.Nil(t, err)
+	assert.Equal(t, "https://futures.kraken.com/derivatives/api/v3/cancelorder", request.URL.String())
+	assert.Equal(t, "key", request.Header.Get("APIKey"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "limitPrice=100&orderType=lmt&side=buy&size=1&symbol=PI_XBTUSD", string(body))
+	assert.Equal(t, client.sign("/api/v3/cancelorder", string(body), request.Header.Get("Nonce")), request.Header.Get("Authent"))
+}
+
+func TestParseResponse(t *testing.T) {
+	type testCase struct {
+		name          string
+		statusCode    int
+		body          string
+		expectedBook  OrderBook
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			name:       "success",
+			statusCode: 200,
+			body:       `{"result":"success","serverTime":"2020-07-22T13:37:27.077Z","orderBook":{"bids":[[9800.5,1200]],"asks":[[9801,500]]}}`,
+			expectedBook: OrderBook{
+				Bids: []PriceLevel{{Price: 9800.5, Size: 1200}},
+				Asks: []PriceLevel{{Price: 9801, Size: 500}},
+			},
+		},
+		{
+			name:          "error",
+			statusCode:    200,
+			body:          `{"result":"error","serverTime":"2020-07-22T13:37:27.077Z","error":"apiLimitExceeded"}`,
+			expectedError: APIError{Message: "apiLimitExceeded"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var response struct {
+				OrderBook OrderBook `json:"orderBook"`
+			}
+
+			err := parseResponse(&http.Response{
+				StatusCode: testCase.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(testCase.body)),
+			}, &response)
+
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedBook, response.OrderBook)
+		})
+	}
+}
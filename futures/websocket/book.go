@@ -0,0 +1,54 @@
+package websocket
+
+import (
+	spot "github.com/lk16/kraken/websocket"
+)
+
+// Book converts the snapshot so it can be maintained with spot.Book.Update() or a spot.BookManager.
+func (snapshot BookSnapshot) Book() spot.Book {
+	book := spot.Book{
+		ChannelName: "book",
+		Pair:        snapshot.ProductID,
+		Data: spot.BookData{
+			Asks: make([]spot.PriceLevel, 0, len(snapshot.Asks)),
+			Bids: make([]spot.PriceLevel, 0, len(snapshot.Bids)),
+		},
+	}
+
+	for _, ask := range snapshot.Asks {
+		book.Data.Asks = append(book.Data.Asks, priceLevel(ask.Price, ask.Qty, snapshot.Timestamp))
+	}
+
+	for _, bid := range snapshot.Bids {
+		book.Data.Bids = append(book.Data.Bids, priceLevel(bid.Price, bid.Qty, snapshot.Timestamp))
+	}
+
+	// the futures snapshot is not sorted
+	book.Update(spot.BookUpdate{})
+	return book
+}
+
+// BookUpdate converts the update of a single level, a zero Qty removes the level.
+func (update BookUpdate) BookUpdate() spot.BookUpdate {
+	bookUpdate := spot.BookUpdate{
+		ChannelName: "book",
+		Pair:        update.ProductID,
+	}
+
+	level := priceLevel(update.Price, update.Qty, update.Timestamp)
+
+	if update.Side == "sell" {
+		bookUpdate.Data.Asks = []spot.PriceLevel{level}
+	} else {
+		bookUpdate.Data.Bids = []spot.PriceLevel{level}
+	}
+	return bookUpdate
+}
+
+func priceLevel(price float64, qty float64, timestamp MilliTime) spot.PriceLevel {
+	return spot.PriceLevel{
+		Price:     spot.Float64String(price),
+		Volume:    spot.Float64String(qty),
+		Timestamp: spot.UnixTime(timestamp),
+	}
+}
@@ -0,0 +1,230 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MilliTime parses timestamps sent as milliseconds since the unix epoch.
+type MilliTime time.Time
+
+func (milliTime *MilliTime) UnmarshalJSON(bytes []byte) error {
+	if string(bytes) == "null" {
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(bytes, &number); err != nil {
+		return err
+	}
+
+	millis, err := number.Int64()
+	if err != nil {
+		return err
+	}
+
+	*milliTime = MilliTime(time.Unix(0, millis*int64(time.Millisecond)).UTC())
+	return nil
+}
+
+type Challenge struct {
+	Event   string `json:"event"`
+	APIKey  string `json:"api_key,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type Subscribe struct {
+	Event             string   `json:"event"`
+	Feed              string   `json:"feed"`
+	ProductIDs        []string `json:"product_ids,omitempty"`
+	APIKey            string   `json:"api_key,omitempty"`
+	OriginalChallenge string   `json:"original_challenge,omitempty"`
+	SignedChallenge   string   `json:"signed_challenge,omitempty"`
+}
+
+type Info struct {
+	Event   string `json:"event"`
+	Version int    `json:"version"`
+}
+
+// SubscriptionStatus is received after subscribing or unsubscribing.
+type SubscriptionStatus struct {
+	Event      string   `json:"event"`
+	Feed       string   `json:"feed"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+type Error struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+}
+
+type Alert struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+}
+
+type Heartbeat struct {
+	Feed string    `json:"feed"`
+	Time MilliTime `json:"time"`
+}
+
+type BookLevel struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+type BookSnapshot struct {
+	Feed      string      `json:"feed"`
+	ProductID string      `json:"product_id"`
+	Timestamp MilliTime   `json:"timestamp"`
+	Seq       int64       `json:"seq"`
+	Bids      []BookLevel `json:"bids"`
+	Asks      []BookLevel `json:"asks"`
+}
+
+type BookUpdate struct {
+	Feed      string    `json:"feed"`
+	ProductID string    `json:"product_id"`
+	Side      string    `json:"side"`
+	Seq       int64     `json:"seq"`
+	Price     float64   `json:"price"`
+	Qty       float64   `json:"qty"`
+	Timestamp MilliTime `json:"timestamp"`
+}
+
+type Ticker struct {
+	Feed                  string    `json:"feed"`
+	ProductID             string    `json:"product_id"`
+	Time                  MilliTime `json:"time"`
+	Bid                   float64   `json:"bid"`
+	Ask                   float64   `json:"ask"`
+	BidSize               float64   `json:"bid_size"`
+	AskSize               float64   `json:"ask_size"`
+	Volume                float64   `json:"volume"`
+	Index                 float64   `json:"index"`
+	Last                  float64   `json:"last"`
+	Change                float64   `json:"change"`
+	Premium               float64   `json:"premium"`
+	FundingRate           float64   `json:"funding_rate"`
+	FundingRatePrediction float64   `json:"funding_rate_prediction"`
+	RelativeFundingRate   float64   `json:"relative_funding_rate"`
+	NextFundingRateTime   MilliTime `json:"next_funding_rate_time"`
+	OpenInterest          float64   `json:"openInterest"`
+	MarkPrice             float64   `json:"markPrice"`
+	Tag                   string    `json:"tag"`
+	Pair                  string    `json:"pair"`
+	Suspended             bool      `json:"suspended"`
+}
+
+type Trade struct {
+	Feed      string    `json:"feed"`
+	ProductID string    `json:"product_id"`
+	UID       string    `json:"uid"`
+	Side      string    `json:"side"`
+	Type      string    `json:"type"`
+	Seq       int64     `json:"seq"`
+	Time      MilliTime `json:"time"`
+	Qty       float64   `json:"qty"`
+	Price     float64   `json:"price"`
+}
+
+type TradeSnapshot struct {
+	Feed      string  `json:"feed"`
+	ProductID string  `json:"product_id"`
+	Trades    []Trade `json:"trades"`
+}
+
+type Fill struct {
+	Instrument    string    `json:"instrument"`
+	Time          MilliTime `json:"time"`
+	Price         float64   `json:"price"`
+	Seq           int64     `json:"seq"`
+	Buy           bool      `json:"buy"`
+	Qty           float64   `json:"qty"`
+	OrderID       string    `json:"order_id"`
+	ClientOrderID string    `json:"cli_ord_id"`
+	FillID        string    `json:"fill_id"`
+	FillType      string    `json:"fill_type"`
+	FeePaid       float64   `json:"fee_paid"`
+	FeeCurrency   string    `json:"fee_currency"`
+}
+
+// Fills is received for both the fills_snapshot and the fills feed.
+type Fills struct {
+	Feed     string `json:"feed"`
+	Username string `json:"username"`
+	Fills    []Fill `json:"fills"`
+}
+
+type Order struct {
+	Instrument     string    `json:"instrument"`
+	Time           MilliTime `json:"time"`
+	LastUpdateTime MilliTime `json:"last_update_time"`
+	Qty            float64   `json:"qty"`
+	Filled         float64   `json:"filled"`
+	LimitPrice     float64   `json:"limit_price"`
+	StopPrice      float64   `json:"stop_price"`
+	Type           string    `json:"type"`
+	OrderID        string    `json:"order_id"`
+	ClientOrderID  string    `json:"cli_ord_id"`
+	Direction      int       `json:"direction"`
+	ReduceOnly     bool      `json:"reduce_only"`
+}
+
+type OpenOrdersSnapshot struct {
+	Feed    string  `json:"feed"`
+	Account string  `json:"account"`
+	Orders  []Order `json:"orders"`
+}
+
+type OpenOrderUpdate struct {
+	Feed     string `json:"feed"`
+	Order    Order  `json:"order"`
+	OrderID  string `json:"order_id"`
+	IsCancel bool   `json:"is_cancel"`
+	Reason   string `json:"reason"`
+}
+
+type Position struct {
+	Instrument           string  `json:"instrument"`
+	Balance              float64 `json:"balance"`
+	PnL                  float64 `json:"pnl"`
+	EntryPrice           float64 `json:"entry_price"`
+	MarkPrice            float64 `json:"mark_price"`
+	IndexPrice           float64 `json:"index_price"`
+	LiquidationThreshold float64 `json:"liquidation_threshold"`
+	EffectiveLeverage    float64 `json:"effective_leverage"`
+	ReturnOnEquity       float64 `json:"return_on_equity"`
+}
+
+type OpenPositions struct {
+	Feed      string     `json:"feed"`
+	Account   string     `json:"account"`
+	Positions []Position `json:"positions"`
+	Seq       int64      `json:"seq"`
+	Timestamp MilliTime  `json:"timestamp"`
+}
+
+type FuturesBalance struct {
+	Name              string  `json:"name"`
+	PairName          string  `json:"pair_name"`
+	Unit              string  `json:"unit"`
+	PortfolioValue    float64 `json:"portfolio_value"`
+	Balance           float64 `json:"balance"`
+	MaintenanceMargin float64 `json:"maintenance_margin"`
+	InitialMargin     float64 `json:"initial_margin"`
+	AvailableMargin   float64 `json:"available"`
+	UnrealizedFunding float64 `json:"unrealized_funding"`
+	PnL               float64 `json:"pnl"`
+}
+
+// Balances is received for both the balances_snapshot and the balances feed.
+type Balances struct {
+	Feed      string                    `json:"feed"`
+	Account   string                    `json:"account"`
+	Holding   map[string]float64        `json:"holding"`
+	Futures   map[string]FuturesBalance `json:"futures"`
+	Timestamp MilliTime                 `json:"timestamp"`
+	Seq       int64                     `json:"seq"`
+}
@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type envelope struct {
+	Event string `json:"event"`
+	Feed  string `json:"feed"`
+}
+
+func unmarshalReceivedMessage(bytes []byte) (interface{}, error) {
+	var header envelope
+
+	if err := json.Unmarshal(bytes, &header); err != nil {
+		return nil, fmt.Errorf("parsing message failed: %w", err)
+	}
+
+	var target interface{}
+	var ok bool
+
+	if header.Event != "" {
+		eventMap := map[string]interface{}{
+			"alert":        &Alert{},
+			"challenge":    &Challenge{},
+			"error":        &Error{},
+			"info":         &Info{},
+			"subscribed":   &SubscriptionStatus{},
+			"unsubscribed": &SubscriptionStatus{},
+		}
+
+		if target, ok = eventMap[header.Event]; !ok {
+			return nil, fmt.Errorf("unknown event %s", header.Event)
+		}
+	} else {
+		feedMap := map[string]interface{}{
+			"balances":             &Balances{},
+			"balances_snapshot":    &Balances{},
+			"book":                 &BookUpdate{},
+			"book_snapshot":        &BookSnapshot{},
+			"fills":                &Fills{},
+			"fills_snapshot":       &Fills{},
+			"heartbeat":            &Heartbeat{},
+			"open_orders":          &OpenOrderUpdate{},
+			"open_orders_snapshot": &OpenOrdersSnapshot{},
+			"open_positions":       &OpenPositions{},
+			"ticker":               &Ticker{},
+			"trade":                &Trade{},
+			"trade_snapshot":       &TradeSnapshot{},
+		}
+
+		if target, ok = feedMap[header.Feed]; !ok {
+			return nil, fmt.Errorf("unknown feed %s", header.Feed)
+		}
+	}
+
+	if err := json.Unmarshal(bytes, target); err != nil {
+		return nil, fmt.Errorf("parsing %s%s failed: %w", header.Event, header.Feed, err)
+	}
+
+	return reflect.Indirect(reflect.ValueOf(target)).Interface(), nil
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalReceivedMessage(t *testing.T) {
+
+	type testCase struct {
+		name          string
+		bytes         []byte
+		expectedModel interface{}
+		expectedError error
+	}
+
+	timestamp := MilliTime(time.Date(2021, 2, 2, 12, 43, 45, 817000000, time.UTC))
+
+	testCases := []testCase{
+		{
+			name:          "info",
+			bytes:         []byte(`{"event":"info","version":1}`),
+			expectedModel: Info{Event: "info", Version: 1},
+		},
+		{
+			name:          "challenge",
+			bytes:         []byte(`{"event":"challenge","message":"226aee50-88fc-4618-a42a-34f7709570b2"}`),
+			expectedModel: Challenge{Event: "challenge", Message: "226aee50-88fc-4618-a42a-34f7709570b2"},
+		},
+		{
+			name:          "subscribed",
+			bytes:         []byte(`{"event":"subscribed","feed":"book","product_ids":["PI_XBTUSD"]}`),
+			expectedModel: SubscriptionStatus{Event: "subscribed", Feed: "book", ProductIDs: []string{"PI_XBTUSD"}},
+		},
+		{
+			name: "book_snapshot",
+			bytes: []byte(`{"feed":"book_snapshot","product_id":"PI_XBTUSD","timestamp":1612269825817,"seq":326072249,"tickSize":null,` +
+				`"bids":[{"price":34892.5,"qty":6385}],"asks":[{"price":34911.5,"qty":20598}]}`),
+			expectedModel: BookSnapshot{
+				Feed:      "book_snapshot",
+				ProductID: "PI_XBTUSD",
+				Timestamp: timestamp,
+				Seq:       326072249,
+				Bids:      []BookLevel{{Price: 34892.5, Qty: 6385}},
+				Asks:      []BookLevel{{Price: 34911.5, Qty: 20598}},
+			},
+		},
+		{
+			name:  "book",
+			bytes: []byte(`{"feed":"book","product_id":"PI_XBTUSD","side":"sell","seq":326094134,"price":34981.0,"qty":0.0,"timestamp":1612269825817}`),
+			expectedModel: BookUpdate{
+				Feed: "book", ProductID: "PI_XBTUSD", Side: "sell", Seq: 326094134, Price: 34981.0, Timestamp: timestamp,
+			},
+		},
+		{
+			name:  "trade",
+			bytes: []byte(`{"feed":"trade","product_id":"PI_XBTUSD","uid":"05af78ac-a774-478c-a50c-8b9c234e071e","side":"sell","type":"fill","seq":653355,"time":1612269825817,"qty":7423,"price":34893}`),
+			expectedModel: Trade{
+				Feed: "trade", ProductID: "PI_XBTUSD", UID: "05af78ac-a774-478c-a50c-8b9c234e071e", Side: "sell", Type: "fill",
+				Seq: 653355, Time: timestamp, Qty: 7423, Price: 34893,
+			},
+		},
+		{
+			name: "fills_snapshot",
+			bytes: []byte(`{"feed":"fills_snapshot","username":"DemoUser","fills":[{"instrument":"FI_XBTUSD_200925","time":1612269825817,` +
+				`"price":11937.5,"seq":36,"buy":true,"qty":5000.0,"order_id":"9e30258b-5a98-4002-968a-5b0e149bcfbf",` +
+				`"cli_ord_id":"8b58d9da-fcaf-4f60-91bc-9973a3eba48d","fill_id":"cad76f07-814e-4dc6-8478-7867407b6bff",` +
+				`"fill_type":"maker","fee_paid":-0.00009142,"fee_currency":"BTC"}]}`),
+			expectedModel: Fills{
+				Feed:     "fills_snapshot",
+				Username: "DemoUser",
+				Fills: []Fill{{
+					Instrument: "FI_XBTUSD_200925", Time: timestamp, Price: 11937.5, Seq: 36, Buy: true, Qty: 5000.0,
+					OrderID: "9e30258b-5a98-4002-968a-5b0e149bcfbf", ClientOrderID: "8b58d9da-fcaf-4f60-91bc-9973a3eba48d",
+					FillID: "cad76f07-814e-4dc6-8478-7867407b6bff", FillType: "maker", FeePaid: -0.00009142, FeeCurrency: "BTC",
+				}},
+			},
+		},
+		{
+			name: "open_orders",
+			bytes: []byte(`{"feed":"open_orders","order":{"instrument":"PI_XBTUSD","time":1612269825817,"last_update_time":1612269825817,` +
+				`"qty":1000.0,"filled":0.0,"limit_price":9400.0,"stop_price":0.0,"type":"limit","order_id":"46346b7c-9f84-4e03-9b86-1ac8c9ba7a1b",` +
+				`"direction":0,"reduce_only":false},"is_cancel":false,"reason":"new_placed_order_by_user"}`),
+			expectedModel: OpenOrderUpdate{
+				Feed: "open_orders",
+				Order: Order{
+					Instrument: "PI_XBTUSD", Time: timestamp, LastUpdateTime: timestamp, Qty: 1000.0, LimitPrice: 9400.0,
+					Type: "limit", OrderID: "46346b7c-9f84-4e03-9b86-1ac8c9ba7a1b",
+				},
+				Reason: "new_placed_order_by_user",
+			},
+		},
+		{
+			name:          "unknown feed",
+			bytes:         []byte(`{"feed":"foo"}`),
+			expectedError: errors.New("unknown feed foo"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			model, err := unmarshalReceivedMessage(testCase.bytes)
+
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedModel, model)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	spot "github.com/lk16/kraken/websocket"
+)
+
+const (
+	wsURL             = "wss://futures.kraken.com/ws/v1"
+	keepAliveDuration = 30 * time.Second
+	challengeTimeout  = 10 * time.Second
+)
+
+// privateFeeds require a signed challenge
+var privateFeeds = map[string]bool{
+	"balances":       true,
+	"fills":          true,
+	"open_orders":    true,
+	"open_positions": true,
+}
+
+type Client struct {
+	ws          *spot.Conn
+	receiveChan chan interface{}
+	verbose     bool
+
+	key           string
+	decodedSecret []byte
+
+	challengeMutex    sync.Mutex
+	challengeChan     chan string
+	originalChallenge string
+	signedChallenge   string
+
+	bookSeqMutex sync.Mutex
+	bookSeq      map[string]int64
+}
+
+func newClient() *Client {
+	return &Client{
+		receiveChan:   make(chan interface{}),
+		challengeChan: make(chan string, 1),
+		bookSeq:       make(map[string]int64),
+	}
+}
+
+func NewClient() (*Client, error) {
+	client := newClient()
+
+	conn, err := spot.Dial(wsURL, "futures")
+	if err != nil {
+		return nil, err
+	}
+
+	client.ws = conn
+
+	go client.wsListener(conn)
+	go conn.KeepAlivePing(keepAliveDuration, client.deliverError)
+	return client, nil
+}
+
+func (client *Client) SetVerbose(verbose bool) {
+	client.verbose = verbose
+
+	if client.ws != nil {
+		client.ws.SetVerbose(verbose)
+	}
+}
+
+func (client *Client) SetAuth(key string, secret string) error {
+	decodedSecret, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return err
+	}
+
+	client.key = key
+	client.decodedSecret = decodedSecret
+	return nil
+}
+
+func (client *Client) signChallenge(challenge string) string {
+	// note: calling Write() on a hash object cannot fail, the returned error is always nil
+
+	sha256State := sha256.New()
+	sha256State.Write([]byte(challenge))
+
+	hmacState := hmac.New(sha512.New, client.decodedSecret)
+	hmacState.Write(sha256State.Sum(nil))
+
+	return base64.StdEncoding.EncodeToString(hmacState.Sum(nil))
+}
+
+// authenticate requests a challenge once and signs it, the signed challenge is reused for all private feeds.
+func (client *Client) authenticate() error {
+	client.challengeMutex.Lock()
+	defer client.challengeMutex.Unlock()
+
+	if client.signedChallenge != "" {
+		return nil
+	}
+
+	if client.decodedSecret == nil {
+		return errors.New("private feeds require SetAuth()")
+	}
+
+	if err := client.write(Challenge{Event: "challenge", APIKey: client.key}); err != nil {
+		return err
+	}
+
+	select {
+	case challenge := <-client.challengeChan:
+		client.originalChallenge = challenge
+		client.signedChallenge = client.signChallenge(challenge)
+		return nil
+	case <-time.After(challengeTimeout):
+		return errors.New("no challenge received")
+	}
+}
+
+func (client *Client) wsListener(conn *spot.Conn) {
+	conn.ReadLoop(func(frame []byte) {
+		model, err := unmarshalReceivedMessage(frame)
+
+		if err != nil {
+			client.deliverError(err)
+			return
+		}
+
+		client.process(model)
+	}, client.deliverError)
+}
+
+func (client *Client) process(model interface{}) {
+	switch message := model.(type) {
+	case Challenge:
+		// consumed by authenticate()
+		select {
+		case client.challengeChan <- message.Message:
+		default:
+		}
+		return
+	case BookSnapshot:
+		client.setBookSeq(message.ProductID, message.Seq)
+	case BookUpdate:
+		if gap := client.checkBookSeq(message.ProductID, message.Seq); gap != nil {
+			client.receiveChan <- *gap
+		}
+	}
+
+	client.receiveChan <- model
+}
+
+func (client *Client) setBookSeq(productID string, seq int64) {
+	client.bookSeqMutex.Lock()
+	defer client.bookSeqMutex.Unlock()
+
+	client.bookSeq[productID] = seq
+}
+
+// checkBookSeq returns a gap if updates of productID were missed, the book should then be resubscribed.
+func (client *Client) checkBookSeq(productID string, seq int64) *spot.SequenceGap {
+	client.bookSeqMutex.Lock()
+	defer client.bookSeqMutex.Unlock()
+
+	last, ok := client.bookSeq[productID]
+	client.bookSeq[productID] = seq
+
+	if ok && seq != last+1 {
+		return &spot.SequenceGap{ChannelName: "book " + productID, Expected: last + 1, Received: seq}
+	}
+	return nil
+}
+
+func (client *Client) deliverError(err error) {
+	client.receiveChan <- err
+}
+
+// Listen returns the channel through which all received messages and errors are delivered.
+func (client *Client) Listen() <-chan interface{} {
+	return client.receiveChan
+}
+
+func (client *Client) write(message interface{}) error {
+	if client.ws == nil {
+		return errors.New("not connected to futures websocket")
+	}
+	return client.ws.WriteJSON(message)
+}
+
+func (client *Client) subscription(event string, feed string, productIDs []string) (Subscribe, error) {
+	subscribe := Subscribe{Event: event, Feed: feed, ProductIDs: productIDs}
+
+	if privateFeeds[feed] {
+		if err := client.authenticate(); err != nil {
+			return subscribe, fmt.Errorf("authenticating for %s failed: %w", feed, err)
+		}
+
+		subscribe.APIKey = client.key
+		subscribe.OriginalChallenge = client.originalChallenge
+		subscribe.SignedChallenge = client.signedChallenge
+	}
+	return subscribe, nil
+}
+
+// Subscribe subscribes to feed, productIDs are only used by public feeds.
+// Private feeds require SetAuth(), Listen() must be read concurrently while the challenge is requested.
+func (client *Client) Subscribe(feed string, productIDs []string) error {
+	subscribe, err := client.subscription("subscribe", feed, productIDs)
+	if err != nil {
+		return err
+	}
+	return client.write(subscribe)
+}
+
+func (client *Client) Unsubscribe(feed string, productIDs []string) error {
+	unsubscribe, err := client.subscription("unsubscribe", feed, productIDs)
+	if err != nil {
+		return err
+	}
+	return client.write(unsubscribe)
+}
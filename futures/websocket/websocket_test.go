@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"testing"
+
+	spot "github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignChallenge(t *testing.T) {
+	client := newClient()
+	assert.Nil(t, client.SetAuth("key", "c2VjcmV0"))
+
+	assert.Equal(t, "YZKOCBzBfTLHnSvMQyW3wnmY7vFAS6v/qi4kQ4cdk3WTNoGBpCrZhJsjI2/SYlSnrBFSdbdvOQMPQ7XCtPSyCA==",
+		client.signChallenge("c100b894-1729-464d-ae1c-0d2a8cd6a5b8"))
+}
+
+func TestProcessBookSequence(t *testing.T) {
+	client := newClient()
+	go func() {
+		client.process(Challenge{Event: "challenge", Message: "c100b894-1729-464d-ae1c-0d2a8cd6a5b8"})
+		client.process(BookSnapshot{Feed: "book_snapshot", ProductID: "PI_XBTUSD", Seq: 1})
+		client.process(BookUpdate{Feed: "book", ProductID: "PI_XBTUSD", Seq: 2})
+		client.process(BookUpdate{Feed: "book", ProductID: "PI_XBTUSD", Seq: 4})
+	}()
+
+	assert.IsType(t, BookSnapshot{}, <-client.Listen())
+	assert.IsType(t, BookUpdate{}, <-client.Listen())
+	assert.Equal(t, spot.SequenceGap{ChannelName: "book PI_XBTUSD", Expected: 3, Received: 4}, <-client.Listen())
+	assert.IsType(t, BookUpdate{}, <-client.Listen())
+
+	// challenges are not delivered through Listen()
+	assert.Equal(t, "c100b894-1729-464d-ae1c-0d2a8cd6a5b8", <-client.challengeChan)
+}
+
+func TestBookConversion(t *testing.T) {
+	manager := spot.NewBookManager(nil, 0)
+
+	snapshot := BookSnapshot{
+		ProductID: "PI_XBTUSD",
+		Bids:      []BookLevel{{Price: 99, Qty: 5}, {Price: 100, Qty: 10}},
+		Asks:      []BookLevel{{Price: 102, Qty: 3}, {Price: 101, Qty: 7}},
+	}
+	assert.Nil(t, manager.Handle(snapshot.Book()))
+	assert.Nil(t, manager.Handle(BookUpdate{ProductID: "PI_XBTUSD", Side: "sell", Price: 101, Qty: 0}.BookUpdate()))
+	assert.Nil(t, manager.Handle(BookUpdate{ProductID: "PI_XBTUSD", Side: "buy", Price: 100.5, Qty: 2}.BookUpdate()))
+
+	book, ok := manager.Snapshot("PI_XBTUSD")
+	assert.True(t, ok)
+	assert.Equal(t, []spot.PriceLevel{{Price: 102, Volume: 3}}, book.Data.Asks)
+	assert.Equal(t, []spot.PriceLevel{{Price: 100.5, Volume: 2}, {Price: 100, Volume: 10}, {Price: 99, Volume: 5}}, book.Data.Bids)
+}
+
+func TestSubscribePrivateRequiresAuth(t *testing.T) {
+	client := newClient()
+
+	err := client.Subscribe("fills", nil)
+	assert.EqualError(t, err, "authenticating for fills failed: private feeds require SetAuth()")
+}
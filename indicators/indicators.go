@@ -0,0 +1,125 @@
+package indicators
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lk16/kraken/candles"
+)
+
+// Indicator is updated with candles in order, such as those of the CandleClosed events of candles.Builder and
+// candles.OHLCTracker. A candle with the same Start as the previous one replaces it, so the candle in progress
+// of the ohlc channel can be passed on every update. Values are only meaningful once Ready() returns true.
+type Indicator interface {
+	Update(candle candles.Candle)
+	Ready() bool
+}
+
+var _ Indicator = &SMA{}
+var _ Indicator = &EMA{}
+var _ Indicator = &RSI{}
+var _ Indicator = &MACD{}
+var _ Indicator = &Bollinger{}
+var _ Indicator = &ATR{}
+var _ Indicator = &VWAP{}
+
+// Update updates all indicators with candle.
+func Update(candle candles.Candle, indicators ...Indicator) {
+	for _, indicator := range indicators {
+		indicator.Update(candle)
+	}
+}
+
+// PeriodError is returned by the constructors for periods that can not hold a value.
+type PeriodError struct {
+	Indicator string
+	Period    int
+}
+
+func (err PeriodError) Error() string {
+	return fmt.Sprintf("non-positive period %d for %s", err.Period, err.Indicator)
+}
+
+func checkPeriod(name string, period int) error {
+	if period <= 0 {
+		return PeriodError{Indicator: name, Period: period}
+	}
+	return nil
+}
+
+// bar tells apart a new candle from an update of the previous one.
+type bar struct {
+	start   time.Time
+	started bool
+}
+
+func (bar *bar) next(candle candles.Candle) bool {
+	if bar.started && candle.Start.Equal(bar.start) {
+		return false
+	}
+
+	bar.start = candle.Start
+	bar.started = true
+	return true
+}
+
+// window keeps the sum and sum of squares of the last values.
+type window struct {
+	values     []float64
+	next       int
+	count      int
+	sum        float64
+	sumSquares float64
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+func (window *window) add(value float64) {
+	if window.count == len(window.values) {
+		old := window.values[window.next]
+		window.sum -= old
+		window.sumSquares -= old * old
+	} else {
+		window.count++
+	}
+
+	window.values[window.next] = value
+	window.next = (window.next + 1) % len(window.values)
+	window.sum += value
+	window.sumSquares += value * value
+}
+
+// update adds value for a new candle and replaces the last value otherwise.
+func (window *window) update(value float64, next bool) {
+	if next || window.count == 0 {
+		window.add(value)
+		return
+	}
+
+	last := (window.next + len(window.values) - 1) % len(window.values)
+	old := window.values[last]
+	window.values[last] = value
+	window.sum += value - old
+	window.sumSquares += value*value - old*old
+}
+
+func (window *window) full() bool {
+	return window.count == len(window.values)
+}
+
+func (window *window) mean() float64 {
+	return window.sum / float64(window.count)
+}
+
+// variance is the population variance of the window.
+func (window *window) variance() float64 {
+	mean := window.mean()
+	variance := window.sumSquares/float64(window.count) - mean*mean
+	if variance < 0 {
+		// rounding errors
+		return 0
+	}
+	return variance
+}
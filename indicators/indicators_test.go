@@ -0,0 +1,183 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/candles"
+	"github.com/stretchr/testify/assert"
+)
+
+func minute(index int) time.Time {
+	return time.Unix(int64(index)*60, 0)
+}
+
+func mustIndicator(indicator Indicator, err error) Indicator {
+	if err != nil {
+		panic(err)
+	}
+	return indicator
+}
+
+func closes(values ...float64) []candles.Candle {
+	var series []candles.Candle
+	for index, value := range values {
+		series = append(series, candles.Candle{Start: minute(index), Open: value, High: value, Low: value, Close: value})
+	}
+	return series
+}
+
+func TestSingleValueIndicators(t *testing.T) {
+	testCases := []struct {
+		name      string
+		indicator Indicator
+		value     func(indicator Indicator) float64
+		series    []candles.Candle
+		readyFrom int
+		expected  []float64
+	}{
+		{
+			name:      "sma",
+			indicator: mustIndicator(NewSMA(3)),
+			value:     func(indicator Indicator) float64 { return indicator.(*SMA).Value() },
+			series:    closes(1, 2, 3, 4, 5),
+			readyFrom: 2,
+			expected:  []float64{1, 1.5, 2, 3, 4},
+		},
+		{
+			name:      "ema",
+			indicator: mustIndicator(NewEMA(3)),
+			value:     func(indicator Indicator) float64 { return indicator.(*EMA).Value() },
+			series:    closes(1, 2, 3, 4, 5, 2),
+			readyFrom: 2,
+			expected:  []float64{1, 1.5, 2, 3, 4, 3},
+		},
+		{
+			name:      "rsi",
+			indicator: mustIndicator(NewRSI(2)),
+			value:     func(indicator Indicator) float64 { return indicator.(*RSI).Value() },
+			series:    closes(1, 2, 3, 2, 2),
+			readyFrom: 2,
+			expected:  []float64{50, 100, 100, 50, 50},
+		},
+		{
+			name:      "atr",
+			indicator: mustIndicator(NewATR(2)),
+			value:     func(indicator Indicator) float64 { return indicator.(*ATR).Value() },
+			series: []candles.Candle{
+				{Start: minute(0), High: 10, Low: 8, Close: 9},
+				{Start: minute(1), High: 11, Low: 9, Close: 10},
+				{Start: minute(2), High: 12, Low: 10, Close: 11},
+				// gaps up, the true range starts at the previous close
+				{Start: minute(3), High: 15, Low: 13, Close: 14},
+			},
+			readyFrom: 1,
+			expected:  []float64{2, 2, 2, 3},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var values []float64
+			for index, candle := range testCase.series {
+				testCase.indicator.Update(candle)
+				assert.Equal(t, index >= testCase.readyFrom, testCase.indicator.Ready())
+				values = append(values, testCase.value(testCase.indicator))
+			}
+			assert.InDeltaSlice(t, testCase.expected, values, 1e-9)
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	macd := mustIndicator(NewMACD(2, 3, 2)).(*MACD)
+
+	for index, candle := range closes(1, 2, 3, 4, 5, 6) {
+		macd.Update(candle)
+		// the signal needs two values of the line, which needs three candles
+		assert.Equal(t, index >= 3, macd.Ready())
+	}
+
+	line, signal, histogram := macd.Value()
+	assert.InDelta(t, 0.5, line, 1e-9)
+	assert.InDelta(t, 0.5, signal, 1e-9)
+	assert.InDelta(t, 0, histogram, 1e-9)
+}
+
+func TestBollinger(t *testing.T) {
+	bollinger := mustIndicator(NewBollinger(3, 2)).(*Bollinger)
+	for _, candle := range closes(10, 1, 2, 3) {
+		bollinger.Update(candle)
+	}
+
+	assert.True(t, bollinger.Ready())
+
+	lower, middle, upper := bollinger.Value()
+	width := 2 * math.Sqrt(2.0/3)
+	assert.InDelta(t, 2-width, lower, 1e-9)
+	assert.InDelta(t, 2, middle, 1e-9)
+	assert.InDelta(t, 2+width, upper, 1e-9)
+}
+
+func TestVWAP(t *testing.T) {
+	start := time.Date(2021, 3, 4, 23, 58, 0, 0, time.UTC)
+	vwap := NewVWAP(24 * time.Hour)
+
+	assert.False(t, vwap.Ready())
+
+	Update(candles.Candle{Start: start, VWAP: 10, Volume: 1}, vwap)
+	// without a VWAP the typical price of 13 is used
+	Update(candles.Candle{Start: start.Add(time.Minute), High: 15, Low: 12, Close: 12, Volume: 3}, vwap)
+	assert.True(t, vwap.Ready())
+	assert.InDelta(t, 12.25, vwap.Value(), 1e-9)
+
+	// a new session starts at midnight
+	Update(candles.Candle{Start: start.Add(2 * time.Minute), VWAP: 20, Volume: 2}, vwap)
+	assert.InDelta(t, 20, vwap.Value(), 1e-9)
+}
+
+func TestUpdateReplacesCandleInProgress(t *testing.T) {
+	newIndicators := func() []Indicator {
+		return []Indicator{
+			mustIndicator(NewSMA(2)),
+			mustIndicator(NewEMA(2)),
+			mustIndicator(NewRSI(2)),
+			mustIndicator(NewMACD(2, 3, 2)),
+			mustIndicator(NewBollinger(2, 2)),
+			mustIndicator(NewATR(2)),
+			NewVWAP(0),
+		}
+	}
+
+	final := closes(1, 3, 2, 5, 4)
+	for index := range final {
+		final[index].Volume = 1
+	}
+
+	expected := newIndicators()
+	live := newIndicators()
+
+	for _, candle := range final {
+		Update(candle, expected...)
+
+		// the ohlc channel sends the candle in progress before its final version
+		inProgress := candle
+		inProgress.High, inProgress.Close, inProgress.Volume = 10, 10, 0.5
+		Update(inProgress, live...)
+		Update(candle, live...)
+	}
+
+	assert.Equal(t, expected, live)
+}
+
+func TestNonPositivePeriod(t *testing.T) {
+	_, err := NewSMA(0)
+	assert.Equal(t, PeriodError{Indicator: "SMA", Period: 0}, err)
+
+	_, err = NewBollinger(-1, 2)
+	assert.Equal(t, PeriodError{Indicator: "Bollinger", Period: -1}, err)
+
+	_, err = NewMACD(12, 0, 9)
+	assert.EqualError(t, err, "non-positive period 0 for MACD slow")
+}
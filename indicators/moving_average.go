@@ -0,0 +1,88 @@
+package indicators
+
+import "github.com/lk16/kraken/candles"
+
+// SMA is the simple moving average of the close price over period candles.
+type SMA struct {
+	window *window
+	bar    bar
+}
+
+func NewSMA(period int) (*SMA, error) {
+	if err := checkPeriod("SMA", period); err != nil {
+		return nil, err
+	}
+	return &SMA{window: newWindow(period)}, nil
+}
+
+func (sma *SMA) Update(candle candles.Candle) {
+	sma.window.update(candle.Close, sma.bar.next(candle))
+}
+
+func (sma *SMA) Ready() bool {
+	return sma.window.full()
+}
+
+func (sma *SMA) Value() float64 {
+	if sma.window.count == 0 {
+		return 0
+	}
+	return sma.window.mean()
+}
+
+// ema is an exponential moving average of values, seeded with the simple average of the first period values.
+type ema struct {
+	period int
+	alpha  float64
+	count  int
+	value  float64
+}
+
+func newEMA(period int) ema {
+	return ema{period: period, alpha: 2 / float64(period+1)}
+}
+
+func (ema *ema) add(value float64) {
+	ema.count++
+
+	if ema.count <= ema.period {
+		ema.value += (value - ema.value) / float64(ema.count)
+		return
+	}
+	ema.value += ema.alpha * (value - ema.value)
+}
+
+func (ema *ema) ready() bool {
+	return ema.count >= ema.period
+}
+
+// EMA is the exponential moving average of the close price with smoothing 2 / (period + 1).
+type EMA struct {
+	ema      ema
+	previous ema
+	bar      bar
+}
+
+func NewEMA(period int) (*EMA, error) {
+	if err := checkPeriod("EMA", period); err != nil {
+		return nil, err
+	}
+	return &EMA{ema: newEMA(period)}, nil
+}
+
+func (ema *EMA) Update(candle candles.Candle) {
+	if ema.bar.next(candle) {
+		ema.previous = ema.ema
+	} else {
+		ema.ema = ema.previous
+	}
+	ema.ema.add(candle.Close)
+}
+
+func (ema *EMA) Ready() bool {
+	return ema.ema.ready()
+}
+
+func (ema *EMA) Value() float64 {
+	return ema.ema.value
+}
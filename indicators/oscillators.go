@@ -0,0 +1,132 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/lk16/kraken/candles"
+)
+
+// wilder is Wilder's moving average, seeded with the simple average of the first period values.
+type wilder struct {
+	period int
+	count  int
+	value  float64
+}
+
+func (wilder *wilder) add(value float64) {
+	wilder.count++
+
+	if wilder.count <= wilder.period {
+		wilder.value += (value - wilder.value) / float64(wilder.count)
+		return
+	}
+	wilder.value = (wilder.value*float64(wilder.period-1) + value) / float64(wilder.period)
+}
+
+func (wilder *wilder) ready() bool {
+	return wilder.count >= wilder.period
+}
+
+// RSI is the relative strength index of the close price with Wilder's smoothing, between 0 and 100.
+type RSI struct {
+	rsiState
+	previous rsiState
+	bar      bar
+}
+
+type rsiState struct {
+	gains     wilder
+	losses    wilder
+	lastClose float64
+	started   bool
+}
+
+func NewRSI(period int) (*RSI, error) {
+	if err := checkPeriod("RSI", period); err != nil {
+		return nil, err
+	}
+	return &RSI{rsiState: rsiState{gains: wilder{period: period}, losses: wilder{period: period}}}, nil
+}
+
+func (rsi *RSI) Update(candle candles.Candle) {
+	if rsi.bar.next(candle) {
+		rsi.previous = rsi.rsiState
+	} else {
+		rsi.rsiState = rsi.previous
+	}
+
+	if rsi.started {
+		change := candle.Close - rsi.lastClose
+		rsi.gains.add(math.Max(change, 0))
+		rsi.losses.add(math.Max(-change, 0))
+	}
+
+	rsi.lastClose = candle.Close
+	rsi.started = true
+}
+
+// Ready returns true after period + 1 candles, as the first candle has no change.
+func (rsi *RSI) Ready() bool {
+	return rsi.gains.ready()
+}
+
+func (rsi *RSI) Value() float64 {
+	if rsi.losses.value == 0 {
+		if rsi.gains.value == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+rsi.gains.value/rsi.losses.value)
+}
+
+// MACD is the difference between a fast and a slow EMA of the close price, with an EMA of that difference as signal.
+type MACD struct {
+	macdState
+	previous macdState
+	bar      bar
+}
+
+type macdState struct {
+	fast   ema
+	slow   ema
+	signal ema
+}
+
+// NewMACD creates a MACD, the common parameters are 12, 26 and 9.
+func NewMACD(fast int, slow int, signal int) (*MACD, error) {
+	if err := checkPeriod("MACD fast", fast); err != nil {
+		return nil, err
+	}
+	if err := checkPeriod("MACD slow", slow); err != nil {
+		return nil, err
+	}
+	if err := checkPeriod("MACD signal", signal); err != nil {
+		return nil, err
+	}
+	return &MACD{macdState: macdState{fast: newEMA(fast), slow: newEMA(slow), signal: newEMA(signal)}}, nil
+}
+
+func (macd *MACD) Update(candle candles.Candle) {
+	if macd.bar.next(candle) {
+		macd.previous = macd.macdState
+	} else {
+		macd.macdState = macd.previous
+	}
+
+	macd.fast.add(candle.Close)
+	macd.slow.add(candle.Close)
+
+	if macd.fast.ready() && macd.slow.ready() {
+		macd.signal.add(macd.fast.value - macd.slow.value)
+	}
+}
+
+func (macd *MACD) Ready() bool {
+	return macd.signal.ready()
+}
+
+func (macd *MACD) Value() (line float64, signal float64, histogram float64) {
+	line = macd.fast.value - macd.slow.value
+	return line, macd.signal.value, line - macd.signal.value
+}
@@ -0,0 +1,85 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/lk16/kraken/candles"
+)
+
+// Bollinger bands are the SMA of the close price plus and minus a number of standard deviations.
+type Bollinger struct {
+	window     *window
+	deviations float64
+	bar        bar
+}
+
+// NewBollinger creates Bollinger bands, the common parameters are 20 and 2.
+func NewBollinger(period int, deviations float64) (*Bollinger, error) {
+	if err := checkPeriod("Bollinger", period); err != nil {
+		return nil, err
+	}
+	return &Bollinger{window: newWindow(period), deviations: deviations}, nil
+}
+
+func (bollinger *Bollinger) Update(candle candles.Candle) {
+	bollinger.window.update(candle.Close, bollinger.bar.next(candle))
+}
+
+func (bollinger *Bollinger) Ready() bool {
+	return bollinger.window.full()
+}
+
+func (bollinger *Bollinger) Value() (lower float64, middle float64, upper float64) {
+	if bollinger.window.count == 0 {
+		return 0, 0, 0
+	}
+
+	middle = bollinger.window.mean()
+	width := bollinger.deviations * math.Sqrt(bollinger.window.variance())
+	return middle - width, middle, middle + width
+}
+
+// ATR is the average true range with Wilder's smoothing.
+type ATR struct {
+	atrState
+	previous atrState
+	bar      bar
+}
+
+type atrState struct {
+	average   wilder
+	lastClose float64
+	started   bool
+}
+
+func NewATR(period int) (*ATR, error) {
+	if err := checkPeriod("ATR", period); err != nil {
+		return nil, err
+	}
+	return &ATR{atrState: atrState{average: wilder{period: period}}}, nil
+}
+
+func (atr *ATR) Update(candle candles.Candle) {
+	if atr.bar.next(candle) {
+		atr.previous = atr.atrState
+	} else {
+		atr.atrState = atr.previous
+	}
+
+	trueRange := candle.High - candle.Low
+	if atr.started {
+		trueRange = math.Max(trueRange, math.Max(math.Abs(candle.High-atr.lastClose), math.Abs(candle.Low-atr.lastClose)))
+	}
+
+	atr.average.add(trueRange)
+	atr.lastClose = candle.Close
+	atr.started = true
+}
+
+func (atr *ATR) Ready() bool {
+	return atr.average.ready()
+}
+
+func (atr *ATR) Value() float64 {
+	return atr.average.value
+}
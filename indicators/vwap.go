@@ -0,0 +1,62 @@
+package indicators
+
+import (
+	"time"
+
+	"github.com/lk16/kraken/candles"
+)
+
+// VWAP is the volume weighted average price since the start of the session. Candles without a VWAP of their own
+// are weighted at their typical price, (high + low + close) / 3.
+type VWAP struct {
+	vwapState
+	session  time.Duration
+	previous vwapState
+	bar      bar
+}
+
+type vwapState struct {
+	sessionStart time.Time
+	cost         float64
+	volume       float64
+}
+
+// NewVWAP creates a VWAP that restarts every session, aligned like time.Truncate. A session of 0 never restarts.
+func NewVWAP(session time.Duration) *VWAP {
+	return &VWAP{session: session}
+}
+
+func (vwap *VWAP) Update(candle candles.Candle) {
+	if vwap.bar.next(candle) {
+		vwap.previous = vwap.vwapState
+	} else {
+		vwap.vwapState = vwap.previous
+	}
+
+	if vwap.session > 0 {
+		if start := candle.Start.Truncate(vwap.session); !start.Equal(vwap.sessionStart) {
+			vwap.sessionStart = start
+			vwap.cost = 0
+			vwap.volume = 0
+		}
+	}
+
+	price := candle.VWAP
+	if price == 0 {
+		price = (candle.High + candle.Low + candle.Close) / 3
+	}
+
+	vwap.cost += price * candle.Volume
+	vwap.volume += candle.Volume
+}
+
+func (vwap *VWAP) Ready() bool {
+	return vwap.volume > 0
+}
+
+func (vwap *VWAP) Value() float64 {
+	if vwap.volume == 0 {
+		return 0
+	}
+	return vwap.cost / vwap.volume
+}
@@ -0,0 +1,94 @@
+// Package matching holds the fill semantics shared by the paper exchange and the backtester.
+package matching
+
+import (
+	"github.com/lk16/kraken/fees"
+	"github.com/lk16/kraken/websocket"
+)
+
+// Crossed returns whether a level at price would trade with an order at limit.
+func Crossed(buy bool, price float64, limit float64) bool {
+	if buy {
+		return price <= limit
+	}
+	return price >= limit
+}
+
+// HitsBids returns whether the aggressor of trade sold, so that it filled resting bids.
+func HitsBids(trade websocket.TradeData) bool {
+	return trade.Side == "s"
+}
+
+// Opposite returns the side of the book an order takes liquidity from.
+func Opposite(book websocket.Book, buy bool) []websocket.PriceLevel {
+	if buy {
+		return book.Data.Asks
+	}
+	return book.Data.Bids
+}
+
+type levelKey struct {
+	pair  string
+	buy   bool
+	price float64
+}
+
+// Liquidity tracks the volume taken from the book. Volume taken from a level is not available to other orders
+// until Reset() is called for the pair, when its book changed.
+type Liquidity struct {
+	consumed map[levelKey]float64
+}
+
+func NewLiquidity() *Liquidity {
+	return &Liquidity{consumed: make(map[levelKey]float64)}
+}
+
+func (liquidity *Liquidity) Reset(pair string) {
+	for key := range liquidity.consumed {
+		if key.pair == pair {
+			delete(liquidity.consumed, key)
+		}
+	}
+}
+
+// Take fills up to volume of an order against book, up to limit unless market is set, and calls fill with the
+// price and volume taken from each level.
+func (liquidity *Liquidity) Take(pair string, book websocket.Book, buy bool, market bool, limit float64, volume float64, fill func(price float64, volume float64)) {
+	for _, level := range Opposite(book, buy) {
+		price := float64(level.Price)
+
+		if volume <= 0 || (!market && !Crossed(buy, price, limit)) {
+			return
+		}
+
+		key := levelKey{pair: pair, buy: buy, price: price}
+		available := float64(level.Volume) - liquidity.consumed[key]
+		if available <= 0 {
+			continue
+		}
+
+		taken := volume
+		if available < taken {
+			taken = available
+		}
+
+		liquidity.consumed[key] += taken
+		volume -= taken
+		fill(price, taken)
+	}
+}
+
+// Fees charges fills by the tier of the volume traded so far.
+type Fees struct {
+	Tiers  []fees.Tier
+	Volume float64
+}
+
+// Charge returns the cost and fee of a fill and adds its cost to the traded volume.
+func (charges *Fees) Charge(price float64, volume float64, maker bool) (cost float64, fee float64) {
+	cost = price * volume
+	fee = cost * fees.Percent(charges.Tiers, charges.Volume, maker) / 100
+
+	charges.Volume += cost
+	return cost, fee
+}
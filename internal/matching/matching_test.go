@@ -0,0 +1,49 @@
+package matching
+
+import (
+	"testing"
+
+	"github.com/lk16/kraken/fees"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiquidityTake(t *testing.T) {
+	book := websocket.Book{Data: websocket.BookData{
+		Asks: []websocket.PriceLevel{{Price: 101, Volume: 1}, {Price: 102, Volume: 2}, {Price: 103, Volume: 5}},
+		Bids: []websocket.PriceLevel{{Price: 100, Volume: 1}},
+	}}
+
+	type fill struct{ price, volume float64 }
+
+	var fills []fill
+	record := func(price float64, volume float64) {
+		fills = append(fills, fill{price, volume})
+	}
+
+	liquidity := NewLiquidity()
+	liquidity.Take("XBT/EUR", book, true, false, 102, 5, record)
+	assert.Equal(t, []fill{{101, 1}, {102, 2}}, fills)
+
+	// taken volume is gone until the book changes
+	fills = nil
+	liquidity.Take("XBT/EUR", book, true, true, 0, 4, record)
+	assert.Equal(t, []fill{{103, 4}}, fills)
+
+	liquidity.Reset("XBT/EUR")
+	fills = nil
+	liquidity.Take("XBT/EUR", book, true, true, 0, 0.5, record)
+	assert.Equal(t, []fill{{101, 0.5}}, fills)
+}
+
+func TestFeesCharge(t *testing.T) {
+	charges := Fees{Tiers: fees.DefaultTiers, Volume: 49000}
+
+	cost, fee := charges.Charge(1000, 1, false)
+	assert.Equal(t, 1000.0, cost)
+	assert.InDelta(t, 2.6, fee, 1e-9)
+
+	// the first fill moved the volume into the next tier
+	_, fee = charges.Charge(1000, 1, true)
+	assert.InDelta(t, 1.4, fee, 1e-9)
+}
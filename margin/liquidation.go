@@ -0,0 +1,77 @@
+package margin
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+)
+
+// DefaultMarginStop is the margin_stop of the AssetPairs endpoint.
+const DefaultMarginStop = 40
+
+func MarginLevel(balance rest.TradeBalance) float64 {
+	if balance.MarginLevel != 0 {
+		return float64(balance.MarginLevel)
+	}
+
+	if balance.Margin == 0 {
+		return 0
+	}
+	return 100 * float64(balance.Equity) / float64(balance.Margin)
+}
+
+type Liquidation struct {
+	Price    float64
+	Distance float64
+}
+
+// EstimateLiquidation assumes the value of all other positions stays the same, balance must be requested in the
+// quote currency of the pair.
+func EstimateLiquidation(balance rest.TradeBalance, position Position, markPrice float64, marginStop float64) (Liquidation, bool) {
+	volume := position.RemainingVolume()
+	if volume <= 0 || balance.Margin == 0 || markPrice <= 0 {
+		return Liquidation{}, false
+	}
+
+	// equity that can be lost before the margin level reaches the margin stop
+	buffer := float64(balance.Equity) - float64(balance.Margin)*marginStop/100
+	move := buffer / volume
+
+	price := markPrice - move
+	if position.Type == "sell" {
+		price = markPrice + move
+	}
+
+	if price < 0 {
+		price = 0
+	}
+
+	return Liquidation{Price: price, Distance: 100 * math.Abs(markPrice-price) / markPrice}, true
+}
+
+func SettleOrder(position Position) websocket.AddOrder {
+	pair := position.Pair
+	if parsed, err := assets.ParsePair(pair); err == nil {
+		pair = parsed.WSName()
+	}
+
+	side := "sell"
+	if position.Type == "sell" {
+		side = "buy"
+	}
+
+	order := websocket.AddOrder{
+		Type:      side,
+		OrderType: "settle-position",
+		Pair:      pair,
+		Volume:    websocket.Round(position.RemainingVolume(), 8),
+	}
+
+	if leverage := math.Round(position.Leverage()); leverage >= 2 {
+		order.Leverage = fmt.Sprintf("%d", int(leverage))
+	}
+	return order
+}
@@ -0,0 +1,60 @@
+package margin
+
+import (
+	"testing"
+
+	"github.com/lk16/kraken/mockserver"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarginLevel(t *testing.T) {
+	server, err := mockserver.NewRESTServer("key", "c2VjcmV0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	balance, err := newTestClient(t, server).TradeBalance("ZEUR")
+	assert.Nil(t, err)
+	assert.Equal(t, "ZEUR", server.Requests()[0].Data.Get("asset"))
+	assert.Equal(t, 208.0, MarginLevel(balance))
+
+	assert.Equal(t, 208.0, MarginLevel(rest.TradeBalance{Equity: 10400, Margin: 5000}))
+	assert.Equal(t, 0.0, MarginLevel(rest.TradeBalance{Equity: 10400}))
+}
+
+func TestEstimateLiquidation(t *testing.T) {
+	balance := rest.TradeBalance{Equity: 10400, Margin: 5000}
+
+	long := testPosition()
+	liquidation, ok := EstimateLiquidation(balance, long, 31000, DefaultMarginStop)
+	assert.True(t, ok)
+	assert.InDelta(t, 10000, liquidation.Price, 1e-9)
+	assert.InDelta(t, 100*21000.0/31000, liquidation.Distance, 1e-9)
+
+	short := testPosition()
+	short.Type = "sell"
+	liquidation, ok = EstimateLiquidation(balance, short, 31000, DefaultMarginStop)
+	assert.True(t, ok)
+	assert.InDelta(t, 52000, liquidation.Price, 1e-9)
+
+	_, ok = EstimateLiquidation(rest.TradeBalance{Equity: 10400}, long, 31000, DefaultMarginStop)
+	assert.False(t, ok)
+}
+
+func TestSettleOrder(t *testing.T) {
+	assert.Equal(t, websocket.AddOrder{
+		Type:      "sell",
+		OrderType: "settle-position",
+		Pair:      "XBT/EUR",
+		Volume:    "0.40000000",
+		Leverage:  "3",
+	}, SettleOrder(testPosition()))
+
+	short := testPosition()
+	short.Type = "sell"
+	short.Margin = 0
+	order := SettleOrder(short)
+	assert.Equal(t, "buy", order.Type)
+	assert.Equal(t, "", order.Leverage)
+}
@@ -0,0 +1,259 @@
+package margin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+)
+
+// Position is identified by the postxid of its trades, Cost, Fee and Margin are those of the opening trades.
+type Position struct {
+	PositionID   string
+	Pair         string
+	Type         string
+	OpenTime     time.Time
+	Volume       float64
+	VolumeClosed float64
+	Cost         float64
+	Fee          float64
+	Margin       float64
+	Realized     float64
+	// Value and Net are as of the last Resync.
+	Value    float64
+	Net      float64
+	TradeIDs []string
+}
+
+func (position Position) RemainingVolume() float64 {
+	return position.Volume - position.VolumeClosed
+}
+
+func (position Position) OpenPrice() float64 {
+	if position.Volume == 0 {
+		return 0
+	}
+	return position.Cost / position.Volume
+}
+
+func (position Position) Leverage() float64 {
+	if position.Margin == 0 {
+		return 0
+	}
+	return position.Cost / position.Margin
+}
+
+func (position Position) IsClosed() bool {
+	return position.RemainingVolume() <= 1e-9
+}
+
+func (position Position) profit(volume float64, price float64) float64 {
+	if position.Type == "sell" {
+		return volume * (position.OpenPrice() - price)
+	}
+	return volume * (price - position.OpenPrice())
+}
+
+func (position Position) copy() Position {
+	position.TradeIDs = append([]string(nil), position.TradeIDs...)
+	return position
+}
+
+type PositionOpened struct {
+	Position Position
+}
+
+type PositionChanged struct {
+	TradeID  string
+	Position Position
+}
+
+type PositionClosed struct {
+	Position Position
+}
+
+type PositionSource interface {
+	OpenPositions(docalcs bool, transactionIDs ...string) (map[string]rest.Position, error)
+}
+
+// PositionTracker links margin trades to positions by their postxid.
+type PositionTracker struct {
+	mutex     sync.RWMutex
+	now       func() time.Time
+	positions map[string]*Position
+	trades    *websocket.OwnTradeStore
+	syncTime  time.Time
+}
+
+func NewPositionTracker() *PositionTracker {
+	return &PositionTracker{
+		now:       time.Now,
+		positions: make(map[string]*Position),
+		trades:    websocket.NewOwnTradeStore(),
+	}
+}
+
+func (tracker *PositionTracker) Handle(rawMessage interface{}) []interface{} {
+	message, ok := rawMessage.(websocket.OwnTrades)
+	if !ok {
+		return nil
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	var events []interface{}
+	for _, entry := range tracker.trades.Add(message) {
+		events = append(events, tracker.addTrade(entry.TradeID, entry.Trade)...)
+	}
+	return events
+}
+
+func (tracker *PositionTracker) addTrade(tradeID string, trade websocket.OwnTrade) []interface{} {
+	if trade.PosTransactionID == "" || !time.Time(trade.Time).After(tracker.syncTime) {
+		return nil
+	}
+
+	volume, price := float64(trade.Volume), float64(trade.Price)
+
+	// spot trades carry a postxid as well, only margin trades open positions
+	position, ok := tracker.positions[trade.PosTransactionID]
+	if !ok && trade.Margin == 0 {
+		return nil
+	}
+
+	if !ok {
+		position = &Position{
+			PositionID: trade.PosTransactionID,
+			Pair:       trade.Pair,
+			Type:       trade.Type,
+			OpenTime:   time.Time(trade.Time),
+		}
+		tracker.positions[position.PositionID] = position
+	}
+
+	position.TradeIDs = append(position.TradeIDs, tradeID)
+
+	if trade.Type == position.Type {
+		position.Volume += volume
+		position.Cost += float64(trade.Cost)
+		position.Fee += float64(trade.Fee)
+		position.Margin += float64(trade.Margin)
+
+		if !ok {
+			return []interface{}{PositionOpened{Position: position.copy()}}
+		}
+		return []interface{}{PositionChanged{TradeID: tradeID, Position: position.copy()}}
+	}
+
+	if remaining := position.RemainingVolume(); volume > remaining {
+		volume = remaining
+	}
+
+	position.Realized += position.profit(volume, price)
+	position.VolumeClosed += volume
+
+	if position.IsClosed() {
+		delete(tracker.positions, position.PositionID)
+		return []interface{}{PositionClosed{Position: position.copy()}}
+	}
+	return []interface{}{PositionChanged{TradeID: tradeID, Position: position.copy()}}
+}
+
+func positionFromREST(positionID string, position rest.Position) Position {
+	return Position{
+		PositionID:   positionID,
+		Pair:         position.Pair,
+		Type:         position.Type,
+		OpenTime:     time.Time(position.Time),
+		Volume:       float64(position.Volume),
+		VolumeClosed: float64(position.VolumeClosed),
+		Cost:         float64(position.Cost),
+		Fee:          float64(position.Fee),
+		Margin:       float64(position.Margin),
+		Value:        float64(position.Value),
+		Net:          float64(position.Net),
+	}
+}
+
+// Resync should be called on start and after a SequenceGap.
+func (tracker *PositionTracker) Resync(source PositionSource) ([]interface{}, error) {
+	syncTime := tracker.now()
+
+	openPositions, err := source.OpenPositions(true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get open positions: %w", err)
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	var events []interface{}
+
+	positions := make(map[string]*Position)
+	for positionID, openPosition := range openPositions {
+		position := positionFromREST(positionID, openPosition)
+
+		if tracked, ok := tracker.positions[positionID]; ok {
+			position.Realized = tracked.Realized
+			position.TradeIDs = tracked.TradeIDs
+		} else {
+			events = append(events, PositionOpened{Position: position.copy()})
+		}
+		positions[positionID] = &position
+	}
+
+	for positionID, tracked := range tracker.positions {
+		if _, ok := positions[positionID]; !ok {
+			events = append(events, PositionClosed{Position: tracked.copy()})
+		}
+	}
+
+	tracker.positions = positions
+	tracker.syncTime = syncTime
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventPositionID(events[i]) < eventPositionID(events[j])
+	})
+	return events, nil
+}
+
+func eventPositionID(event interface{}) string {
+	switch event := event.(type) {
+	case PositionOpened:
+		return event.Position.PositionID
+	case PositionClosed:
+		return event.Position.PositionID
+	default:
+		return ""
+	}
+}
+
+func (tracker *PositionTracker) Position(positionID string) (Position, bool) {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	position, ok := tracker.positions[positionID]
+	if !ok {
+		return Position{}, false
+	}
+	return position.copy(), true
+}
+
+func (tracker *PositionTracker) Positions() []Position {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	positions := make([]Position, 0, len(tracker.positions))
+	for _, position := range tracker.positions {
+		positions = append(positions, position.copy())
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].PositionID < positions[j].PositionID
+	})
+	return positions
+}
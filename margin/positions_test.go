@@ -0,0 +1,178 @@
+package margin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/mockserver"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+var syncTime = time.Unix(1614859200, 0)
+
+const testPositionID = "TF5GVO-T7ZZ2-6NBKBI"
+
+func newTestClient(t *testing.T, server *mockserver.RESTServer) *rest.Client {
+	client := rest.NewClient(rest.WithURL(server.URL()))
+	assert.Nil(t, client.SetAuth("key", "c2VjcmV0"))
+	return client
+}
+
+func testPosition() Position {
+	return Position{
+		PositionID:   testPositionID,
+		Pair:         "XXBTZEUR",
+		Type:         "buy",
+		OpenTime:     time.Unix(1614859200, 500000000),
+		Volume:       0.5,
+		VolumeClosed: 0.1,
+		Cost:         15000,
+		Fee:          24,
+		Margin:       5000,
+		Value:        12400,
+		Net:          400,
+	}
+}
+
+func marginTrade(positionID string, pair string, side string, price float64, volume float64, second int) websocket.OwnTrade {
+	return websocket.OwnTrade{
+		PosTransactionID: positionID,
+		Pair:             pair,
+		Type:             side,
+		Price:            websocket.Float64String(price),
+		Volume:           websocket.Float64String(volume),
+		Cost:             websocket.Float64String(price * volume),
+		Margin:           websocket.Float64String(price * volume / 5),
+		Time:             websocket.UnixTime(syncTime.Add(time.Duration(second) * time.Second)),
+	}
+}
+
+func ownTrades(trades map[string]websocket.OwnTrade) websocket.OwnTrades {
+	message := websocket.OwnTrades{ChannelName: "ownTrades"}
+	for id, trade := range trades {
+		message.Trades = append(message.Trades, websocket.OwnTradeEntry{TradeID: id, Trade: trade})
+	}
+	return message
+}
+
+func TestPositionTracker(t *testing.T) {
+	server, err := mockserver.NewRESTServer("key", "c2VjcmV0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	tracker := NewPositionTracker()
+	tracker.now = func() time.Time { return syncTime }
+
+	events, err := tracker.Resync(client)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{PositionOpened{Position: testPosition()}}, events)
+	assert.Equal(t, "true", server.Requests()[0].Data.Get("docalcs"))
+
+	position, ok := tracker.Position(testPositionID)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.4, position.RemainingVolume(), 1e-9)
+	assert.Equal(t, 30000.0, position.OpenPrice())
+	assert.Equal(t, 3.0, position.Leverage())
+
+	assert.Empty(t, tracker.Handle(websocket.HeartBeat{}))
+
+	events = tracker.Handle(ownTrades(map[string]websocket.OwnTrade{
+		// part of the position returned by Resync
+		"TOLD": marginTrade(testPositionID, "XBT/EUR", "buy", 30000, 0.1, -10),
+		// not a margin trade
+		"TSPOT": marginTrade("", "XBT/EUR", "buy", 31000, 1, 5),
+		"T1":    marginTrade(testPositionID, "XBT/EUR", "sell", 31000, 0.2, 10),
+		"T2":    marginTrade("TNEW", "ETH/EUR", "sell", 2000, 1, 15),
+		"T3":    marginTrade(testPositionID, "XBT/EUR", "sell", 32000, 0.2, 20),
+	}))
+	assert.Len(t, events, 3)
+
+	changed := events[0].(PositionChanged)
+	assert.Equal(t, "T1", changed.TradeID)
+	assert.InDelta(t, 0.3, changed.Position.VolumeClosed, 1e-9)
+	assert.InDelta(t, 200, changed.Position.Realized, 1e-9)
+
+	opened := events[1].(PositionOpened)
+	assert.Equal(t, Position{
+		PositionID: "TNEW",
+		Pair:       "ETH/EUR",
+		Type:       "sell",
+		OpenTime:   syncTime.Add(15 * time.Second),
+		Volume:     1,
+		Cost:       2000,
+		Margin:     400,
+		TradeIDs:   []string{"T2"},
+	}, opened.Position)
+
+	closed := events[2].(PositionClosed)
+	assert.True(t, closed.Position.IsClosed())
+	assert.InDelta(t, 600, closed.Position.Realized, 1e-9)
+	assert.Equal(t, []string{"T1", "T3"}, closed.Position.TradeIDs)
+
+	// trades are only applied once
+	assert.Empty(t, tracker.Handle(ownTrades(map[string]websocket.OwnTrade{
+		"T2": marginTrade("TNEW", "ETH/EUR", "sell", 2000, 1, 15),
+	})))
+
+	positions := tracker.Positions()
+	assert.Len(t, positions, 1)
+	assert.Equal(t, "TNEW", positions[0].PositionID)
+
+	// a short position is closed by buying, with profit when the price fell
+	events = tracker.Handle(ownTrades(map[string]websocket.OwnTrade{
+		"T4": marginTrade("TNEW", "ETH/EUR", "buy", 1900, 1, 30),
+	}))
+	assert.InDelta(t, 100, events[0].(PositionClosed).Position.Realized, 1e-9)
+
+	server.FailNext("OpenPositions", "EGeneral:Internal error")
+	_, err = tracker.Resync(client)
+	assert.Error(t, err)
+}
+
+func TestPositionTrackerSpotTrades(t *testing.T) {
+	tracker := NewPositionTracker()
+
+	// Kraken sends a postxid with spot trades as well
+	spot := marginTrade("TKH2SE-M7IF5-CFI7LT", "XBT/EUR", "buy", 30000, 1, 10)
+	spot.Margin = 0
+	assert.Empty(t, tracker.Handle(ownTrades(map[string]websocket.OwnTrade{"T1": spot})))
+	assert.Empty(t, tracker.Positions())
+
+	// without margin a trade still closes a tracked position
+	assert.Len(t, tracker.Handle(ownTrades(map[string]websocket.OwnTrade{
+		"T2": marginTrade("TPOS", "XBT/EUR", "buy", 30000, 1, 20),
+	})), 1)
+
+	closing := marginTrade("TPOS", "XBT/EUR", "sell", 31000, 1, 30)
+	closing.Margin = 0
+	events := tracker.Handle(ownTrades(map[string]websocket.OwnTrade{"T3": closing}))
+	assert.InDelta(t, 1000, events[0].(PositionClosed).Position.Realized, 1e-9)
+}
+
+func TestPositionTrackerResync(t *testing.T) {
+	server, err := mockserver.NewRESTServer("key", "c2VjcmV0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	tracker := NewPositionTracker()
+	tracker.now = func() time.Time { return syncTime }
+
+	tracker.Handle(ownTrades(map[string]websocket.OwnTrade{
+		"T1": marginTrade("TGONE", "ETH/EUR", "buy", 2000, 1, 1),
+	}))
+
+	events, err := tracker.Resync(newTestClient(t, server))
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, PositionOpened{Position: testPosition()}, events[0])
+	assert.Equal(t, "TGONE", events[1].(PositionClosed).Position.PositionID)
+
+	// trades of the missed period are part of the resynced positions
+	assert.Empty(t, tracker.Handle(ownTrades(map[string]websocket.OwnTrade{
+		"T2": marginTrade(testPositionID, "XBT/EUR", "sell", 31000, 0.1, -5),
+	})))
+}
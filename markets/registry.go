@@ -0,0 +1,298 @@
+package markets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+)
+
+type Source interface {
+	AssetPairs() (map[string]rest.AssetPair, error)
+	Assets() (map[string]rest.Asset, error)
+}
+
+type AssetInfo struct {
+	Name            string
+	Altname         string
+	Decimals        int
+	DisplayDecimals int
+	Status          string
+}
+
+// PairInfo holds the names and trading rules of a pair. Name is the REST name such as "XXBTZEUR",
+// Altname is "XBTEUR" and WSName is "XBT/EUR".
+type PairInfo struct {
+	Pair           assets.Pair
+	Name           string
+	Altname        string
+	WSName         string
+	Base           AssetInfo
+	Quote          AssetInfo
+	PriceDecimals  int
+	VolumeDecimals int
+	CostDecimals   int
+	OrderMin       float64
+	CostMin        float64
+	TickSize       float64
+	Status         string
+	Details        rest.AssetPair
+}
+
+type UnknownPairError struct {
+	Pair string
+}
+
+func (err UnknownPairError) Error() string {
+	return fmt.Sprintf("unknown pair %s", err.Pair)
+}
+
+type InvalidOrderError struct {
+	Pair   string
+	Reason string
+}
+
+func (err InvalidOrderError) Error() string {
+	return fmt.Sprintf("invalid order for %s: %s", err.Pair, err.Reason)
+}
+
+type Registry struct {
+	mutex  sync.RWMutex
+	pairs  map[string]*PairInfo
+	assets map[string]*AssetInfo
+}
+
+func Load(source Source) (*Registry, error) {
+	assets, err := source.Assets()
+	if err != nil {
+		return nil, fmt.Errorf("could not get assets: %w", err)
+	}
+
+	pairs, err := source.AssetPairs()
+	if err != nil {
+		return nil, fmt.Errorf("could not get asset pairs: %w", err)
+	}
+
+	registry := NewRegistry()
+	registry.Update(pairs, assets)
+	return registry, nil
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		pairs:  make(map[string]*PairInfo),
+		assets: make(map[string]*AssetInfo),
+	}
+}
+
+func (registry *Registry) Update(pairs map[string]rest.AssetPair, assets map[string]rest.Asset) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for name, asset := range assets {
+		info := &AssetInfo{
+			Name:            name,
+			Altname:         asset.Altname,
+			Decimals:        asset.Decimals,
+			DisplayDecimals: asset.DisplayDecimals,
+			Status:          asset.Status,
+		}
+		registry.assets[name] = info
+		registry.assets[asset.Altname] = info
+	}
+
+	for name, pair := range pairs {
+		info := &PairInfo{
+			Pair:           pair.Canonical(),
+			Name:           name,
+			Altname:        pair.Altname,
+			WSName:         pair.WSName,
+			Base:           registry.asset(pair.Base),
+			Quote:          registry.asset(pair.Quote),
+			PriceDecimals:  pair.PairDecimals,
+			VolumeDecimals: pair.LotDecimals,
+			CostDecimals:   pair.CostDecimals,
+			OrderMin:       float64(pair.OrderMin),
+			CostMin:        float64(pair.CostMin),
+			TickSize:       float64(pair.TickSize),
+			Status:         pair.Status,
+			Details:        pair,
+		}
+
+		for _, key := range []string{name, pair.Altname, pair.WSName, info.Pair.V2Name()} {
+			if key != "" {
+				registry.pairs[key] = info
+			}
+		}
+	}
+}
+
+func (registry *Registry) asset(name string) AssetInfo {
+	if info, ok := registry.assets[name]; ok {
+		return *info
+	}
+	return AssetInfo{Name: name, Altname: name}
+}
+
+// Pair looks up a pair by its REST name, altname, websocket name or v2 name.
+func (registry *Registry) Pair(name string) (PairInfo, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	info, ok := registry.pairs[name]
+	if !ok {
+		pair, err := assets.ParsePair(name)
+		if err != nil {
+			return PairInfo{}, false
+		}
+
+		if info, ok = registry.pairs[pair.WSName()]; !ok {
+			return PairInfo{}, false
+		}
+	}
+	return *info, true
+}
+
+func (registry *Registry) Lookup(pair assets.Pair) (PairInfo, bool) {
+	return registry.Pair(pair.WSName())
+}
+
+func (registry *Registry) pair(name string) (PairInfo, error) {
+	info, ok := registry.Pair(name)
+	if !ok {
+		return PairInfo{}, UnknownPairError{Pair: name}
+	}
+	return info, nil
+}
+
+func (registry *Registry) Asset(name string) (AssetInfo, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	info, ok := registry.assets[name]
+	if !ok {
+		return AssetInfo{}, false
+	}
+	return *info, true
+}
+
+func (registry *Registry) Pairs() []PairInfo {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	seen := make(map[string]bool)
+
+	var pairs []PairInfo
+	for _, info := range registry.pairs {
+		if !seen[info.Name] {
+			seen[info.Name] = true
+			pairs = append(pairs, *info)
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs
+}
+
+func roundDecimals(number float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(number*scale) / scale
+}
+
+func (registry *Registry) RoundPrice(pair string, price float64) (float64, error) {
+	info, err := registry.pair(pair)
+	if err != nil {
+		return 0, err
+	}
+
+	if info.TickSize > 0 {
+		price = math.Round(price/info.TickSize) * info.TickSize
+	}
+	return roundDecimals(price, info.PriceDecimals), nil
+}
+
+// RoundVolume rounds volume down to the lot decimals of pair, so it never exceeds the available balance.
+func (registry *Registry) RoundVolume(pair string, volume float64) (float64, error) {
+	info, err := registry.pair(pair)
+	if err != nil {
+		return 0, err
+	}
+
+	scale := math.Pow10(info.VolumeDecimals)
+	// the small offset keeps values like 0.29999999999 that mean 0.3 from being rounded down
+	return math.Floor(volume*scale+1e-6) / scale, nil
+}
+
+func (registry *Registry) ValidateOrder(pair string, price float64, volume float64) error {
+	info, err := registry.pair(pair)
+	if err != nil {
+		return err
+	}
+
+	invalid := func(format string, args ...interface{}) error {
+		return InvalidOrderError{Pair: pair, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if info.Status != "" && info.Status != "online" {
+		return invalid("pair is %s", info.Status)
+	}
+
+	if volume < info.OrderMin {
+		return invalid("volume %v is below the minimum of %v", volume, info.OrderMin)
+	}
+
+	if price == 0 {
+		return nil
+	}
+
+	if cost := price * volume; cost < info.CostMin {
+		return invalid("cost %v is below the minimum of %v", cost, info.CostMin)
+	}
+
+	if rounded, _ := registry.RoundPrice(pair, price); math.Abs(rounded-price) > info.TickSize*1e-6 {
+		return invalid("price %v is not a multiple of the tick size %v", price, info.TickSize)
+	}
+	return nil
+}
+
+// NewAddOrder rounds price and volume to the precision of pair, price is ignored for market orders.
+func (registry *Registry) NewAddOrder(pair string, side string, orderType string, price float64, volume float64) (websocket.AddOrder, error) {
+	info, err := registry.pair(pair)
+	if err != nil {
+		return websocket.AddOrder{}, err
+	}
+
+	if orderType == "market" {
+		price = 0
+	}
+
+	if price, err = registry.RoundPrice(pair, price); err != nil {
+		return websocket.AddOrder{}, err
+	}
+
+	if volume, err = registry.RoundVolume(pair, volume); err != nil {
+		return websocket.AddOrder{}, err
+	}
+
+	if err := registry.ValidateOrder(pair, price, volume); err != nil {
+		return websocket.AddOrder{}, err
+	}
+
+	order := websocket.AddOrder{
+		Type:      side,
+		OrderType: orderType,
+		Pair:      info.WSName,
+		Volume:    websocket.Round(volume, info.VolumeDecimals),
+	}
+
+	if price != 0 {
+		order.Price = websocket.Round(price, info.PriceDecimals)
+	}
+	return order, nil
+}
@@ -0,0 +1,128 @@
+package markets
+
+import (
+	"testing"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/mockserver"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestRegistry(t *testing.T) *Registry {
+	server, err := mockserver.NewRESTServer("key", "c2VjcmV0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	registry, err := Load(rest.NewClient(rest.WithURL(server.URL())))
+	assert.Nil(t, err)
+	return registry
+}
+
+func TestRegistryLookup(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	for _, name := range []string{"XXBTZEUR", "XBTEUR", "XBT/EUR", "BTC/EUR", "BTCEUR"} {
+		info, ok := registry.Pair(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, "XXBTZEUR", info.Name)
+		assert.Equal(t, "XBT/EUR", info.WSName)
+		assert.Equal(t, assets.Pair{Base: "XBT", Quote: "EUR"}, info.Pair)
+		assert.Equal(t, AssetInfo{Name: "XXBT", Altname: "XBT", Decimals: 10, DisplayDecimals: 5, Status: "enabled"}, info.Base)
+		assert.Equal(t, "EUR", info.Quote.Altname)
+		assert.Equal(t, 1, info.PriceDecimals)
+		assert.Equal(t, 8, info.VolumeDecimals)
+		assert.Equal(t, 0.0001, info.OrderMin)
+	}
+
+	_, ok := registry.Pair("ETH/EUR")
+	assert.False(t, ok)
+
+	info, ok := registry.Lookup(assets.NewPair("XXRP", "ZEUR"))
+	assert.True(t, ok)
+	assert.Equal(t, "XXRPZEUR", info.Name)
+
+	asset, ok := registry.Asset("XRP")
+	assert.True(t, ok)
+	assert.Equal(t, "XXRP", asset.Name)
+
+	var names []string
+	for _, info := range registry.Pairs() {
+		names = append(names, info.Name)
+	}
+	assert.Equal(t, []string{"XXBTZEUR", "XXRPZEUR"}, names)
+}
+
+func TestRegistryRounding(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	testCases := []struct {
+		pair   string
+		price  float64
+		volume float64
+		// rounded
+		expectedPrice  float64
+		expectedVolume float64
+	}{
+		{pair: "XBT/EUR", price: 30300.06, volume: 0.123456789, expectedPrice: 30300.1, expectedVolume: 0.12345678},
+		{pair: "XBT/EUR", price: 30300.04, volume: 0.3, expectedPrice: 30300, expectedVolume: 0.3},
+		{pair: "XRPEUR", price: 0.4269999, volume: 10.999999999, expectedPrice: 0.427, expectedVolume: 10.99999999},
+	}
+
+	for _, testCase := range testCases {
+		price, err := registry.RoundPrice(testCase.pair, testCase.price)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expectedPrice, price)
+
+		volume, err := registry.RoundVolume(testCase.pair, testCase.volume)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expectedVolume, volume)
+	}
+
+	_, err := registry.RoundPrice("ETH/EUR", 1)
+	assert.Equal(t, UnknownPairError{Pair: "ETH/EUR"}, err)
+}
+
+func TestRegistryValidateOrder(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	testCases := []struct {
+		name   string
+		price  float64
+		volume float64
+		reason string
+	}{
+		{name: "valid", price: 30300.1, volume: 0.001},
+		{name: "market", price: 0, volume: 0.0001},
+		{name: "volume", price: 30300, volume: 0.00001, reason: "volume 1e-05 is below the minimum of 0.0001"},
+		{name: "cost", price: 1000, volume: 0.0001, reason: "cost 0.1 is below the minimum of 0.5"},
+		{name: "tick", price: 30300.05, volume: 0.001, reason: "price 30300.05 is not a multiple of the tick size 0.1"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := registry.ValidateOrder("XBT/EUR", testCase.price, testCase.volume)
+			if testCase.reason == "" {
+				assert.Nil(t, err)
+				return
+			}
+			assert.Equal(t, InvalidOrderError{Pair: "XBT/EUR", Reason: testCase.reason}, err)
+		})
+	}
+}
+
+func TestRegistryNewAddOrder(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	order, err := registry.NewAddOrder("XXBTZEUR", "buy", "limit", 30300.06, 0.123456789)
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.AddOrder{Type: "buy", OrderType: "limit", Pair: "XBT/EUR", Price: "30300.1", Volume: "0.12345678"}, order)
+
+	order, err = registry.NewAddOrder("XBT/EUR", "sell", "market", 30300, 0.5)
+	assert.Nil(t, err)
+	assert.Equal(t, "", order.Price)
+
+	_, err = registry.NewAddOrder("XRP/EUR", "buy", "limit", 0.42, 1)
+	assert.IsType(t, InvalidOrderError{}, err)
+}
@@ -0,0 +1,647 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	kraken "github.com/lk16/kraken/websocket"
+)
+
+const (
+	// DefaultToken is the token private requests must use, unless Server.SetToken() is called.
+	DefaultToken = "mock-token"
+
+	systemStatusVersion = "1.9.0"
+)
+
+// Request is a message the server received from a client.
+type Request struct {
+	Connection string
+	Event      string
+	Raw        string
+}
+
+// Server is a local Kraken websocket server for tests, serving the public websocket at PublicURL(),
+// the private websocket at PrivateURL() and the v2 level3 websocket at Level3URL(). It speaks the v1 protocol,
+// v2 requests are only recorded.
+type Server struct {
+	httpServer *httptest.Server
+	upgrader   websocket.Upgrader
+
+	mutex         sync.Mutex
+	token         string
+	conns         map[*serverConn]bool
+	lastChannelID int
+	lastOrderID   int
+	openOrders    map[string]bool
+	requests      []Request
+}
+
+type serverConn struct {
+	name       string
+	ws         *websocket.Conn
+	writeMutex sync.Mutex
+
+	// subscriptions are keyed by channel name and pair, private channels have no pair
+	subscriptions map[subscriptionKey]int
+	sequences     map[string]int
+}
+
+type subscriptionKey struct {
+	channelName string
+	pair        string
+}
+
+func New() *Server {
+	server := &Server{
+		token:      DefaultToken,
+		conns:      make(map[*serverConn]bool),
+		openOrders: make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/public", func(writer http.ResponseWriter, request *http.Request) {
+		server.serve("public", writer, request)
+	})
+	mux.HandleFunc("/private", func(writer http.ResponseWriter, request *http.Request) {
+		server.serve("private", writer, request)
+	})
+	mux.HandleFunc("/level3", func(writer http.ResponseWriter, request *http.Request) {
+		server.serve("level3", writer, request)
+	})
+
+	server.httpServer = httptest.NewServer(mux)
+	return server
+}
+
+func (server *Server) PublicURL() string {
+	return "ws" + strings.TrimPrefix(server.httpServer.URL, "http") + "/public"
+}
+
+func (server *Server) PrivateURL() string {
+	return "ws" + strings.TrimPrefix(server.httpServer.URL, "http") + "/private"
+}
+
+func (server *Server) Level3URL() string {
+	return "ws" + strings.TrimPrefix(server.httpServer.URL, "http") + "/level3"
+}
+
+// Close disconnects all clients and stops the server.
+func (server *Server) Close() {
+	server.Disconnect("public")
+	server.Disconnect("private")
+	server.Disconnect("level3")
+	server.httpServer.Close()
+}
+
+func (server *Server) SetToken(token string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.token = token
+}
+
+// Requests returns all messages received so far, in order.
+func (server *Server) Requests() []Request {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return append([]Request(nil), server.requests...)
+}
+
+// WaitForRequest waits until a message with event was received on connection and returns it.
+func (server *Server) WaitForRequest(connection string, event string, timeout time.Duration) (Request, error) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		for _, request := range server.Requests() {
+			if request.Connection == connection && request.Event == event {
+				return request, nil
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return Request{}, fmt.Errorf("no %s request received on %s connection", event, connection)
+}
+
+// WaitForSubscription waits until a client on connection subscribed to channelName for pair.
+func (server *Server) WaitForSubscription(connection string, channelName string, pair string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if len(server.subscribers(connection, channelName, pair)) != 0 {
+			return nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return fmt.Errorf("no subscription to %s %s on %s connection", channelName, pair, connection)
+}
+
+func (server *Server) serve(name string, writer http.ResponseWriter, request *http.Request) {
+	ws, err := server.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		return
+	}
+
+	conn := &serverConn{
+		name:          name,
+		ws:            ws,
+		subscriptions: make(map[subscriptionKey]int),
+		sequences:     make(map[string]int),
+	}
+
+	server.mutex.Lock()
+	server.conns[conn] = true
+	server.mutex.Unlock()
+
+	defer func() {
+		server.mutex.Lock()
+		delete(server.conns, conn)
+		server.mutex.Unlock()
+		ws.Close()
+	}()
+
+	conn.writeJSON(map[string]interface{}{
+		"connectionID": json.Number("12345678901234567890"),
+		"event":        "systemStatus",
+		"status":       "online",
+		"version":      systemStatusVersion,
+	})
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		server.handle(conn, message)
+	}
+}
+
+func (conn *serverConn) write(frame []byte) error {
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	return conn.ws.WriteMessage(websocket.TextMessage, frame)
+}
+
+func (conn *serverConn) writeJSON(message interface{}) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.write(bytes)
+}
+
+// connections returns the connections of name, "" returns all connections.
+func (server *Server) connections(name string) []*serverConn {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	var conns []*serverConn
+	for conn := range server.conns {
+		if name == "" || conn.name == name {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// Disconnect closes all client connections of name ("public", "private" or "level3") without a close handshake.
+func (server *Server) Disconnect(name string) {
+	for _, conn := range server.connections(name) {
+		conn.ws.Close()
+	}
+}
+
+// SendRaw sends frame as-is to all clients on connection, which is useful to send malformed frames.
+func (server *Server) SendRaw(connection string, frame string) error {
+	for _, conn := range server.connections(connection) {
+		if err := conn.write([]byte(frame)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type subscriber struct {
+	conn      *serverConn
+	channelID int
+	name      string
+}
+
+func (server *Server) subscribers(connection string, channelName string, pair string) []subscriber {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	var subscribers []subscriber
+	for conn := range server.conns {
+		if conn.name != connection {
+			continue
+		}
+
+		for key, channelID := range conn.subscriptions {
+			// "book" matches any depth, "book-25" only that depth
+			name := strings.Split(key.channelName, "-")[0]
+			if (key.channelName == channelName || name == channelName) && key.pair == pair {
+				subscribers = append(subscribers, subscriber{conn: conn, channelID: channelID, name: key.channelName})
+			}
+		}
+	}
+	return subscribers
+}
+
+// Publish sends payload on a public channel to all subscribers of pair as [channelID, payload, channelName, pair].
+// The channelName "book" matches subscriptions of any depth, "book-25" only those of depth 25.
+func (server *Server) Publish(channelName string, pair string, payload interface{}) error {
+	for _, subscriber := range server.subscribers("public", channelName, pair) {
+		frame := []interface{}{subscriber.channelID, payload, subscriber.name, pair}
+		if err := subscriber.conn.writeJSON(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishPrivate sends payload on the ownTrades or openOrders channel to all subscribers,
+// with a sequence number that increases per connection.
+func (server *Server) PublishPrivate(channelName string, payload interface{}) error {
+	for _, subscriber := range server.subscribers("private", channelName, "") {
+		if err := server.sendPrivate(subscriber.conn, channelName, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (server *Server) sendPrivate(conn *serverConn, channelName string, payload interface{}) error {
+	server.mutex.Lock()
+	conn.sequences[channelName]++
+	sequence := conn.sequences[channelName]
+	server.mutex.Unlock()
+
+	frame := []interface{}{payload, channelName, map[string]int{"sequence": sequence}}
+	return conn.writeJSON(frame)
+}
+
+// Play sends recorded frames to the clients on the connection they were recorded from.
+// With speed 1 the original timing is kept, kraken.ReplayMaxSpeed does not wait at all.
+func (server *Server) Play(frames []kraken.RecordedFrame, speed float64) error {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	first := frames[0].ReceivedAt
+
+	for _, frame := range frames {
+		if speed > 0 {
+			offset := time.Duration(float64(frame.ReceivedAt.Sub(first)) / speed)
+			time.Sleep(time.Until(start.Add(offset)))
+		}
+
+		if err := server.SendRaw(frame.Connection, frame.Frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type request struct {
+	Event        string          `json:"event"`
+	ReqID        int             `json:"reqid,omitempty"`
+	Pair         json.RawMessage `json:"pair"`
+	Token        string          `json:"token"`
+	Subscription struct {
+		Name     string `json:"name"`
+		Depth    int    `json:"depth"`
+		Interval int    `json:"interval"`
+		Token    string `json:"token"`
+	} `json:"subscription"`
+	OrderType     string   `json:"ordertype"`
+	Type          string   `json:"type"`
+	Price         string   `json:"price"`
+	Volume        string   `json:"volume"`
+	TransactionID []string `json:"txid"`
+}
+
+// pairs returns the pair list of a subscription, or the single pair of an order.
+func (parsed request) pairs() []string {
+	var pairs []string
+	if err := json.Unmarshal(parsed.Pair, &pairs); err == nil {
+		return pairs
+	}
+
+	var pair string
+	if err := json.Unmarshal(parsed.Pair, &pair); err == nil {
+		return []string{pair}
+	}
+	return nil
+}
+
+func (server *Server) handle(conn *serverConn, message []byte) {
+	var parsed request
+	if err := json.Unmarshal(message, &parsed); err != nil {
+		conn.writeJSON(map[string]interface{}{"event": "error", "status": "error", "errorMessage": "Malformed request"})
+		return
+	}
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, Request{Connection: conn.name, Event: parsed.Event, Raw: string(message)})
+	server.mutex.Unlock()
+
+	switch parsed.Event {
+	case "ping":
+		conn.writeJSON(withReqID(map[string]interface{}{"event": "pong"}, parsed.ReqID))
+	case "subscribe":
+		server.subscribe(conn, parsed)
+	case "unsubscribe":
+		server.unsubscribe(conn, parsed)
+	case "addOrder":
+		server.addOrder(conn, parsed)
+	case "cancelOrder":
+		server.cancelOrder(conn, parsed)
+	case "cancelAll":
+		server.cancelAll(conn, parsed)
+	default:
+		conn.writeJSON(withReqID(map[string]interface{}{
+			"event":        "error",
+			"status":       "error",
+			"errorMessage": "Unsupported event",
+		}, parsed.ReqID))
+	}
+}
+
+func withReqID(message map[string]interface{}, reqID int) map[string]interface{} {
+	if reqID != 0 {
+		message["reqid"] = reqID
+	}
+	return message
+}
+
+func isPrivateChannel(name string) bool {
+	return name == "ownTrades" || name == "openOrders"
+}
+
+func channelName(parsed request) (string, map[string]interface{}) {
+	details := map[string]interface{}{"name": parsed.Subscription.Name}
+
+	switch parsed.Subscription.Name {
+	case "book":
+		depth := parsed.Subscription.Depth
+		if depth == 0 {
+			depth = 10
+		}
+		details["depth"] = depth
+		return fmt.Sprintf("book-%d", depth), details
+	case "ohlc":
+		interval := parsed.Subscription.Interval
+		if interval == 0 {
+			interval = 1
+		}
+		details["interval"] = interval
+		return fmt.Sprintf("ohlc-%d", interval), details
+	default:
+		return parsed.Subscription.Name, details
+	}
+}
+
+var publicChannels = map[string]bool{"book": true, "ohlc": true, "spread": true, "ticker": true, "trade": true}
+
+func (server *Server) checkToken(token string) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return token == server.token
+}
+
+func (server *Server) subscribe(conn *serverConn, parsed request) {
+	name, details := channelName(parsed)
+
+	status := func(pair string) map[string]interface{} {
+		message := withReqID(map[string]interface{}{
+			"event":        "subscriptionStatus",
+			"channelName":  name,
+			"subscription": details,
+		}, parsed.ReqID)
+		if pair != "" {
+			message["pair"] = pair
+		}
+		return message
+	}
+
+	fail := func(pair string, errorMessage string) {
+		message := status(pair)
+		delete(message, "channelName")
+		message["status"] = "error"
+		message["errorMessage"] = errorMessage
+		conn.writeJSON(message)
+	}
+
+	if isPrivateChannel(parsed.Subscription.Name) {
+		switch {
+		case conn.name != "private":
+			fail("", "Private data and trading are unavailable")
+		case !server.checkToken(parsed.Subscription.Token):
+			fail("", "EGeneral:Invalid arguments:token")
+		default:
+			server.mutex.Lock()
+			conn.subscriptions[subscriptionKey{channelName: name}] = 0
+			conn.sequences[name] = 0
+			server.mutex.Unlock()
+
+			message := status("")
+			message["status"] = "subscribed"
+			conn.writeJSON(message)
+
+			// Kraken starts with a snapshot, which is empty for a fresh account
+			server.sendPrivate(conn, name, []interface{}{})
+		}
+		return
+	}
+
+	pairs := parsed.pairs()
+
+	if !publicChannels[parsed.Subscription.Name] {
+		if len(pairs) == 0 {
+			pairs = []string{""}
+		}
+		for _, pair := range pairs {
+			fail(pair, "Subscription name invalid")
+		}
+		return
+	}
+
+	if len(pairs) == 0 {
+		fail("", "Pair field must be an array")
+		return
+	}
+
+	for _, pair := range pairs {
+		key := subscriptionKey{channelName: name, pair: pair}
+
+		server.mutex.Lock()
+		_, exists := conn.subscriptions[key]
+		if !exists {
+			server.lastChannelID++
+			conn.subscriptions[key] = server.lastChannelID
+		}
+		channelID := conn.subscriptions[key]
+		server.mutex.Unlock()
+
+		if exists {
+			fail(pair, "Already subscribed")
+			continue
+		}
+
+		message := status(pair)
+		message["status"] = "subscribed"
+		message["channelID"] = channelID
+		conn.writeJSON(message)
+	}
+}
+
+func (server *Server) unsubscribe(conn *serverConn, parsed request) {
+	name, details := channelName(parsed)
+
+	pairs := parsed.pairs()
+	if len(pairs) == 0 {
+		pairs = []string{""}
+	}
+
+	for _, pair := range pairs {
+		key := subscriptionKey{channelName: name, pair: pair}
+
+		server.mutex.Lock()
+		channelID, exists := conn.subscriptions[key]
+		delete(conn.subscriptions, key)
+		server.mutex.Unlock()
+
+		message := withReqID(map[string]interface{}{
+			"event":        "subscriptionStatus",
+			"subscription": details,
+		}, parsed.ReqID)
+		if pair != "" {
+			message["pair"] = pair
+		}
+
+		if !exists {
+			message["status"] = "error"
+			message["errorMessage"] = "Subscription Not Found"
+		} else {
+			message["status"] = "unsubscribed"
+			message["channelName"] = name
+			if channelID != 0 {
+				message["channelID"] = channelID
+			}
+		}
+		conn.writeJSON(message)
+	}
+}
+
+// checkPrivate replies with an error status for event if the request is not authorized.
+func (server *Server) checkPrivate(conn *serverConn, event string, parsed request) bool {
+	errorMessage := ""
+	switch {
+	case conn.name != "private":
+		errorMessage = "Private data and trading are unavailable"
+	case !server.checkToken(parsed.Token):
+		errorMessage = "EGeneral:Invalid arguments:token"
+	default:
+		return true
+	}
+
+	conn.writeJSON(withReqID(map[string]interface{}{
+		"event":        event,
+		"status":       "error",
+		"errorMessage": errorMessage,
+	}, parsed.ReqID))
+	return false
+}
+
+func (server *Server) addOrder(conn *serverConn, parsed request) {
+	if !server.checkPrivate(conn, "addOrderStatus", parsed) {
+		return
+	}
+
+	server.mutex.Lock()
+	server.lastOrderID++
+	txid := fmt.Sprintf("OMOCK0-%05d-MOCKED", server.lastOrderID)
+	server.openOrders[txid] = true
+	server.mutex.Unlock()
+
+	pair := strings.Join(parsed.pairs(), "")
+	description := fmt.Sprintf("%s %s %s @ %s %s", parsed.Type, parsed.Volume, strings.ReplaceAll(pair, "/", ""),
+		parsed.OrderType, parsed.Price)
+
+	conn.writeJSON(withReqID(map[string]interface{}{
+		"event":  "addOrderStatus",
+		"status": "ok",
+		"txid":   txid,
+		"descr":  description,
+	}, parsed.ReqID))
+}
+
+// OpenOrders returns the txids of orders that were added and not canceled.
+func (server *Server) OpenOrders() []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	var txids []string
+	for txid := range server.openOrders {
+		txids = append(txids, txid)
+	}
+	sort.Strings(txids)
+	return txids
+}
+
+func (server *Server) cancelOrder(conn *serverConn, parsed request) {
+	if !server.checkPrivate(conn, "cancelOrderStatus", parsed) {
+		return
+	}
+
+	server.mutex.Lock()
+	var unknown bool
+	for _, txid := range parsed.TransactionID {
+		if !server.openOrders[txid] {
+			unknown = true
+		}
+	}
+	if !unknown {
+		for _, txid := range parsed.TransactionID {
+			delete(server.openOrders, txid)
+		}
+	}
+	server.mutex.Unlock()
+
+	message := withReqID(map[string]interface{}{"event": "cancelOrderStatus", "status": "ok"}, parsed.ReqID)
+	if unknown {
+		message["status"] = "error"
+		message["errorMessage"] = "EOrder:Unknown order"
+	}
+	conn.writeJSON(message)
+}
+
+func (server *Server) cancelAll(conn *serverConn, parsed request) {
+	if !server.checkPrivate(conn, "cancelAllStatus", parsed) {
+		return
+	}
+
+	server.mutex.Lock()
+	count := len(server.openOrders)
+	server.openOrders = make(map[string]bool)
+	server.mutex.Unlock()
+
+	conn.writeJSON(withReqID(map[string]interface{}{
+		"event":  "cancelAllStatus",
+		"status": "ok",
+		"count":  count,
+	}, parsed.ReqID))
+}
@@ -0,0 +1,172 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	kraken "github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+const timeout = 2 * time.Second
+
+const tickerPayload = `{"a":["0.42700000",16169,"16169.08316400"],"b":["0.42690000",1000,"1000.00000000"],` +
+	`"c":["0.42700000","270.85683600"],"v":["57719824.25617952","60354910.83998816"],"p":["0.40286360","0.40226657"],` +
+	`"t":[22509,23886],"l":["0.36000000","0.36000000"],"h":["0.43605000","0.43605000"],"o":["0.38529000","0.39564000"]}`
+
+func receive(t *testing.T, client *kraken.Client) interface{} {
+	select {
+	case message := <-client.Listen():
+		return message
+	case <-time.After(timeout):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func connect(t *testing.T, server *Server) *kraken.Client {
+	client, err := kraken.NewClient(kraken.WithPublicURL(server.PublicURL()), kraken.WithPrivateURL(server.PrivateURL()))
+	assert.Nil(t, err)
+	assert.IsType(t, kraken.SystemStatus{}, receive(t, client))
+	return client
+}
+
+func TestPublicSubscription(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	client := connect(t, server)
+
+	assert.Nil(t, client.Send(kraken.Subscribe{Pair: []string{"XBT/EUR"}, Subscription: kraken.Subscription{Name: "ticker"}}))
+
+	status := receive(t, client).(kraken.SubscriptionStatus)
+	assert.Equal(t, "subscribed", status.Status)
+	assert.Equal(t, "ticker", status.ChannelName)
+	assert.Len(t, client.ActiveSubscriptions(), 1)
+
+	assert.Nil(t, server.Publish("ticker", "XBT/EUR", json.RawMessage(tickerPayload)))
+
+	ticker := receive(t, client).(kraken.Ticker)
+	assert.Equal(t, "XBT/EUR", ticker.Pair)
+	assert.Equal(t, kraken.Int64String(status.ChannelID), ticker.ChannelID)
+
+	assert.Nil(t, client.Send(kraken.Ping{ReqID: 7}))
+	assert.Equal(t, kraken.Pong{Event: "pong", ReqID: 7}, receive(t, client))
+
+	assert.Nil(t, client.Send(kraken.Unsubscribe{Pair: []string{"XBT/EUR"}, Subscription: kraken.Subscription{Name: "ticker"}}))
+	assert.Equal(t, "unsubscribed", receive(t, client).(kraken.SubscriptionStatus).Status)
+	assert.Len(t, client.ActiveSubscriptions(), 0)
+}
+
+func TestSubscriptionError(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	client := connect(t, server)
+	assert.Nil(t, client.ConnectWs("private"))
+	assert.IsType(t, kraken.SystemStatus{}, receive(t, client))
+
+	subscribe := kraken.Subscribe{Pair: []string{"XBT/EUR"}, Subscription: kraken.Subscription{Name: "tickers"}}
+	assert.Nil(t, client.Send(subscribe))
+
+	failed := receive(t, client).(kraken.SubscriptionFailedError)
+	assert.Equal(t, "error", failed.Status.Status)
+	assert.Equal(t, "XBT/EUR", failed.Status.Pair)
+	assert.Equal(t, "subscribing to tickers XBT/EUR failed: Subscription name invalid", failed.Error())
+
+	subscriptions := client.Subscriptions()
+	assert.Len(t, subscriptions, 1)
+	assert.Equal(t, kraken.SubscriptionError, subscriptions[0].State)
+	assert.Equal(t, "Subscription name invalid", subscriptions[0].ErrorMessage)
+
+	// a failed subscription can be retried
+	assert.Nil(t, client.Send(subscribe))
+	assert.IsType(t, kraken.SubscriptionFailedError{}, receive(t, client))
+
+	// wrong token
+	assert.Nil(t, client.SendPrivate(kraken.Subscribe{Subscription: kraken.Subscription{Name: "ownTrades"}}))
+	assert.Equal(t, "EGeneral:Invalid arguments:token", receive(t, client).(kraken.SubscriptionFailedError).Status.ErrorMessage)
+
+	client.SetWebsocketToken(DefaultToken)
+
+	assert.Nil(t, client.SendPrivate(kraken.Subscribe{Subscription: kraken.Subscription{Name: "ownTrades"}}))
+	assert.Equal(t, "subscribed", receive(t, client).(kraken.SubscriptionStatus).Status)
+	assert.Len(t, client.ActiveSubscriptions(), 1)
+}
+
+func TestPrivateOrders(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	client := connect(t, server)
+	assert.Nil(t, client.ConnectWs("private"))
+	assert.IsType(t, kraken.SystemStatus{}, receive(t, client))
+
+	// wrong token
+	assert.Nil(t, client.SendPrivate(kraken.AddOrder{OrderType: "limit", Type: "buy", Pair: "XBT/EUR", Price: "9000", Volume: "1"}))
+	assert.Equal(t, "EGeneral:Invalid arguments:token", receive(t, client).(kraken.Error).Message)
+
+	client.SetWebsocketToken(DefaultToken)
+
+	assert.Nil(t, client.SendPrivate(kraken.Subscribe{Subscription: kraken.Subscription{Name: "ownTrades"}}))
+	assert.Equal(t, "subscribed", receive(t, client).(kraken.SubscriptionStatus).Status)
+	assert.True(t, receive(t, client).(kraken.OwnTrades).Snapshot)
+
+	assert.Nil(t, client.SendPrivate(kraken.AddOrder{ReqID: 3, OrderType: "limit", Type: "buy", Pair: "XBT/EUR", Price: "9000", Volume: "1"}))
+	status := receive(t, client).(kraken.AddOrderStatus)
+	assert.Equal(t, kraken.AddOrderStatus{
+		Event:         "addOrderStatus",
+		ReqID:         3,
+		Status:        "ok",
+		TransactionID: "OMOCK0-00001-MOCKED",
+		Description:   "buy 1 XBTEUR @ limit 9000",
+	}, status)
+	assert.Equal(t, []string{status.TransactionID}, server.OpenOrders())
+
+	assert.Nil(t, client.SendPrivate(kraken.CancelOrder{TransactionID: []string{status.TransactionID}}))
+	assert.Equal(t, "ok", receive(t, client).(kraken.CancelOrderStatus).Status)
+	assert.Empty(t, server.OpenOrders())
+
+	assert.Nil(t, server.PublishPrivate("ownTrades", json.RawMessage(`[]`)))
+	ownTrades := receive(t, client).(kraken.OwnTrades)
+	assert.False(t, ownTrades.Snapshot)
+	assert.Equal(t, 2, int(ownTrades.Sequence.Sequence))
+}
+
+func TestDisconnectAndMalformedFrames(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	client := connect(t, server)
+
+	assert.Nil(t, client.Send(kraken.Subscribe{Pair: []string{"XBT/EUR"}, Subscription: kraken.Subscription{Name: "spread"}}))
+	assert.IsType(t, kraken.SubscriptionStatus{}, receive(t, client))
+
+	assert.Nil(t, server.SendRaw("public", `[1,{"broken"`))
+	assert.Error(t, receive(t, client).(error))
+
+	server.Disconnect("public")
+	disconnect := receive(t, client)
+	assert.IsType(t, kraken.DisconnectError{}, disconnect)
+	assert.Equal(t, "public", disconnect.(kraken.DisconnectError).PublicPrivate)
+	assert.Len(t, client.ActiveSubscriptions(), 0)
+}
+
+func TestPlay(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	client := connect(t, server)
+
+	start := time.Now()
+	frames := []kraken.RecordedFrame{
+		{ReceivedAt: start, Connection: "public", Frame: `{"event":"heartbeat"}`},
+		{ReceivedAt: start.Add(time.Second), Connection: "public", Frame: `{"event":"pong","reqid":1}`},
+	}
+
+	go server.Play(frames, kraken.ReplayMaxSpeed)
+
+	assert.Equal(t, kraken.HeartBeat{Event: "heartbeat"}, receive(t, client))
+	assert.Equal(t, kraken.Pong{Event: "pong", ReqID: 1}, receive(t, client))
+}
@@ -0,0 +1,305 @@
+package mockserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+)
+
+const (
+	// rate limit of the starter verification tier
+	DefaultRateLimit      = 15
+	DefaultRateLimitDecay = 0.33
+)
+
+// RESTRequest is a request the RESTServer accepted or rejected.
+type RESTRequest struct {
+	Method  string
+	Private bool
+	Data    url.Values
+}
+
+// RESTServer is a local Kraken REST API for tests. Private requests are verified like Kraken does:
+// the API-Key header, the API-Sign signature and an increasing nonce. Results of all methods are canned
+// and can be replaced with SetResult().
+type RESTServer struct {
+	httpServer    *httptest.Server
+	key           string
+	decodedSecret []byte
+	now           func() time.Time
+
+	mutex       sync.Mutex
+	results     map[string]interface{}
+	failures    map[string][][]string
+	lastNonce   int64
+	rateMax     float64
+	rateDecay   float64
+	rateCounter float64
+	rateUpdated time.Time
+	lastOrderID int
+	requests    []RESTRequest
+}
+
+// NewRESTServer starts a server accepting private requests signed with key and the base64 encoded secret.
+func NewRESTServer(key string, secret string) (*RESTServer, error) {
+	decodedSecret, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode secret: %w", err)
+	}
+
+	server := &RESTServer{
+		key:           key,
+		decodedSecret: decodedSecret,
+		now:           time.Now,
+		results:       defaultResults(),
+		failures:      make(map[string][][]string),
+		rateMax:       DefaultRateLimit,
+		rateDecay:     DefaultRateLimitDecay,
+	}
+
+	server.httpServer = httptest.NewServer(http.HandlerFunc(server.serve))
+	return server, nil
+}
+
+func defaultResults() map[string]interface{} {
+	return map[string]interface{}{
+		"SystemStatus": json.RawMessage(`{"status":"online","timestamp":"2021-03-04T12:00:00Z"}`),
+		"Assets": json.RawMessage(`{` +
+			`"XXBT":{"aclass":"currency","altname":"XBT","decimals":10,"display_decimals":5,"collateral_value":1,"status":"enabled"},` +
+			`"XXRP":{"aclass":"currency","altname":"XRP","decimals":8,"display_decimals":5,"status":"enabled"},` +
+			`"ZEUR":{"aclass":"currency","altname":"EUR","decimals":4,"display_decimals":2,"collateral_value":1,"status":"enabled"}}`),
+		"AssetPairs": json.RawMessage(`{` +
+			`"XXBTZEUR":{"altname":"XBTEUR","wsname":"XBT/EUR","aclass_base":"currency","base":"XXBT","aclass_quote":"currency",` +
+			`"quote":"ZEUR","lot":"unit","cost_decimals":5,"pair_decimals":1,"lot_decimals":8,"lot_multiplier":1,` +
+			`"leverage_buy":[2,3,4,5],"leverage_sell":[2,3,4,5],"fees":[[0,0.26],[50000,0.24],[100000,0.22]],` +
+			`"fees_maker":[[0,0.16],[50000,0.14],[100000,0.12]],"fee_volume_currency":"ZUSD","margin_call":80,"margin_stop":40,` +
+			`"ordermin":"0.0001","costmin":"0.5","tick_size":"0.1","status":"online"},` +
+			`"XXRPZEUR":{"altname":"XRPEUR","wsname":"XRP/EUR","aclass_base":"currency","base":"XXRP","aclass_quote":"currency",` +
+			`"quote":"ZEUR","lot":"unit","cost_decimals":6,"pair_decimals":5,"lot_decimals":8,"lot_multiplier":1,` +
+			`"leverage_buy":[2,3],"leverage_sell":[2,3],"fees":[[0,0.26],[50000,0.24],[100000,0.22]],` +
+			`"fees_maker":[[0,0.16],[50000,0.14],[100000,0.12]],"fee_volume_currency":"ZUSD","margin_call":80,"margin_stop":40,` +
+			`"ordermin":"10","costmin":"0.5","tick_size":"0.00001","status":"online"}}`),
+		"Ticker": json.RawMessage(`{"XXBTZEUR":{"a":["30300.10000","1","1.000"],"b":["30300.00000","2","2.000"],` +
+			`"c":["30303.20000","0.00067643"],"v":["4083.67001100","4412.73601799"],"p":["30706.77771","30689.13205"],` +
+			`"t":[34619,38907],"l":["29868.30000","29868.30000"],"h":["31631.00000","31631.00000"],"o":"30502.80000"}}`),
+		"OHLC": json.RawMessage(`{"XXBTZEUR":[` +
+			`[1614859200,"30300.0","30310.0","30290.0","30305.0","30301.2","1.50000000",12],` +
+			`[1614859260,"30305.0","30305.0","30300.0","30300.0","30302.5","0.25000000",3]],"last":1614859260}`),
+		"Trades": json.RawMessage(`{"XXBTZEUR":[` +
+			`["30300.00000","0.10000000",1614859200.1234,"b","m","",1001],` +
+			`["30305.00000","0.20000000",1614859201.5,"s","l","",1002]],"last":"1614859201500000000"}`),
+		"GetWebSocketsToken": json.RawMessage(`{"token":"` + DefaultToken + `","expires":900}`),
+		"Balance":            json.RawMessage(`{"ZEUR":"1000.0000","XXBT":"0.5000000000"}`),
+		"OpenOrders":         json.RawMessage(`{"open":{}}`),
+		"QueryOrders":        json.RawMessage(`{}`),
+		"TradesHistory":      json.RawMessage(`{"trades":{},"count":0}`),
+		"OpenPositions": json.RawMessage(`{"TF5GVO-T7ZZ2-6NBKBI":{"ordertxid":"OLWNFG-LLH4R-D6SFFP","posstatus":"open",` +
+			`"pair":"XXBTZEUR","time":1614859200.5,"type":"buy","ordertype":"limit","cost":"15000.00000","fee":"24.00000",` +
+			`"vol":"0.50000000","vol_closed":"0.10000000","margin":"5000.00000","value":"12400.0","net":"+400.00000",` +
+			`"terms":"0.0100% per 4 hours","rollovertm":"1614873600","misc":"","oflags":""}}`),
+		"TradeBalance": json.RawMessage(`{"eb":"20000.0000","tb":"10000.0000","m":"5000.0000","n":"400.0000",` +
+			`"c":"12000.0000","v":"12400.0000","e":"10400.0000","mf":"5400.0000","ml":"208.00","uv":"0.0000"}`),
+		"TradeVolume": json.RawMessage(`{"currency":"ZUSD","volume":"45000.0000",` +
+			`"fees":{"XXBTZEUR":{"fee":"0.2600","minfee":"0.1000","maxfee":"0.2600","nextfee":"0.2400","nextvolume":"50000.0000","tiervolume":"0.0000"}},` +
+			`"fees_maker":{"XXBTZEUR":{"fee":"0.1600","minfee":"0.0000","maxfee":"0.1600","nextfee":"0.1400","nextvolume":"50000.0000","tiervolume":"0.0000"}}}`),
+	}
+}
+
+func (server *RESTServer) URL() string {
+	return server.httpServer.URL
+}
+
+func (server *RESTServer) Close() {
+	server.httpServer.Close()
+}
+
+// SetResult sets the result returned by method, for example "Ticker" or "OpenOrders".
+// The result is marshalled to JSON, use json.RawMessage for literal JSON.
+func (server *RESTServer) SetResult(method string, result interface{}) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.results[method] = result
+}
+
+// FailNext makes the next call to method return errors, such as "EOrder:Insufficient funds".
+// Calling it multiple times fails multiple calls.
+func (server *RESTServer) FailNext(method string, errors ...string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.failures[method] = append(server.failures[method], errors)
+}
+
+// SetRateLimit sets the maximum of the private API call counter and how much it decreases per second.
+// A max of 0 disables rate limiting.
+func (server *RESTServer) SetRateLimit(max float64, decayPerSecond float64) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.rateMax = max
+	server.rateDecay = decayPerSecond
+}
+
+// Requests returns all received requests, in order.
+func (server *RESTServer) Requests() []RESTRequest {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return append([]RESTRequest(nil), server.requests...)
+}
+
+func (server *RESTServer) serve(writer http.ResponseWriter, request *http.Request) {
+	split := strings.Split(strings.TrimPrefix(request.URL.Path, "/"), "/")
+	if len(split) != 3 || split[0] != rest.APIVersion || (split[1] != "public" && split[1] != "private") {
+		http.NotFound(writer, request)
+		return
+	}
+
+	// the signature covers the body as it was sent, which ParseForm consumes
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := request.ParseForm(); err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	method := split[2]
+	private := split[1] == "private"
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, RESTRequest{Method: method, Private: private, Data: request.PostForm})
+	server.mutex.Unlock()
+
+	var result interface{}
+	var errors []string
+
+	if private {
+		errors = server.verify(request, string(body))
+	}
+
+	if len(errors) == 0 {
+		result, errors = server.result(method, request.PostForm)
+	}
+
+	if errors == nil {
+		errors = []string{}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(map[string]interface{}{"error": errors, "result": result})
+}
+
+// verify checks the key, signature, nonce and rate limit of a private request.
+func (server *RESTServer) verify(request *http.Request, body string) []string {
+	if request.Header.Get("API-Key") != server.key {
+		return []string{"EAPI:Invalid key"}
+	}
+
+	nonce, err := strconv.ParseInt(request.PostForm.Get("nonce"), 10, 64)
+	if err != nil {
+		return []string{"EAPI:Invalid nonce"}
+	}
+
+	if request.Header.Get("API-Sign") != rest.Sign(server.decodedSecret, request.URL.Path, request.PostForm.Get("nonce"), body) {
+		return []string{"EAPI:Invalid signature"}
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if nonce <= server.lastNonce {
+		return []string{"EAPI:Invalid nonce"}
+	}
+	server.lastNonce = nonce
+
+	if !server.countCall(methodCost(strings.TrimPrefix(request.URL.Path, "/"+rest.APIVersion+"/private/"))) {
+		return []string{"EAPI:Rate limit exceeded"}
+	}
+	return nil
+}
+
+// methodCost returns how much a private call increases the rate limit counter.
+func methodCost(method string) float64 {
+	switch method {
+	case "AddOrder", "CancelOrder":
+		// orders are limited by the matching engine instead
+		return 0
+	case "Ledgers", "QueryLedgers", "TradesHistory", "QueryTrades":
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (server *RESTServer) countCall(cost float64) bool {
+	now := server.now()
+
+	if !server.rateUpdated.IsZero() {
+		server.rateCounter -= now.Sub(server.rateUpdated).Seconds() * server.rateDecay
+		if server.rateCounter < 0 {
+			server.rateCounter = 0
+		}
+	}
+	server.rateUpdated = now
+
+	if server.rateMax > 0 && server.rateCounter+cost > server.rateMax {
+		return false
+	}
+
+	server.rateCounter += cost
+	return true
+}
+
+func (server *RESTServer) result(method string, data url.Values) (interface{}, []string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if failures := server.failures[method]; len(failures) != 0 {
+		server.failures[method] = failures[1:]
+		return nil, failures[0]
+	}
+
+	switch method {
+	case "Time":
+		now := server.now()
+		return map[string]interface{}{"unixtime": now.Unix(), "rfc1123": now.UTC().Format(time.RFC1123)}, nil
+	case "AddOrder":
+		if _, ok := server.results[method]; !ok {
+			server.lastOrderID++
+			description := fmt.Sprintf("%s %s %s @ %s %s", data.Get("type"), data.Get("volume"), data.Get("pair"),
+				data.Get("ordertype"), data.Get("price"))
+
+			return map[string]interface{}{
+				"descr": map[string]string{"order": description},
+				"txid":  []string{fmt.Sprintf("OMOCK0-%05d-MOCKED", server.lastOrderID)},
+			}, nil
+		}
+	case "CancelOrder":
+		if _, ok := server.results[method]; !ok {
+			return map[string]int{"count": 1}, nil
+		}
+	}
+
+	result, ok := server.results[method]
+	if !ok {
+		return nil, []string{"EGeneral:Unknown method"}
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testKey    = "key"
+	testSecret = "c2VjcmV0"
+)
+
+func newRESTClient(t *testing.T, server *RESTServer, secret string) *rest.Client {
+	client := rest.NewClient(rest.WithURL(server.URL()))
+	assert.Nil(t, client.SetAuth(testKey, secret))
+	return client
+}
+
+func TestRESTServerVerifiesSignature(t *testing.T) {
+	server, err := NewRESTServer(testKey, testSecret)
+	assert.Nil(t, err)
+	defer server.Close()
+
+	token, err := newRESTClient(t, server, testSecret).GetWebSocketsToken()
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultToken, token.Token)
+
+	_, err = newRESTClient(t, server, "b3RoZXI=").GetWebSocketsToken()
+	assert.Equal(t, rest.APIError{Errors: []string{"EAPI:Invalid signature"}}, err)
+
+	assert.Len(t, server.Requests(), 2)
+	assert.Equal(t, "GetWebSocketsToken", server.Requests()[0].Method)
+}
+
+func TestRESTServerRejectsReusedNonce(t *testing.T) {
+	server, err := NewRESTServer(testKey, testSecret)
+	assert.Nil(t, err)
+	defer server.Close()
+
+	post := func(nonce string) []string {
+		// the body is signed as sent, which need not be sorted like url.Values.Encode()
+		body := "nonce=" + nonce + "&asset=XBT"
+
+		path := "/0/private/Balance"
+		request, err := http.NewRequest("POST", server.URL()+path, strings.NewReader(body))
+		assert.Nil(t, err)
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Add("API-Key", testKey)
+		request.Header.Add("API-Sign", rest.Sign([]byte("secret"), path, nonce, body))
+
+		response, err := http.DefaultClient.Do(request)
+		assert.Nil(t, err)
+		defer response.Body.Close()
+
+		var decoded rest.Response
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&decoded))
+		return decoded.Error
+	}
+
+	assert.Empty(t, post("1000"))
+	assert.Equal(t, []string{"EAPI:Invalid nonce"}, post("1000"))
+	assert.Equal(t, []string{"EAPI:Invalid nonce"}, post("999"))
+	assert.Empty(t, post("1001"))
+}
+
+func TestRESTServerErrorsAndRateLimit(t *testing.T) {
+	server, err := NewRESTServer(testKey, testSecret)
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client := newRESTClient(t, server, testSecret)
+
+	server.FailNext("OpenOrders", "EService:Unavailable")
+	_, err = client.OpenOrders()
+	assert.Equal(t, rest.APIError{Errors: []string{"EService:Unavailable"}}, err)
+
+	server.SetResult("OpenOrders", json.RawMessage(`{"open":{"OQCLML-BW3P3-BUCMWZ":{"status":"open","vol":"1.5"}}}`))
+	openOrders, err := client.OpenOrders()
+	assert.Nil(t, err)
+	assert.Equal(t, rest.Float64String(1.5), openOrders.Open["OQCLML-BW3P3-BUCMWZ"].Volume)
+
+	// both calls above counted, TradesHistory costs 2
+	server.SetRateLimit(4, 0)
+	_, err = client.TradesHistory(time.Time{}, 0)
+	assert.Nil(t, err)
+
+	_, err = client.OpenOrders()
+	assert.Equal(t, rest.APIError{Errors: []string{"EAPI:Rate limit exceeded"}}, err)
+
+	server.SetRateLimit(0, 0)
+	_, err = client.OpenOrders()
+	assert.Nil(t, err)
+}
+
+func TestRESTServerMarketData(t *testing.T) {
+	server, err := NewRESTServer(testKey, testSecret)
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client := rest.NewClient(rest.WithURL(server.URL()))
+
+	ohlc, err := client.OHLC("XBTEUR", 1, time.Unix(1614859200, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "XXBTZEUR", ohlc.Pair)
+	assert.Equal(t, time.Unix(1614859260, 0), ohlc.Last)
+	assert.Len(t, ohlc.Candles, 2)
+	assert.Equal(t, rest.OHLC{
+		Time:   rest.UnixTime(time.Unix(1614859200, 0)),
+		Open:   30300,
+		High:   30310,
+		Low:    30290,
+		Close:  30305,
+		VWAP:   30301.2,
+		Volume: 1.5,
+		Count:  12,
+	}, ohlc.Candles[0])
+
+	trades, err := client.Trades("XBTEUR", time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, "XXBTZEUR", trades.Pair)
+	assert.Equal(t, time.Unix(0, 1614859201500000000), trades.Last)
+	assert.Len(t, trades.Trades, 2)
+	assert.Equal(t, int64(1002), trades.Trades[1].TradeID)
+	assert.Equal(t, "s", trades.Trades[1].Side)
+
+	requests := server.Requests()
+	assert.False(t, requests[0].Private)
+	assert.Equal(t, "1614859200", requests[0].Data.Get("since"))
+	assert.Equal(t, "", requests[1].Data.Get("since"))
+}
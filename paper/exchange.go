@@ -0,0 +1,468 @@
+package paper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/fees"
+	"github.com/lk16/kraken/internal/matching"
+	"github.com/lk16/kraken/websocket"
+)
+
+// Trader is the order entry interface shared by websocket.Client and Exchange,
+// strategies depending on it run unchanged in paper mode.
+type Trader interface {
+	Send(rawMessage interface{}) error
+	SendPrivate(rawMessage interface{}) error
+	Listen() <-chan interface{}
+}
+
+var _ Trader = &websocket.Client{}
+var _ Trader = &Exchange{}
+
+type Option func(exchange *Exchange)
+
+// WithFeeTiers replaces fees.DefaultTiers, tiers must be sorted by volume.
+func WithFeeTiers(tiers []fees.Tier) Option {
+	return func(exchange *Exchange) {
+		exchange.fees.Tiers = tiers
+	}
+}
+
+// WithTradedVolume sets the volume in the quote currency that was traded before, which selects the fee tier.
+func WithTradedVolume(volume float64) Option {
+	return func(exchange *Exchange) {
+		exchange.fees.Volume = volume
+	}
+}
+
+// Exchange simulates the private websocket. Orders sent with SendPrivate() are matched against market data
+// and reported with the same AddOrderStatus, OpenOrders and OwnTrades messages as Kraken sends.
+//
+// Market and marketable limit orders take liquidity from the book. Resting limit orders are filled as maker
+// by trades at or through their price. Balances are not checked.
+type Exchange struct {
+	client *websocket.Client
+	outbox *outbox
+
+	mutex       sync.Mutex
+	books       *websocket.BookManager
+	liquidity   *matching.Liquidity
+	orders      map[string]*order
+	orderIDs    []string
+	trades      *websocket.OwnTradeStore
+	lastOrderID int
+	lastTradeID int
+	fees        matching.Fees
+	subscribed  map[string]bool
+	sequences   map[string]int64
+	marketTime  time.Time
+}
+
+type order struct {
+	transactionID string
+	request       websocket.AddOrder
+	buy           bool
+	market        bool
+	price         float64
+	volume        float64
+	executed      float64
+	cost          float64
+	fee           float64
+	status        string
+	openTime      time.Time
+}
+
+// NewExchange matches orders against the market data received by client, which is also delivered through Listen().
+// Client may be nil, market data is then passed to Handle().
+func NewExchange(client *websocket.Client, options ...Option) *Exchange {
+	exchange := &Exchange{
+		client:     client,
+		outbox:     newOutbox(),
+		books:      websocket.NewBookManager(nil, 0),
+		liquidity:  matching.NewLiquidity(),
+		orders:     make(map[string]*order),
+		trades:     websocket.NewOwnTradeStore(),
+		fees:       matching.Fees{Tiers: fees.DefaultTiers},
+		subscribed: make(map[string]bool),
+		sequences:  make(map[string]int64),
+	}
+
+	for _, option := range options {
+		option(exchange)
+	}
+
+	if client != nil {
+		go exchange.forward()
+	}
+
+	return exchange
+}
+
+func (exchange *Exchange) forward() {
+	for message := range exchange.client.Listen() {
+		exchange.outbox.push(message)
+		exchange.Handle(message)
+	}
+}
+
+// Listen returns the channel through which market data from the client and simulated private messages are delivered.
+func (exchange *Exchange) Listen() <-chan interface{} {
+	return exchange.outbox.out
+}
+
+// Send passes public messages such as subscriptions to the client.
+func (exchange *Exchange) Send(rawMessage interface{}) error {
+	if exchange.client == nil {
+		return errors.New("paper exchange has no client")
+	}
+	return exchange.client.Send(rawMessage)
+}
+
+// SendPrivate handles AddOrder, CancelOrder, CancelAll and subscriptions to ownTrades and openOrders.
+func (exchange *Exchange) SendPrivate(rawMessage interface{}) error {
+	exchange.mutex.Lock()
+	defer exchange.mutex.Unlock()
+
+	var messages []interface{}
+
+	switch message := rawMessage.(type) {
+	case websocket.AddOrder:
+		messages = exchange.addOrder(message)
+	case websocket.CancelOrder:
+		messages = exchange.cancelOrder(message)
+	case websocket.CancelAll:
+		messages = exchange.cancelAll()
+	case websocket.Subscribe:
+		messages = exchange.subscribe(message)
+	case websocket.Unsubscribe:
+		messages = exchange.unsubscribe(message)
+	case websocket.Ping:
+		messages = []interface{}{websocket.Pong{Event: "pong", ReqID: message.ReqID}}
+	default:
+		return fmt.Errorf("unsupported message type %T", message)
+	}
+
+	exchange.outbox.push(messages...)
+	return nil
+}
+
+// Handle matches open orders against Book, BookUpdate and Trade messages, other messages are ignored.
+func (exchange *Exchange) Handle(rawMessage interface{}) {
+	exchange.mutex.Lock()
+	defer exchange.mutex.Unlock()
+
+	var messages []interface{}
+
+	switch message := rawMessage.(type) {
+	case websocket.Book:
+		exchange.updateMarketTime(message.LastUpdate())
+		exchange.books.Handle(message)
+		messages = exchange.bookChanged(message.Pair)
+	case websocket.BookUpdate:
+		exchange.updateMarketTime(message.LastUpdate())
+		exchange.books.Handle(message)
+		messages = exchange.bookChanged(message.Pair)
+	case websocket.Trade:
+		for _, trade := range message.Data {
+			exchange.updateMarketTime(time.Time(trade.Time))
+			messages = append(messages, exchange.matchTrade(message.Pair, trade)...)
+		}
+	}
+
+	exchange.outbox.push(messages...)
+}
+
+func (exchange *Exchange) updateMarketTime(timestamp time.Time) {
+	if timestamp.After(exchange.marketTime) {
+		exchange.marketTime = timestamp
+	}
+}
+
+// now returns the time of the latest market data, so replayed sessions get reproducible timestamps.
+func (exchange *Exchange) now() time.Time {
+	if exchange.marketTime.IsZero() {
+		return time.Now()
+	}
+	return exchange.marketTime
+}
+
+// Trades returns all simulated trades.
+func (exchange *Exchange) Trades() websocket.OwnTradeList {
+	exchange.mutex.Lock()
+	defer exchange.mutex.Unlock()
+
+	return exchange.trades.Trades()
+}
+
+// OpenOrders returns the orders that are not closed or canceled.
+func (exchange *Exchange) OpenOrders() map[string]websocket.OpenOrder {
+	exchange.mutex.Lock()
+	defer exchange.mutex.Unlock()
+
+	orders := make(map[string]websocket.OpenOrder)
+	for _, orderID := range exchange.orderIDs {
+		orders[orderID] = exchange.orders[orderID].openOrder()
+	}
+	return orders
+}
+
+func errorStatus(event string, message string) websocket.Error {
+	return websocket.Error{Event: event, Status: "error", Message: message}
+}
+
+func subscriptionError(subscription websocket.Subscription, message string) websocket.SubscriptionFailedError {
+	return websocket.SubscriptionFailedError{Status: websocket.SubscriptionStatus{
+		Event:        "subscriptionStatus",
+		Status:       "error",
+		Subscription: websocket.SubscriptionDetails{Name: subscription.Name},
+		ErrorMessage: message,
+	}}
+}
+
+func parseAmount(value string, name string) (float64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("EGeneral:Invalid arguments:%s", name)
+	}
+	return amount, nil
+}
+
+func (exchange *Exchange) addOrder(request websocket.AddOrder) []interface{} {
+	fail := func(err error) []interface{} {
+		return []interface{}{errorStatus("addOrderStatus", err.Error())}
+	}
+
+	if request.Type != "buy" && request.Type != "sell" {
+		return fail(errors.New("EGeneral:Invalid arguments:type"))
+	}
+
+	if request.OrderType != "market" && request.OrderType != "limit" {
+		return fail(errors.New("EGeneral:Invalid arguments:ordertype"))
+	}
+
+	volume, err := parseAmount(request.Volume, "volume")
+	if err != nil || volume == 0 {
+		return fail(errors.New("EGeneral:Invalid arguments:volume"))
+	}
+
+	newOrder := &order{
+		request:  request,
+		buy:      request.Type == "buy",
+		market:   request.OrderType == "market",
+		volume:   volume,
+		status:   "pending",
+		openTime: exchange.now(),
+	}
+
+	if !newOrder.market {
+		if newOrder.price, err = parseAmount(request.Price, "price"); err != nil || newOrder.price == 0 {
+			return fail(errors.New("EGeneral:Invalid arguments:price"))
+		}
+	}
+
+	status := websocket.AddOrderStatus{
+		Event:       "addOrderStatus",
+		ReqID:       int(request.ReqID),
+		Status:      "ok",
+		Description: newOrder.description(),
+	}
+
+	if request.Validate == "true" {
+		return []interface{}{status}
+	}
+
+	exchange.lastOrderID++
+	newOrder.transactionID = fmt.Sprintf("OPAPER-%05d-ORDERS", exchange.lastOrderID)
+	status.TransactionID = newOrder.transactionID
+
+	exchange.orders[newOrder.transactionID] = newOrder
+	exchange.orderIDs = append(exchange.orderIDs, newOrder.transactionID)
+
+	messages := []interface{}{status}
+	messages = append(messages, exchange.openOrdersMessage(map[string]websocket.OpenOrder{
+		newOrder.transactionID: newOrder.openOrder(),
+	})...)
+
+	if strings.Contains(request.OFlags, "post") && exchange.crosses(newOrder) {
+		return append(messages, exchange.close(newOrder, "canceled", "Post only order")...)
+	}
+
+	newOrder.status = "open"
+	messages = append(messages, exchange.openOrdersMessage(map[string]websocket.OpenOrder{
+		newOrder.transactionID: {Status: "open", UserReference: newOrder.userReference()},
+	})...)
+
+	return append(messages, exchange.matchTaker(newOrder)...)
+}
+
+func (exchange *Exchange) cancelOrder(request websocket.CancelOrder) []interface{} {
+	for _, transactionID := range request.TransactionID {
+		if _, ok := exchange.orders[transactionID]; !ok {
+			return []interface{}{errorStatus("cancelOrderStatus", "EOrder:Unknown order")}
+		}
+	}
+
+	var messages []interface{}
+	for _, transactionID := range request.TransactionID {
+		messages = append(messages, exchange.close(exchange.orders[transactionID], "canceled", "User requested")...)
+	}
+
+	status := websocket.CancelOrderStatus{Event: "cancelOrderStatus", ReqID: request.ReqID, Status: "ok"}
+	return append([]interface{}{status}, messages...)
+}
+
+func (exchange *Exchange) cancelAll() []interface{} {
+	var messages []interface{}
+
+	orderIDs := append([]string(nil), exchange.orderIDs...)
+	for _, orderID := range orderIDs {
+		messages = append(messages, exchange.close(exchange.orders[orderID], "canceled", "User requested")...)
+	}
+
+	status := websocket.CancelAllStatus{Event: "cancelAllStatus", Status: "ok", Count: len(orderIDs)}
+	return append([]interface{}{status}, messages...)
+}
+
+func (exchange *Exchange) subscribe(request websocket.Subscribe) []interface{} {
+	name := request.Subscription.Name
+	if name != "ownTrades" && name != "openOrders" {
+		return []interface{}{subscriptionError(request.Subscription, "Subscription name invalid")}
+	}
+
+	exchange.subscribed[name] = true
+	exchange.sequences[name] = 0
+
+	messages := []interface{}{websocket.SubscriptionStatus{
+		Event:        "subscriptionStatus",
+		ChannelName:  name,
+		ReqID:        request.ReqID,
+		Status:       "subscribed",
+		Subscription: websocket.SubscriptionDetails{Name: name},
+	}}
+
+	if name == "openOrders" {
+		orders := make(map[string]websocket.OpenOrder)
+		for _, orderID := range exchange.orderIDs {
+			orders[orderID] = exchange.orders[orderID].openOrder()
+		}
+		return append(messages, exchange.openOrdersMessage(orders)...)
+	}
+
+	snapshot := websocket.OwnTrades{ChannelName: "ownTrades", Trades: exchange.trades.Trades(), Snapshot: true}
+	exchange.sequences[name]++
+	snapshot.Sequence.Sequence = exchange.sequences[name]
+
+	return append(messages, snapshot)
+}
+
+func (exchange *Exchange) unsubscribe(request websocket.Unsubscribe) []interface{} {
+	name := request.Subscription.Name
+	if !exchange.subscribed[name] {
+		return []interface{}{subscriptionError(request.Subscription, "Subscription Not Found")}
+	}
+
+	delete(exchange.subscribed, name)
+	return []interface{}{websocket.SubscriptionStatus{
+		Event:        "subscriptionStatus",
+		ChannelName:  name,
+		ReqID:        request.ReqID,
+		Status:       "unsubscribed",
+		Subscription: websocket.SubscriptionDetails{Name: name},
+	}}
+}
+
+// openOrdersMessage returns the message for subscribers of openOrders, if any.
+func (exchange *Exchange) openOrdersMessage(orders map[string]websocket.OpenOrder) []interface{} {
+	if !exchange.subscribed["openOrders"] {
+		return nil
+	}
+
+	exchange.sequences["openOrders"]++
+	return []interface{}{websocket.OpenOrders{
+		Orders:      orders,
+		ChannelName: "openOrders",
+		Sequence:    websocket.Sequence{Sequence: exchange.sequences["openOrders"]},
+	}}
+}
+
+func (exchange *Exchange) ownTradesMessage(tradeID string, trade websocket.OwnTrade) []interface{} {
+	if !exchange.subscribed["ownTrades"] {
+		return nil
+	}
+
+	exchange.sequences["ownTrades"]++
+	return []interface{}{websocket.OwnTrades{
+		Trades:      websocket.OwnTradeList{{TradeID: tradeID, Trade: trade}},
+		ChannelName: "ownTrades",
+		Sequence:    websocket.Sequence{Sequence: exchange.sequences["ownTrades"]},
+	}}
+}
+
+// close marks order closed or canceled and removes it from the open orders.
+func (exchange *Exchange) close(closed *order, status string, reason string) []interface{} {
+	closed.status = status
+
+	delete(exchange.orders, closed.transactionID)
+	for index, orderID := range exchange.orderIDs {
+		if orderID == closed.transactionID {
+			exchange.orderIDs = append(exchange.orderIDs[:index:index], exchange.orderIDs[index+1:]...)
+			break
+		}
+	}
+
+	update := websocket.OpenOrder{Status: status, UserReference: closed.userReference()}
+	if status == "canceled" {
+		update.CancelReason = reason
+	}
+
+	return exchange.openOrdersMessage(map[string]websocket.OpenOrder{closed.transactionID: update})
+}
+
+func (paperOrder *order) userReference() int64 {
+	userReference, _ := strconv.ParseInt(paperOrder.request.UserReference, 10, 64)
+	return userReference
+}
+
+func (paperOrder *order) description() string {
+	pair := strings.ReplaceAll(paperOrder.request.Pair, "/", "")
+	if paperOrder.market {
+		return fmt.Sprintf("%s %.8f %s @ market", paperOrder.request.Type, paperOrder.volume, pair)
+	}
+	return fmt.Sprintf("%s %.8f %s @ limit %v", paperOrder.request.Type, paperOrder.volume, pair, paperOrder.price)
+}
+
+func (paperOrder *order) averagePrice() float64 {
+	if paperOrder.executed == 0 {
+		return 0
+	}
+	return paperOrder.cost / paperOrder.executed
+}
+
+// openOrder returns the full order, as sent for new orders and in the openOrders snapshot.
+func (paperOrder *order) openOrder() websocket.OpenOrder {
+	return websocket.OpenOrder{
+		Cost: websocket.Float64String(paperOrder.cost),
+		Description: websocket.OpenOrderDescription{
+			Leverage:  "none",
+			Order:     paperOrder.description(),
+			OrderType: paperOrder.request.OrderType,
+			Pair:      paperOrder.request.Pair,
+			Price:     websocket.Float64String(paperOrder.price),
+			Type:      paperOrder.request.Type,
+		},
+		Fee:            websocket.Float64String(paperOrder.fee),
+		LimitPrice:     websocket.Float64String(paperOrder.price),
+		OFlags:         paperOrder.request.OFlags,
+		OpenTime:       websocket.UnixTime(paperOrder.openTime),
+		Status:         paperOrder.status,
+		UserReference:  paperOrder.userReference(),
+		Volume:         websocket.Float64String(paperOrder.volume),
+		VolumeExecuted: websocket.Float64String(paperOrder.executed),
+		AveragePrice:   websocket.Float64String(paperOrder.averagePrice()),
+	}
+}
@@ -0,0 +1,178 @@
+package paper
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/mockserver"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// drain returns the messages delivered until none arrive for a while.
+func drain(trader Trader) []interface{} {
+	var messages []interface{}
+	for {
+		select {
+		case message := <-trader.Listen():
+			messages = append(messages, message)
+		case <-time.After(50 * time.Millisecond):
+			return messages
+		}
+	}
+}
+
+func track(tracker *websocket.OrderTracker, messages []interface{}) {
+	for _, message := range messages {
+		tracker.Handle(message)
+	}
+}
+
+func level(price, volume float64) websocket.PriceLevel {
+	return websocket.PriceLevel{Price: websocket.Float64String(price), Volume: websocket.Float64String(volume)}
+}
+
+func newSubscribedExchange(t *testing.T) *Exchange {
+	exchange := NewExchange(nil)
+
+	assert.Nil(t, exchange.SendPrivate(websocket.Subscribe{Subscription: websocket.Subscription{Name: "openOrders"}}))
+	assert.Nil(t, exchange.SendPrivate(websocket.Subscribe{Subscription: websocket.Subscription{Name: "ownTrades"}}))
+
+	messages := drain(exchange)
+	assert.Len(t, messages, 4)
+	assert.True(t, messages[3].(websocket.OwnTrades).Snapshot)
+
+	exchange.Handle(websocket.Book{
+		ChannelName: "book-10",
+		Pair:        "XBT/EUR",
+		Data: websocket.BookData{
+			Asks: []websocket.PriceLevel{level(100, 1), level(101, 2)},
+			Bids: []websocket.PriceLevel{level(99, 1), level(98, 3)},
+		},
+	})
+	return exchange
+}
+
+func TestExchangeMarketOrder(t *testing.T) {
+	exchange := newSubscribedExchange(t)
+	tracker := websocket.NewOrderTracker()
+
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{ReqID: 5, OrderType: "market", Type: "buy", Pair: "XBT/EUR", Volume: "2"}))
+
+	messages := drain(exchange)
+	assert.Equal(t, websocket.AddOrderStatus{
+		Event:         "addOrderStatus",
+		ReqID:         5,
+		Status:        "ok",
+		TransactionID: "OPAPER-00001-ORDERS",
+		Description:   "buy 2.00000000 XBTEUR @ market",
+	}, messages[0])
+
+	track(tracker, messages)
+
+	order, ok := tracker.Order("OPAPER-00001-ORDERS")
+	assert.True(t, ok)
+	assert.Equal(t, websocket.OrderClosed, order.State)
+	assert.InDelta(t, 2, order.FilledVolume(), 1e-9)
+	assert.InDelta(t, 100.5, order.AveragePrice(), 1e-9)
+	assert.InDelta(t, 201*0.0026, order.Fees(), 1e-9)
+
+	// the taken liquidity is gone until the book changes
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{OrderType: "market", Type: "buy", Pair: "XBT/EUR", Volume: "2"}))
+	track(tracker, drain(exchange))
+
+	order, _ = tracker.Order("OPAPER-00002-ORDERS")
+	assert.InDelta(t, 1, order.FilledVolume(), 1e-9)
+	assert.Equal(t, websocket.OrderPartiallyFilled, order.State)
+
+	exchange.Handle(websocket.BookUpdate{Pair: "XBT/EUR", ChannelName: "book-10", Data: websocket.BookUpdateData{
+		Asks: []websocket.PriceLevel{level(102, 5)},
+	}})
+	track(tracker, drain(exchange))
+
+	order, _ = tracker.Order("OPAPER-00002-ORDERS")
+	assert.Equal(t, websocket.OrderClosed, order.State)
+	assert.Len(t, exchange.Trades(), 4)
+}
+
+func TestExchangeLimitOrder(t *testing.T) {
+	exchange := newSubscribedExchange(t)
+	tracker := websocket.NewOrderTracker()
+
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{OrderType: "limit", Type: "buy", Pair: "XBT/EUR", Price: "98.5", Volume: "1", UserReference: "7"}))
+	track(tracker, drain(exchange))
+
+	order, _ := tracker.Order("OPAPER-00001-ORDERS")
+	assert.Equal(t, websocket.OrderOpen, order.State)
+	assert.Equal(t, int64(7), order.Order.UserReference)
+
+	// a buy trade does not fill a resting buy, a sell trade at a higher price neither
+	exchange.Handle(websocket.Trade{Pair: "XBT/EUR", Data: []websocket.TradeData{
+		{Price: 98.4, Volume: 3, Side: "b"},
+		{Price: 98.6, Volume: 3, Side: "s"},
+		{Price: 98.5, Volume: 0.25, Side: "s", Time: websocket.UnixTime(time.Unix(1612269825, 0))},
+	}})
+	track(tracker, drain(exchange))
+
+	order, _ = tracker.Order("OPAPER-00001-ORDERS")
+	assert.Equal(t, websocket.OrderPartiallyFilled, order.State)
+	assert.InDelta(t, 0.25, order.FilledVolume(), 1e-9)
+	assert.InDelta(t, 98.5*0.25*0.0016, order.Fees(), 1e-9)
+	assert.Equal(t, time.Unix(1612269825, 0), time.Time(order.Fills[0].Trade.Time))
+
+	assert.Nil(t, exchange.SendPrivate(websocket.CancelOrder{TransactionID: []string{"OPAPER-00001-ORDERS"}}))
+	messages := drain(exchange)
+	assert.Equal(t, websocket.CancelOrderStatus{Event: "cancelOrderStatus", Status: "ok"}, messages[0])
+	track(tracker, messages)
+
+	order, _ = tracker.Order("OPAPER-00001-ORDERS")
+	assert.Equal(t, websocket.OrderCanceled, order.State)
+	assert.Empty(t, exchange.OpenOrders())
+}
+
+func TestExchangePostOnlyAndErrors(t *testing.T) {
+	exchange := newSubscribedExchange(t)
+	tracker := websocket.NewOrderTracker()
+
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{OrderType: "limit", Type: "sell", Pair: "XBT/EUR", Price: "98", Volume: "1", OFlags: "post"}))
+	messages := drain(exchange)
+	track(tracker, messages)
+
+	order, _ := tracker.Order("OPAPER-00001-ORDERS")
+	assert.Equal(t, websocket.OrderCanceled, order.State)
+	assert.Equal(t, "Post only order", order.Order.CancelReason)
+	assert.Empty(t, exchange.Trades())
+
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{OrderType: "limit", Type: "sell", Pair: "XBT/EUR", Price: "98", Volume: "x"}))
+	assert.Equal(t, []interface{}{websocket.Error{Event: "addOrderStatus", Status: "error", Message: "EGeneral:Invalid arguments:volume"}}, drain(exchange))
+
+	assert.Nil(t, exchange.SendPrivate(websocket.CancelOrder{TransactionID: []string{"OPAPER-00001-ORDERS"}}))
+	assert.Equal(t, []interface{}{websocket.Error{Event: "cancelOrderStatus", Status: "error", Message: "EOrder:Unknown order"}}, drain(exchange))
+
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{Validate: "true", OrderType: "limit", Type: "sell", Pair: "XBT/EUR", Price: "98", Volume: "1"}))
+	assert.Equal(t, "", drain(exchange)[0].(websocket.AddOrderStatus).TransactionID)
+}
+
+func TestExchangeWithClient(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+
+	client, err := websocket.NewClient(websocket.WithPublicURL(server.PublicURL()))
+	assert.Nil(t, err)
+
+	exchange := NewExchange(client)
+	assert.Nil(t, exchange.Send(websocket.Subscribe{Pair: []string{"XBT/EUR"}, Subscription: websocket.Subscription{Name: "book"}}))
+	assert.Nil(t, server.WaitForSubscription("public", "book", "XBT/EUR", time.Second))
+
+	assert.Nil(t, server.Publish("book", "XBT/EUR", json.RawMessage(`{"as":[["100.0","1.0","1612269825"]],"bs":[["99.0","1.0","1612269825"]]}`)))
+	drain(exchange)
+
+	assert.Nil(t, exchange.SendPrivate(websocket.AddOrder{OrderType: "market", Type: "sell", Pair: "XBT/EUR", Volume: "0.5"}))
+	drain(exchange)
+
+	trades := exchange.Trades()
+	assert.Len(t, trades, 1)
+	assert.Equal(t, websocket.Float64String(99), trades[0].Trade.Price)
+	assert.Equal(t, time.Unix(1612269825, 0), time.Time(trades[0].Trade.Time))
+}
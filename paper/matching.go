@@ -0,0 +1,139 @@
+package paper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lk16/kraken/internal/matching"
+	"github.com/lk16/kraken/websocket"
+)
+
+func (paperOrder *order) remaining() float64 {
+	remaining := paperOrder.volume - paperOrder.executed
+	if remaining <= paperOrder.volume*1e-9 {
+		return 0
+	}
+	return remaining
+}
+
+// crosses returns whether order would take liquidity from the book.
+func (exchange *Exchange) crosses(paperOrder *order) bool {
+	if paperOrder.market {
+		return true
+	}
+
+	book, ok := exchange.books.Snapshot(paperOrder.request.Pair)
+	if !ok {
+		return false
+	}
+
+	opposite := matching.Opposite(book, paperOrder.buy)
+	return len(opposite) != 0 && matching.Crossed(paperOrder.buy, float64(opposite[0].Price), paperOrder.price)
+}
+
+// matchTaker fills order against the book, up to its limit price.
+func (exchange *Exchange) matchTaker(paperOrder *order) []interface{} {
+	pair := paperOrder.request.Pair
+
+	book, ok := exchange.books.Snapshot(pair)
+	if !ok {
+		return nil
+	}
+
+	var messages []interface{}
+	exchange.liquidity.Take(pair, book, paperOrder.buy, paperOrder.market, paperOrder.price, paperOrder.remaining(), func(price float64, volume float64) {
+		messages = append(messages, exchange.fill(paperOrder, price, volume, false, exchange.now())...)
+	})
+	return messages
+}
+
+// bookChanged retries market orders that were not completely filled on the new book of pair.
+func (exchange *Exchange) bookChanged(pair string) []interface{} {
+	exchange.liquidity.Reset(pair)
+
+	var messages []interface{}
+
+	orderIDs := append([]string(nil), exchange.orderIDs...)
+	for _, orderID := range orderIDs {
+		paperOrder, ok := exchange.orders[orderID]
+		if ok && paperOrder.market && paperOrder.request.Pair == pair {
+			messages = append(messages, exchange.matchTaker(paperOrder)...)
+		}
+	}
+	return messages
+}
+
+// matchTrade fills resting limit orders as maker, in time priority, when a trade prints at or through their price.
+func (exchange *Exchange) matchTrade(pair string, trade websocket.TradeData) []interface{} {
+	available := float64(trade.Volume)
+	tradePrice := float64(trade.Price)
+
+	hitsBids := matching.HitsBids(trade)
+
+	var messages []interface{}
+
+	orderIDs := append([]string(nil), exchange.orderIDs...)
+	for _, orderID := range orderIDs {
+		if available <= 0 {
+			break
+		}
+
+		paperOrder, ok := exchange.orders[orderID]
+		if !ok || paperOrder.market || paperOrder.request.Pair != pair || paperOrder.buy != hitsBids {
+			continue
+		}
+
+		if !matching.Crossed(paperOrder.buy, tradePrice, paperOrder.price) {
+			continue
+		}
+
+		volume := paperOrder.remaining()
+		if available < volume {
+			volume = available
+		}
+		available -= volume
+
+		messages = append(messages, exchange.fill(paperOrder, paperOrder.price, volume, true, time.Time(trade.Time))...)
+	}
+	return messages
+}
+
+func (exchange *Exchange) fill(paperOrder *order, price float64, volume float64, maker bool, at time.Time) []interface{} {
+	cost, fee := exchange.fees.Charge(price, volume, maker)
+
+	paperOrder.executed += volume
+	paperOrder.cost += cost
+	paperOrder.fee += fee
+
+	exchange.lastTradeID++
+	tradeID := fmt.Sprintf("TPAPER-%05d-TRADES", exchange.lastTradeID)
+
+	trade := websocket.OwnTrade{
+		Cost:               websocket.Float64String(cost),
+		Fee:                websocket.Float64String(fee),
+		OrderTransactionID: paperOrder.transactionID,
+		OrderType:          paperOrder.request.OrderType,
+		Pair:               paperOrder.request.Pair,
+		Price:              websocket.Float64String(price),
+		Time:               websocket.UnixTime(at),
+		Type:               paperOrder.request.Type,
+		Volume:             websocket.Float64String(volume),
+	}
+	exchange.trades.AddList(websocket.OwnTradeList{{TradeID: tradeID, Trade: trade}})
+
+	messages := exchange.ownTradesMessage(tradeID, trade)
+	messages = append(messages, exchange.openOrdersMessage(map[string]websocket.OpenOrder{
+		paperOrder.transactionID: {
+			Cost:           websocket.Float64String(paperOrder.cost),
+			Fee:            websocket.Float64String(paperOrder.fee),
+			UserReference:  paperOrder.userReference(),
+			VolumeExecuted: websocket.Float64String(paperOrder.executed),
+			AveragePrice:   websocket.Float64String(paperOrder.averagePrice()),
+		},
+	})...)
+
+	if paperOrder.remaining() == 0 {
+		messages = append(messages, exchange.close(paperOrder, "closed", "")...)
+	}
+	return messages
+}
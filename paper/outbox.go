@@ -0,0 +1,52 @@
+package paper
+
+import "sync"
+
+// outbox is an unbounded queue, so SendPrivate never blocks on a consumer that is busy sending.
+type outbox struct {
+	mutex  sync.Mutex
+	items  []interface{}
+	notify chan struct{}
+	out    chan interface{}
+}
+
+func newOutbox() *outbox {
+	box := &outbox{
+		notify: make(chan struct{}, 1),
+		out:    make(chan interface{}),
+	}
+	go box.pump()
+	return box
+}
+
+func (box *outbox) push(messages ...interface{}) {
+	if len(messages) == 0 {
+		return
+	}
+
+	box.mutex.Lock()
+	box.items = append(box.items, messages...)
+	box.mutex.Unlock()
+
+	select {
+	case box.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (box *outbox) pump() {
+	for range box.notify {
+		for {
+			box.mutex.Lock()
+			if len(box.items) == 0 {
+				box.mutex.Unlock()
+				break
+			}
+			message := box.items[0]
+			box.items = box.items[1:]
+			box.mutex.Unlock()
+
+			box.out <- message
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package portfolio
+
+type Method int
+
+const (
+	FIFO Method = iota
+	AverageCost
+)
+
+// lot is unpriced until a price in the valuation currency is known.
+type lot struct {
+	volume float64
+	price  float64
+	priced bool
+}
+
+type holding struct {
+	balance  float64
+	lots     []lot
+	realized float64
+	fees     float64
+}
+
+func (holding *holding) add(method Method, volume float64, price float64, priced bool) {
+	if volume <= 0 {
+		return
+	}
+
+	if method == AverageCost && len(holding.lots) == 1 && holding.lots[0].priced == priced {
+		held := &holding.lots[0]
+		held.price = (held.volume*held.price + volume*price) / (held.volume + volume)
+		held.volume += volume
+		return
+	}
+
+	holding.lots = append(holding.lots, lot{volume: volume, price: price, priced: priced})
+}
+
+// remove returns the priced part of volume with its cost basis, volume exceeding the lots has no cost basis.
+func (holding *holding) remove(volume float64) (pricedVolume float64, basis float64) {
+	for volume > 0 && len(holding.lots) != 0 {
+		held := &holding.lots[0]
+
+		taken := volume
+		if held.volume < taken {
+			taken = held.volume
+		}
+
+		if held.priced {
+			pricedVolume += taken
+			basis += taken * held.price
+		}
+
+		held.volume -= taken
+		volume -= taken
+
+		// remove lots that are exhausted, allowing for rounding errors
+		if held.volume <= 1e-12 {
+			holding.lots = holding.lots[1:]
+		}
+	}
+	return pricedVolume, basis
+}
+
+func (holding *holding) sell(volume float64, price float64, priced bool) {
+	pricedVolume, basis := holding.remove(volume)
+	if priced {
+		holding.realized += pricedVolume*price - basis
+	}
+}
+
+func (holding *holding) setPrice(method Method, price float64) {
+	unpriced := false
+	for index := range holding.lots {
+		if !holding.lots[index].priced {
+			holding.lots[index].price = price
+			holding.lots[index].priced = true
+			unpriced = true
+		}
+	}
+
+	if !unpriced || method != AverageCost || len(holding.lots) < 2 {
+		return
+	}
+
+	var merged lot
+	for _, held := range holding.lots {
+		merged.price = (merged.volume*merged.price + held.volume*held.price) / (merged.volume + held.volume)
+		merged.volume += held.volume
+	}
+	merged.priced = true
+	holding.lots = []lot{merged}
+}
+
+func (holding *holding) hasUnpriced() bool {
+	for _, held := range holding.lots {
+		if !held.priced {
+			return true
+		}
+	}
+	return false
+}
+
+func (holding *holding) costBasis() (volume float64, basis float64) {
+	for _, held := range holding.lots {
+		if held.priced {
+			volume += held.volume
+			basis += held.volume * held.price
+		}
+	}
+	return volume, basis
+}
@@ -0,0 +1,236 @@
+package portfolio
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+)
+
+type Source interface {
+	Balance() (map[string]rest.Float64String, error)
+}
+
+type Option func(portfolio *Portfolio)
+
+func WithMethod(method Method) Option {
+	return func(portfolio *Portfolio) {
+		portfolio.method = method
+	}
+}
+
+// UnpricedTrade is returned for a trade without a known price in the valuation currency, it does not count
+// towards PnL and fees.
+type UnpricedTrade struct {
+	TradeID string
+	Trade   websocket.OwnTrade
+}
+
+// Portfolio values balances at the mid price of their pair with the valuation currency, opening balances are
+// priced at the first known price so that PnL starts at zero.
+type Portfolio struct {
+	mutex     sync.RWMutex
+	currency  assets.Asset
+	method    Method
+	holdings  map[assets.Asset]*holding
+	prices    map[assets.Pair]float64
+	books     *websocket.BookManager
+	ownTrades *websocket.OwnTradeStore
+	startTime time.Time
+}
+
+func New(currency string, options ...Option) *Portfolio {
+	portfolio := &Portfolio{
+		currency:  assets.ParseAsset(currency),
+		holdings:  make(map[assets.Asset]*holding),
+		prices:    make(map[assets.Pair]float64),
+		books:     websocket.NewBookManager(nil, 0),
+		ownTrades: websocket.NewOwnTradeStore(),
+	}
+
+	for _, option := range options {
+		option(portfolio)
+	}
+	return portfolio
+}
+
+// Load gets the balances of source as of at, see SetBalances.
+func Load(source Source, currency string, at time.Time, options ...Option) (*Portfolio, error) {
+	balances, err := source.Balance()
+	if err != nil {
+		return nil, fmt.Errorf("could not get balance: %w", err)
+	}
+
+	portfolio := New(currency, options...)
+	portfolio.SetBalances(balances, at)
+	return portfolio, nil
+}
+
+// SetBalances resets PnL, trades until at are ignored as they are part of the balances. As at is compared with
+// trade times of Kraken it should be an exchange time, like that of the last trade in the ownTrades snapshot, and
+// not the local clock.
+func (portfolio *Portfolio) SetBalances(balances map[string]rest.Float64String, at time.Time) {
+	portfolio.mutex.Lock()
+	defer portfolio.mutex.Unlock()
+
+	portfolio.holdings = make(map[assets.Asset]*holding)
+	portfolio.startTime = at
+
+	for name, balance := range balances {
+		asset := assets.ParseAsset(name)
+
+		tracked := portfolio.holding(asset)
+		tracked.balance += float64(balance)
+
+		if asset != portfolio.currency {
+			price, ok := portfolio.price(asset)
+			tracked.add(portfolio.method, float64(balance), price, ok)
+		}
+	}
+}
+
+func (portfolio *Portfolio) holding(asset assets.Asset) *holding {
+	tracked, ok := portfolio.holdings[asset]
+	if !ok {
+		tracked = &holding{}
+		portfolio.holdings[asset] = tracked
+	}
+	return tracked
+}
+
+func (portfolio *Portfolio) price(asset assets.Asset) (float64, bool) {
+	if asset == portfolio.currency {
+		return 1, true
+	}
+
+	if price, ok := portfolio.prices[assets.Pair{Base: asset, Quote: portfolio.currency}]; ok {
+		return price, true
+	}
+
+	if price, ok := portfolio.prices[assets.Pair{Base: portfolio.currency, Quote: asset}]; ok && price != 0 {
+		return 1 / price, true
+	}
+	return 0, false
+}
+
+func (portfolio *Portfolio) SetPrice(pair assets.Pair, price float64) {
+	portfolio.mutex.Lock()
+	defer portfolio.mutex.Unlock()
+
+	portfolio.setPrice(pair, price)
+}
+
+func (portfolio *Portfolio) setPrice(pair assets.Pair, price float64) {
+	portfolio.prices[pair] = price
+
+	for asset, tracked := range portfolio.holdings {
+		if asset == portfolio.currency || !tracked.hasUnpriced() {
+			continue
+		}
+
+		if price, ok := portfolio.price(asset); ok {
+			tracked.setPrice(portfolio.method, price)
+		}
+	}
+}
+
+func (portfolio *Portfolio) Handle(rawMessage interface{}) []interface{} {
+	switch message := rawMessage.(type) {
+	case websocket.Ticker:
+		bid, ask := float64(message.Data.Bid.Price), float64(message.Data.Ask.Price)
+		portfolio.setMid(message.Pair, bid, ask)
+	case websocket.Book:
+		portfolio.handleBook(message, message.Pair)
+	case websocket.BookUpdate:
+		portfolio.handleBook(message, message.Pair)
+	case websocket.OwnTrades:
+		return portfolio.handleOwnTrades(message)
+	}
+	return nil
+}
+
+func (portfolio *Portfolio) setMid(name string, bid float64, ask float64) {
+	pair, err := assets.ParsePair(name)
+	if err != nil || bid <= 0 || ask <= 0 {
+		return
+	}
+
+	portfolio.SetPrice(pair, (bid+ask)/2)
+}
+
+func (portfolio *Portfolio) handleBook(message interface{}, pair string) {
+	if portfolio.books.Handle(message) != nil {
+		return
+	}
+
+	book, ok := portfolio.books.Snapshot(pair)
+	if ok && len(book.Data.Asks) != 0 && len(book.Data.Bids) != 0 {
+		portfolio.setMid(pair, float64(book.Data.Bids[0].Price), float64(book.Data.Asks[0].Price))
+	}
+}
+
+func (portfolio *Portfolio) handleOwnTrades(message websocket.OwnTrades) []interface{} {
+	portfolio.mutex.Lock()
+	defer portfolio.mutex.Unlock()
+
+	var events []interface{}
+	for _, entry := range portfolio.ownTrades.Add(message) {
+		events = append(events, portfolio.applyTrade(entry.TradeID, entry.Trade)...)
+	}
+	return events
+}
+
+// applyTrade skips margin trades, they do not move balances until their position is settled.
+func (portfolio *Portfolio) applyTrade(id string, trade websocket.OwnTrade) []interface{} {
+	if trade.Margin != 0 || !time.Time(trade.Time).After(portfolio.startTime) {
+		return nil
+	}
+
+	pair, err := trade.ParsedPair()
+	if err != nil {
+		return nil
+	}
+
+	portfolio.setPrice(pair, float64(trade.Price))
+
+	rate, priced := portfolio.price(pair.Quote)
+	price := float64(trade.Price) * rate
+	volume, cost, fee := float64(trade.Volume), float64(trade.Cost), float64(trade.Fee)
+
+	base := portfolio.holding(pair.Base)
+	quote := portfolio.holding(pair.Quote)
+
+	if trade.Type == "buy" {
+		base.balance += volume
+		quote.balance -= cost + fee
+		portfolio.acquire(pair.Base, volume, price, priced)
+		portfolio.dispose(pair.Quote, cost+fee, rate, priced)
+	} else {
+		base.balance -= volume
+		quote.balance += cost - fee
+		portfolio.dispose(pair.Base, volume, price, priced)
+		portfolio.acquire(pair.Quote, cost-fee, rate, priced)
+	}
+
+	if !priced {
+		return []interface{}{UnpricedTrade{TradeID: id, Trade: trade}}
+	}
+
+	base.fees += fee * rate
+	return nil
+}
+
+func (portfolio *Portfolio) acquire(asset assets.Asset, volume float64, price float64, priced bool) {
+	if asset != portfolio.currency {
+		portfolio.holding(asset).add(portfolio.method, volume, price, priced)
+	}
+}
+
+func (portfolio *Portfolio) dispose(asset assets.Asset, volume float64, price float64, priced bool) {
+	if asset != portfolio.currency {
+		portfolio.holding(asset).sell(volume, price, priced)
+	}
+}
@@ -0,0 +1,166 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/lk16/kraken/mockserver"
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+var balanceTime = time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+
+var xbteur = assets.Pair{Base: "XBT", Quote: "EUR"}
+
+// testTrade pays a fee of 1% and is made the given number of seconds after the balances were taken.
+func testTrade(id string, pair string, side string, price float64, volume float64, seconds int) websocket.OwnTradeEntry {
+	cost := price * volume
+	return websocket.OwnTradeEntry{TradeID: id, Trade: websocket.OwnTrade{
+		Pair:   pair,
+		Type:   side,
+		Price:  websocket.Float64String(price),
+		Volume: websocket.Float64String(volume),
+		Cost:   websocket.Float64String(cost),
+		Fee:    websocket.Float64String(cost / 100),
+		Time:   websocket.UnixTime(balanceTime.Add(time.Duration(seconds) * time.Second)),
+	}}
+}
+
+func ownTrades(trades ...websocket.OwnTradeEntry) websocket.OwnTrades {
+	return websocket.OwnTrades{ChannelName: "ownTrades", Trades: trades}
+}
+
+func ticker(pair string, bid float64, ask float64) websocket.Ticker {
+	return websocket.Ticker{Pair: pair, Data: websocket.TickerData{
+		Bid: websocket.TickerAskBid{Price: websocket.Float64String(bid)},
+		Ask: websocket.TickerAskBid{Price: websocket.Float64String(ask)},
+	}}
+}
+
+func TestPortfolioAccounting(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     Method
+		realized   float64
+		unrealized float64
+		costBasis  float64
+	}{
+		{"fifo", FIFO, 200, 200, 200},
+		{"average cost", AverageCost, 150, 250, 150},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			portfolio := New("ZEUR", WithMethod(testCase.method))
+			portfolio.SetBalances(map[string]rest.Float64String{"ZEUR": 1000}, balanceTime)
+
+			events := portfolio.Handle(ownTrades(
+				testTrade("T1", "XBT/EUR", "buy", 100, 1, 10),
+				testTrade("T2", "XBT/EUR", "buy", 200, 1, 20),
+				testTrade("T3", "XBT/EUR", "sell", 300, 1, 30),
+			))
+			assert.Empty(t, events)
+
+			// trades are applied once
+			assert.Empty(t, portfolio.Handle(ownTrades(testTrade("T3", "XBT/EUR", "sell", 300, 1, 30))))
+
+			portfolio.Handle(ticker("XBT/EUR", 390, 410))
+
+			report := portfolio.Report()
+			assert.Equal(t, assets.Asset("EUR"), report.Currency)
+			assert.InDelta(t, 994, report.Cash, 1e-9)
+			assert.InDelta(t, 1394, report.Value, 1e-9)
+			assert.InDelta(t, 6, report.Fees, 1e-9)
+			assert.InDelta(t, 394, report.PnL, 1e-9)
+
+			assert.Len(t, report.Assets, 1)
+			xbt := report.Assets[0]
+			assert.Equal(t, assets.Asset("XBT"), xbt.Asset)
+			assert.Equal(t, 1.0, xbt.Balance)
+			assert.Equal(t, 400.0, xbt.Price)
+			assert.InDelta(t, testCase.realized, xbt.Realized, 1e-9)
+			assert.InDelta(t, testCase.unrealized, xbt.Unrealized, 1e-9)
+			assert.InDelta(t, testCase.costBasis, xbt.CostBasis, 1e-9)
+		})
+	}
+}
+
+func TestPortfolioLoad(t *testing.T) {
+	server, err := mockserver.NewRESTServer("key", "c2VjcmV0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client := rest.NewClient(rest.WithURL(server.URL()))
+	assert.Nil(t, client.SetAuth("key", "c2VjcmV0"))
+
+	portfolio, err := Load(client, "EUR", balanceTime)
+	assert.Nil(t, err)
+
+	// opening balances are priced at the first known price
+	report := portfolio.Report()
+	assert.Equal(t, 1000.0, report.Value)
+	assert.Equal(t, 0.5, report.Assets[0].Balance)
+
+	portfolio.Handle(websocket.Book{Pair: "XBT/EUR", Data: websocket.BookData{
+		Asks: []websocket.PriceLevel{{Price: 30010, Volume: 1}},
+		Bids: []websocket.PriceLevel{{Price: 29990, Volume: 1}},
+	}})
+	report = portfolio.Report()
+	assert.Equal(t, 16000.0, report.Value)
+	assert.Equal(t, 0.0, report.PnL)
+
+	// the best ask is removed by the update
+	portfolio.Handle(websocket.BookUpdate{Pair: "XBT/EUR", Data: websocket.BookUpdateData{
+		Asks: []websocket.PriceLevel{{Price: 30010, Volume: 0}, {Price: 30410, Volume: 1}},
+	}})
+	assert.Equal(t, 16100.0, portfolio.Report().Value)
+
+	portfolio.Handle(ticker("XBT/EUR", 30990, 31010))
+	report = portfolio.Report()
+	assert.InDelta(t, 500, report.Unrealized, 1e-9)
+	assert.InDelta(t, 500, report.PnL, 1e-9)
+}
+
+func TestPortfolioCrossPairs(t *testing.T) {
+	portfolio := New("EUR")
+	portfolio.SetBalances(map[string]rest.Float64String{"XXBT": 1}, balanceTime)
+	portfolio.SetPrice(xbteur, 400)
+
+	// trades before the balances were taken are part of them
+	assert.Empty(t, portfolio.Handle(ownTrades(testTrade("TOLD", "XBT/EUR", "buy", 100, 1, -10))))
+
+	// ETH is bought with XBT, which is valued at the XBT/EUR price
+	assert.Empty(t, portfolio.Handle(ownTrades(testTrade("T1", "ETH/XBT", "buy", 0.05, 2, 10))))
+
+	report := portfolio.Report()
+	assert.Len(t, report.Assets, 2)
+	eth, xbt := report.Assets[0], report.Assets[1]
+	assert.InDelta(t, 2, eth.Balance, 1e-9)
+	assert.InDelta(t, 40, eth.CostBasis, 1e-9)
+	assert.InDelta(t, 0.4, eth.Fees, 1e-9)
+	assert.InDelta(t, 0.899, xbt.Balance, 1e-9)
+	assert.Equal(t, 0.0, xbt.Realized)
+
+	// without an ETH/EUR or USD price the trade can not be valued
+	entry := testTrade("T2", "ETH/USD", "sell", 2000, 1, 20)
+	events := portfolio.Handle(ownTrades(entry))
+	assert.Equal(t, []interface{}{UnpricedTrade{TradeID: "T2", Trade: entry.Trade}}, events)
+	assert.InDelta(t, 1, portfolio.Report().Assets[0].Balance, 1e-9)
+}
+
+func TestPortfolioMarginTrades(t *testing.T) {
+	portfolio := New("EUR")
+	portfolio.SetBalances(map[string]rest.Float64String{"ZEUR": 1000}, balanceTime)
+
+	entry := testTrade("T1", "XBT/EUR", "buy", 100, 1, 10)
+	entry.Trade.Margin = 20
+	assert.Empty(t, portfolio.Handle(ownTrades(entry)))
+
+	report := portfolio.Report()
+	assert.Equal(t, 1000.0, report.Cash)
+	assert.Empty(t, report.Assets)
+	assert.Equal(t, 0.0, report.PnL)
+}
@@ -0,0 +1,75 @@
+package portfolio
+
+import (
+	"sort"
+
+	"github.com/lk16/kraken/assets"
+)
+
+type AssetReport struct {
+	Asset   assets.Asset
+	Balance float64
+	// Price is zero if no price of the asset is known.
+	Price      float64
+	Value      float64
+	CostBasis  float64
+	Realized   float64
+	Unrealized float64
+	Fees       float64
+}
+
+type Report struct {
+	Currency   assets.Asset
+	Cash       float64
+	Value      float64
+	Realized   float64
+	Unrealized float64
+	Fees       float64
+	// PnL is the realized and unrealized PnL after fees.
+	PnL    float64
+	Assets []AssetReport
+}
+
+func (portfolio *Portfolio) Report() Report {
+	portfolio.mutex.RLock()
+	defer portfolio.mutex.RUnlock()
+
+	report := Report{Currency: portfolio.currency}
+
+	for asset, tracked := range portfolio.holdings {
+		if asset == portfolio.currency {
+			report.Cash = tracked.balance
+			continue
+		}
+
+		volume, basis := tracked.costBasis()
+
+		assetReport := AssetReport{
+			Asset:     asset,
+			Balance:   tracked.balance,
+			CostBasis: basis,
+			Realized:  tracked.realized,
+			Fees:      tracked.fees,
+		}
+
+		if price, ok := portfolio.price(asset); ok {
+			assetReport.Price = price
+			assetReport.Value = tracked.balance * price
+			assetReport.Unrealized = volume*price - basis
+		}
+
+		report.Value += assetReport.Value
+		report.Realized += assetReport.Realized
+		report.Unrealized += assetReport.Unrealized
+		report.Fees += assetReport.Fees
+		report.Assets = append(report.Assets, assetReport)
+	}
+
+	sort.Slice(report.Assets, func(i, j int) bool {
+		return report.Assets[i].Asset < report.Assets[j].Asset
+	})
+
+	report.Value += report.Cash
+	report.PnL = report.Realized + report.Unrealized - report.Fees
+	return report
+}
@@ -1,5 +1,7 @@
 package rest
 
+import "time"
+
 type Response struct {
 	Error  []string    `json:"error"`
 	Result interface{} `json:"result"`
@@ -9,3 +11,189 @@ type WebSocketToken struct {
 	Token   string `json:"token"`
 	Expires int64  `json:"expires"`
 }
+
+type OrderDescription struct {
+	Pair      string        `json:"pair"`
+	Type      string        `json:"type"`
+	OrderType string        `json:"ordertype"`
+	Price     Float64String `json:"price"`
+	Price2    Float64String `json:"price2"`
+	Leverage  string        `json:"leverage"`
+	Order     string        `json:"order"`
+	Close     string        `json:"close"`
+}
+
+type Order struct {
+	ReferenceID    string           `json:"refid"`
+	UserReference  int64            `json:"userref"`
+	Status         string           `json:"status"`
+	OpenTime       UnixTime         `json:"opentm"`
+	StartTime      UnixTime         `json:"starttm"`
+	ExpireTime     UnixTime         `json:"expiretm"`
+	CloseTime      UnixTime         `json:"closetm"`
+	Description    OrderDescription `json:"descr"`
+	Volume         Float64String    `json:"vol"`
+	VolumeExecuted Float64String    `json:"vol_exec"`
+	Cost           Float64String    `json:"cost"`
+	Fee            Float64String    `json:"fee"`
+	Price          Float64String    `json:"price"`
+	StopPrice      Float64String    `json:"stopprice"`
+	LimitPrice     Float64String    `json:"limitprice"`
+	Miscellaneous  string           `json:"misc"`
+	OFlags         string           `json:"oflags"`
+	Reason         string           `json:"reason"`
+	Trades         []string         `json:"trades"`
+}
+
+type OpenOrders struct {
+	Open map[string]Order `json:"open"`
+}
+
+type TradeInfo struct {
+	OrderTransactionID string        `json:"ordertxid"`
+	PosTransactionID   string        `json:"postxid"`
+	Pair               string        `json:"pair"`
+	Time               UnixTime      `json:"time"`
+	Type               string        `json:"type"`
+	OrderType          string        `json:"ordertype"`
+	Price              Float64String `json:"price"`
+	Cost               Float64String `json:"cost"`
+	Fee                Float64String `json:"fee"`
+	Volume             Float64String `json:"vol"`
+	Margin             Float64String `json:"margin"`
+	Miscellaneous      string        `json:"misc"`
+}
+
+type TradesHistory struct {
+	Trades map[string]TradeInfo `json:"trades"`
+	Count  int                  `json:"count"`
+}
+
+// OHLC is a candle of the OHLC endpoint, Time is the start of its interval.
+type OHLC struct {
+	Time   UnixTime
+	Open   Float64String
+	High   Float64String
+	Low    Float64String
+	Close  Float64String
+	VWAP   Float64String
+	Volume Float64String
+	Count  int64
+}
+
+type OHLCResult struct {
+	Pair    string
+	Candles []OHLC
+	// Last is the start of the last candle, which is still in progress. It can be passed as since to poll for updates.
+	Last time.Time
+}
+
+// PublicTrade is a trade of the Trades endpoint, Side is "b" or "s" and OrderType is "m" or "l".
+type PublicTrade struct {
+	Price     Float64String
+	Volume    Float64String
+	Time      UnixTime
+	Side      string
+	OrderType string
+	Misc      string
+	TradeID   int64
+}
+
+type TradesResult struct {
+	Pair   string
+	Trades []PublicTrade
+	// Last can be passed as since to get the next trades.
+	Last time.Time
+}
+
+// FeeLevel is a [volume, percent] pair of a fee schedule.
+type FeeLevel [2]float64
+
+type AssetPair struct {
+	Altname           string        `json:"altname"`
+	WSName            string        `json:"wsname"`
+	AclassBase        string        `json:"aclass_base"`
+	Base              string        `json:"base"`
+	AclassQuote       string        `json:"aclass_quote"`
+	Quote             string        `json:"quote"`
+	Lot               string        `json:"lot"`
+	CostDecimals      int           `json:"cost_decimals"`
+	PairDecimals      int           `json:"pair_decimals"`
+	LotDecimals       int           `json:"lot_decimals"`
+	LotMultiplier     int           `json:"lot_multiplier"`
+	LeverageBuy       []int         `json:"leverage_buy"`
+	LeverageSell      []int         `json:"leverage_sell"`
+	Fees              []FeeLevel    `json:"fees"`
+	FeesMaker         []FeeLevel    `json:"fees_maker"`
+	FeeVolumeCurrency string        `json:"fee_volume_currency"`
+	MarginCall        int           `json:"margin_call"`
+	MarginStop        int           `json:"margin_stop"`
+	OrderMin          Float64String `json:"ordermin"`
+	CostMin           Float64String `json:"costmin"`
+	TickSize          Float64String `json:"tick_size"`
+	Status            string        `json:"status"`
+}
+
+type Asset struct {
+	Aclass          string  `json:"aclass"`
+	Altname         string  `json:"altname"`
+	Decimals        int     `json:"decimals"`
+	DisplayDecimals int     `json:"display_decimals"`
+	CollateralValue float64 `json:"collateral_value"`
+	Status          string  `json:"status"`
+}
+
+// FeeInfo is the current fee of a pair in percent. NextFee and NextVolume are zero in the highest tier.
+type FeeInfo struct {
+	Fee        Float64String `json:"fee"`
+	MinFee     Float64String `json:"minfee"`
+	MaxFee     Float64String `json:"maxfee"`
+	NextFee    Float64String `json:"nextfee"`
+	NextVolume Float64String `json:"nextvolume"`
+	TierVolume Float64String `json:"tiervolume"`
+}
+
+// TradeVolume holds the 30 day traded volume in Currency, which selects the fee tier. Fees and FeesMaker are
+// keyed by the REST name of the requested pairs.
+type TradeVolume struct {
+	Currency  string             `json:"currency"`
+	Volume    Float64String      `json:"volume"`
+	Fees      map[string]FeeInfo `json:"fees"`
+	FeesMaker map[string]FeeInfo `json:"fees_maker"`
+}
+
+// Position is an open margin position. Value and Net are only set when requested with docalcs.
+type Position struct {
+	OrderTransactionID string        `json:"ordertxid"`
+	Status             string        `json:"posstatus"`
+	Pair               string        `json:"pair"`
+	Time               UnixTime      `json:"time"`
+	Type               string        `json:"type"`
+	OrderType          string        `json:"ordertype"`
+	Cost               Float64String `json:"cost"`
+	Fee                Float64String `json:"fee"`
+	Volume             Float64String `json:"vol"`
+	VolumeClosed       Float64String `json:"vol_closed"`
+	Margin             Float64String `json:"margin"`
+	Value              Float64String `json:"value"`
+	Net                Float64String `json:"net"`
+	Terms              string        `json:"terms"`
+	RolloverTime       UnixTime      `json:"rollovertm"`
+	Miscellaneous      string        `json:"misc"`
+	OFlags             string        `json:"oflags"`
+}
+
+// TradeBalance is the margin account summary, amounts are in the requested asset.
+// MarginLevel is the equity as percentage of the used margin.
+type TradeBalance struct {
+	EquivalentBalance Float64String `json:"eb"`
+	TradeBalance      Float64String `json:"tb"`
+	Margin            Float64String `json:"m"`
+	UnrealizedNet     Float64String `json:"n"`
+	CostBasis         Float64String `json:"c"`
+	Valuation         Float64String `json:"v"`
+	Equity            Float64String `json:"e"`
+	FreeMargin        Float64String `json:"mf"`
+	MarginLevel       Float64String `json:"ml"`
+	UnexecutedValue   Float64String `json:"uv"`
+}
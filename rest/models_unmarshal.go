@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// unmarshalArray unmarshals the elements of a JSON array into fields, of which the first required must be present.
+func unmarshalArray(bytes []byte, required int, fields ...interface{}) error {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(bytes, &elements); err != nil {
+		return err
+	}
+
+	if len(elements) < required {
+		return fmt.Errorf("expected at least %d elements, got %d", required, len(elements))
+	}
+
+	for index, element := range elements {
+		if index == len(fields) {
+			break
+		}
+
+		if err := json.Unmarshal(element, fields[index]); err != nil {
+			return fmt.Errorf("parsing element %d failed: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// unmarshalPairResult splits results holding a list under the name of the pair and a "last" cursor.
+func unmarshalPairResult(bytes []byte, last interface{}) (pair string, list json.RawMessage, err error) {
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return "", nil, err
+	}
+
+	for key, value := range result {
+		if key == "last" {
+			if err := json.Unmarshal(value, last); err != nil {
+				return "", nil, fmt.Errorf("parsing last failed: %w", err)
+			}
+			continue
+		}
+		pair = key
+		list = value
+	}
+	return pair, list, nil
+}
+
+func (ohlc *OHLC) UnmarshalJSON(bytes []byte) error {
+	return unmarshalArray(bytes, 8, &ohlc.Time, &ohlc.Open, &ohlc.High, &ohlc.Low, &ohlc.Close, &ohlc.VWAP,
+		&ohlc.Volume, &ohlc.Count)
+}
+
+func (result *OHLCResult) UnmarshalJSON(bytes []byte) error {
+	var last int64
+
+	pair, list, err := unmarshalPairResult(bytes, &last)
+	if err != nil {
+		return err
+	}
+
+	result.Pair = pair
+	result.Last = time.Unix(last, 0)
+
+	if list == nil {
+		return nil
+	}
+	return json.Unmarshal(list, &result.Candles)
+}
+
+// UnmarshalJSON parses trades with or without the trade ID, which older responses lack.
+func (trade *PublicTrade) UnmarshalJSON(bytes []byte) error {
+	return unmarshalArray(bytes, 6, &trade.Price, &trade.Volume, &trade.Time, &trade.Side, &trade.OrderType,
+		&trade.Misc, &trade.TradeID)
+}
+
+func (result *TradesResult) UnmarshalJSON(bytes []byte) error {
+	// the cursor holds nanoseconds, which are sent as string
+	var last string
+
+	pair, list, err := unmarshalPairResult(bytes, &last)
+	if err != nil {
+		return err
+	}
+
+	result.Pair = pair
+
+	if last != "" {
+		nanoseconds, err := strconv.ParseInt(last, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing last failed: %w", err)
+		}
+		result.Last = time.Unix(0, nanoseconds)
+	}
+
+	if list == nil {
+		return nil
+	}
+	return json.Unmarshal(list, &result.Trades)
+}
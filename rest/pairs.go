@@ -0,0 +1,26 @@
+package rest
+
+import "github.com/lk16/kraken/assets"
+
+// ParsedPair parses the pair of the description, which Kraken gives as altname such as "XBTEUR".
+func (description OrderDescription) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(description.Pair)
+}
+
+// ParsedPair parses the pair of the trade, which Kraken gives as REST name such as "XXBTZEUR".
+func (trade TradeInfo) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(trade.Pair)
+}
+
+func (result OHLCResult) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(result.Pair)
+}
+
+func (result TradesResult) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(result.Pair)
+}
+
+// Canonical returns the canonical form of the pair from its base and quote asset.
+func (pair AssetPair) Canonical() assets.Pair {
+	return assets.NewPair(pair.Base, pair.Quote)
+}
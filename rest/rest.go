@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,14 +20,40 @@ const (
 	APIVersion = "0"
 )
 
+type APIError struct {
+	Errors []string
+}
+
+func (err APIError) Error() string {
+	return fmt.Sprintf("kraken returned error: %s", strings.Join(err.Errors, ", "))
+}
+
 type Client struct {
 	key           string
 	secret        string
 	decodedSecret []byte
+	url           string
+
+	nonceMutex sync.Mutex
+	lastNonce  int64
 }
 
-func NewClient() *Client {
-	return &Client{}
+type Option func(client *Client)
+
+// WithURL sends requests to url instead of the Kraken API, for example a mockserver.RESTServer.
+func WithURL(url string) Option {
+	return func(client *Client) {
+		client.url = url
+	}
+}
+
+func NewClient(options ...Option) *Client {
+	client := &Client{url: APIUrl}
+
+	for _, option := range options {
+		option(client)
+	}
+	return client
 }
 
 func (client *Client) SetAuth(key string, secret string) error {
@@ -38,20 +65,39 @@ func (client *Client) SetAuth(key string, secret string) error {
 	return err
 }
 
-func (client *Client) sign(requestURL string, data url.Values) string {
+// Sign computes the API-Sign header of a private request to urlPath with the base64 decoded secret.
+// Body is the request body exactly as it is sent, nonce is the nonce it contains.
+func Sign(decodedSecret []byte, urlPath string, nonce string, body string) string {
 	// note: calling Write() on a hash object cannot fail, the returned error is always nil
 
 	sha256State := sha256.New()
-	sha256State.Write([]byte(data.Get("nonce")))
-	sha256State.Write([]byte(data.Encode()))
+	sha256State.Write([]byte(nonce))
+	sha256State.Write([]byte(body))
 
-	hmacState := hmac.New(sha512.New, client.decodedSecret)
-	hmacState.Write([]byte(requestURL))
+	hmacState := hmac.New(sha512.New, decodedSecret)
+	hmacState.Write([]byte(urlPath))
 	hmacState.Write(sha256State.Sum(nil))
 
 	return base64.StdEncoding.EncodeToString(hmacState.Sum(nil))
 }
 
+func (client *Client) sign(urlPath string, data url.Values) string {
+	return Sign(client.decodedSecret, urlPath, data.Get("nonce"), data.Encode())
+}
+
+// nextNonce returns the current time in nanoseconds, but always more than the previous nonce.
+func (client *Client) nextNonce() int64 {
+	client.nonceMutex.Lock()
+	defer client.nonceMutex.Unlock()
+
+	nonce := time.Now().UnixNano()
+	if nonce <= client.lastNonce {
+		nonce = client.lastNonce + 1
+	}
+	client.lastNonce = nonce
+	return nonce
+}
+
 func (client *Client) prepareRequest(method string, isPrivate bool, data url.Values) (*http.Request, error) {
 
 	if data == nil {
@@ -59,7 +105,7 @@ func (client *Client) prepareRequest(method string, isPrivate bool, data url.Val
 	}
 
 	if isPrivate {
-		data.Set("nonce", fmt.Sprintf("%d", time.Now().UnixNano()))
+		data.Set("nonce", fmt.Sprintf("%d", client.nextNonce()))
 	}
 
 	var publicOrPrivate string
@@ -71,11 +117,13 @@ func (client *Client) prepareRequest(method string, isPrivate bool, data url.Val
 
 	urlPath := fmt.Sprintf("/%s/%s/%s", APIVersion, publicOrPrivate, method)
 
-	request, err := http.NewRequest("POST", APIUrl+urlPath, strings.NewReader(data.Encode()))
+	request, err := http.NewRequest("POST", client.url+urlPath, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("could not create request: %w", err)
 	}
 
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
 	if isPrivate {
 		request.Header.Add("API-Key", client.key)
 		request.Header.Add("API-Sign", client.sign(urlPath, data))
@@ -106,6 +154,10 @@ func parseResponse(response *http.Response, retType interface{}) error {
 		return fmt.Errorf("parsing JSON body failed: %w", err)
 	}
 
+	if len(retData.Error) != 0 {
+		return APIError{Errors: retData.Error}
+	}
+
 	return nil
 }
 
@@ -132,3 +184,160 @@ func (client *Client) GetWebSocketsToken() (WebSocketToken, error) {
 	}
 	return response, nil
 }
+
+// Balance - Get account balances, keyed by their REST name such as "XXBT"
+func (client *Client) Balance() (map[string]Float64String, error) {
+	var response map[string]Float64String
+
+	if err := client.request("Balance", true, nil, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// OpenOrders - Get open orders
+func (client *Client) OpenOrders() (OpenOrders, error) {
+	var response OpenOrders
+
+	data := url.Values{}
+	data.Set("trades", "true")
+
+	if err := client.request("OpenOrders", true, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// QueryOrders - Query orders info
+func (client *Client) QueryOrders(transactionIDs []string) (map[string]Order, error) {
+	var response map[string]Order
+
+	data := url.Values{}
+	data.Set("trades", "true")
+	data.Set("txid", strings.Join(transactionIDs, ","))
+
+	if err := client.request("QueryOrders", true, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// TradesHistory - Get trades history, start may be zero to get the most recent trades. Results are paged by 50
+// trades, offset skips the first ones and Count is the total number of matching trades.
+func (client *Client) TradesHistory(start time.Time, offset int) (TradesHistory, error) {
+	var response TradesHistory
+
+	data := url.Values{}
+	if !start.IsZero() {
+		data.Set("start", fmt.Sprintf("%d", start.Unix()))
+	}
+	if offset != 0 {
+		data.Set("ofs", fmt.Sprintf("%d", offset))
+	}
+
+	if err := client.request("TradesHistory", true, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// OHLC - Get OHLC data, interval is in minutes, since may be zero to get the most recent candles
+func (client *Client) OHLC(pair string, interval int, since time.Time) (OHLCResult, error) {
+	var response OHLCResult
+
+	data := url.Values{}
+	data.Set("pair", pair)
+	data.Set("interval", fmt.Sprintf("%d", interval))
+	if !since.IsZero() {
+		data.Set("since", fmt.Sprintf("%d", since.Unix()))
+	}
+
+	if err := client.request("OHLC", false, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// Trades - Get recent trades, since may be zero to get the most recent trades
+func (client *Client) Trades(pair string, since time.Time) (TradesResult, error) {
+	var response TradesResult
+
+	data := url.Values{}
+	data.Set("pair", pair)
+	if !since.IsZero() {
+		data.Set("since", fmt.Sprintf("%d", since.UnixNano()))
+	}
+
+	if err := client.request("Trades", false, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// AssetPairs - Get tradable asset pairs, keyed by their REST name such as "XXBTZEUR"
+func (client *Client) AssetPairs() (map[string]AssetPair, error) {
+	var response map[string]AssetPair
+
+	if err := client.request("AssetPairs", false, nil, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// Assets - Get asset info, keyed by their REST name such as "XXBT"
+func (client *Client) Assets() (map[string]Asset, error) {
+	var response map[string]Asset
+
+	if err := client.request("Assets", false, nil, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// TradeVolume - Get the 30 day traded volume and the current fees of pairs
+func (client *Client) TradeVolume(pairs ...string) (TradeVolume, error) {
+	var response TradeVolume
+
+	data := url.Values{}
+	if len(pairs) > 0 {
+		data.Set("pair", strings.Join(pairs, ","))
+	}
+
+	if err := client.request("TradeVolume", true, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// OpenPositions - Get open margin positions keyed by position ID, docalcs adds their current value and profit
+func (client *Client) OpenPositions(docalcs bool, transactionIDs ...string) (map[string]Position, error) {
+	var response map[string]Position
+
+	data := url.Values{}
+	if docalcs {
+		data.Set("docalcs", "true")
+	}
+	if len(transactionIDs) > 0 {
+		data.Set("txid", strings.Join(transactionIDs, ","))
+	}
+
+	if err := client.request("OpenPositions", true, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+// TradeBalance - Get the margin account summary in asset, such as "ZUSD", or in Kraken's default if empty
+func (client *Client) TradeBalance(asset string) (TradeBalance, error) {
+	var response TradeBalance
+
+	data := url.Values{}
+	if asset != "" {
+		data.Set("asset", asset)
+	}
+
+	if err := client.request("TradeBalance", true, data, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
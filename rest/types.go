@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// UnixTime parses timestamps that are sent either as JSON number or as JSON string.
+type UnixTime time.Time
+
+func (unixTime *UnixTime) UnmarshalJSON(bytes []byte) error {
+	if string(bytes) == "null" {
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(bytes, &number); err != nil {
+		return err
+	}
+
+	unixTimeFloat, err := number.Float64()
+	if err != nil {
+		return err
+	}
+
+	sec, dec := math.Modf(unixTimeFloat)
+	*unixTime = UnixTime(time.Unix(int64(sec), int64(dec*(1e9))))
+	return nil
+}
+
+type Float64String float64
+
+func (float64string *Float64String) UnmarshalJSON(bytes []byte) error {
+	if string(bytes) == "null" {
+		return nil
+	}
+
+	// profits such as the net of OpenPositions are sent with a sign
+	if strings.HasPrefix(string(bytes), `"+`) {
+		bytes = append([]byte{'"'}, bytes[2:]...)
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(bytes, &number); err != nil {
+		return err
+	}
+
+	float, err := number.Float64()
+	if err != nil {
+		return err
+	}
+
+	*float64string = Float64String(float)
+	return nil
+}
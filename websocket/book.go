@@ -2,17 +2,27 @@ package websocket
 
 import (
 	"fmt"
+	"hash/crc32"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func (book *Book) PrintTop(n int) {
 	fmt.Printf("Asks:\n")
-	for _, ask := range book.Data.Asks[:n] {
+	for index, ask := range book.Data.Asks {
+		if index == n {
+			break
+		}
 		fmt.Printf("%11.5f %11.5f\n", ask.Price, ask.Volume)
 	}
 
 	fmt.Printf("Bids:\n")
-	for _, bid := range book.Data.Bids[:n] {
+	for index, bid := range book.Data.Bids {
+		if index == n {
+			break
+		}
 		fmt.Printf("%11.5f %11.5f\n", bid.Price, bid.Volume)
 	}
 }
@@ -53,7 +63,32 @@ func updateSide(side []PriceLevel, updates []PriceLevel) []PriceLevel {
 	return side
 }
 
+func latestTimestamp(latest time.Time, levels []PriceLevel) time.Time {
+	for _, level := range levels {
+		if timestamp := time.Time(level.Timestamp); timestamp.After(latest) {
+			latest = timestamp
+		}
+	}
+	return latest
+}
+
+// LastUpdate returns the most recent exchange timestamp of the snapshot or any update applied since.
+// The timestamp of the last update of each level is kept in PriceLevel.Timestamp.
+func (book *Book) LastUpdate() time.Time {
+	latest := latestTimestamp(book.lastUpdate, book.Data.Asks)
+	return latestTimestamp(latest, book.Data.Bids)
+}
+
+func (update *BookUpdate) LastUpdate() time.Time {
+	latest := latestTimestamp(time.Time{}, update.Data.Asks)
+	return latestTimestamp(latest, update.Data.Bids)
+}
+
 func (book *Book) Update(update BookUpdate) {
+	if timestamp := update.LastUpdate(); timestamp.After(book.lastUpdate) {
+		book.lastUpdate = timestamp
+	}
+
 	book.Data.Asks = updateSide(book.Data.Asks, update.Data.Asks)
 	book.Data.Bids = updateSide(book.Data.Bids, update.Data.Bids)
 
@@ -65,3 +100,52 @@ func (book *Book) Update(update BookUpdate) {
 		return book.Data.Bids[i].Price > book.Data.Bids[j].Price
 	})
 }
+
+func (book *Book) Truncate(depth int) {
+	if depth <= 0 {
+		return
+	}
+
+	if len(book.Data.Asks) > depth {
+		book.Data.Asks = book.Data.Asks[:depth]
+	}
+
+	if len(book.Data.Bids) > depth {
+		book.Data.Bids = book.Data.Bids[:depth]
+	}
+}
+
+func (book *Book) Copy() Book {
+	copied := *book
+	copied.Data.Asks = append([]PriceLevel(nil), book.Data.Asks...)
+	copied.Data.Bids = append([]PriceLevel(nil), book.Data.Bids...)
+	return copied
+}
+
+// ChecksumField formats a price or volume as Kraken does for book checksums, without dot and leading zeros.
+func ChecksumField(value float64, decimals int) string {
+	formatted := strings.Replace(strconv.FormatFloat(value, 'f', decimals, 64), ".", "", 1)
+	return strings.TrimLeft(formatted, "0")
+}
+
+func (book *Book) checksumInput(priceDecimals, volumeDecimals int) string {
+	var builder strings.Builder
+
+	for _, side := range [][]PriceLevel{book.Data.Asks, book.Data.Bids} {
+		for index, level := range side {
+			if index == 10 {
+				break
+			}
+			builder.WriteString(ChecksumField(float64(level.Price), priceDecimals))
+			builder.WriteString(ChecksumField(float64(level.Volume), volumeDecimals))
+		}
+	}
+
+	return builder.String()
+}
+
+// Checksum computes the CRC32 checksum Kraken sends along with book updates.
+// It expects a sorted book and the price and volume decimals of the pair.
+func (book *Book) Checksum(priceDecimals, volumeDecimals int) uint32 {
+	return crc32.ChecksumIEEE([]byte(book.checksumInput(priceDecimals, volumeDecimals)))
+}
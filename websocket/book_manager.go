@@ -0,0 +1,313 @@
+package websocket
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type sender interface {
+	Send(rawMessage interface{}) error
+}
+
+type BookOutOfSyncError struct {
+	Pair   string
+	Reason string
+}
+
+func (err BookOutOfSyncError) Error() string {
+	return fmt.Sprintf("book %s out of sync: %s", err.Pair, err.Reason)
+}
+
+type bookPrecision struct {
+	price  int
+	volume int
+}
+
+type managedBook struct {
+	book       Book
+	synced     bool
+	receivedAt time.Time
+	// resyncing is set once a resubscribe was sent, updates are dropped until the new snapshot arrives
+	resyncing bool
+}
+
+// BookManager keeps the books of many pairs up to date from messages received through Listen().
+// It is safe to take snapshots from other goroutines while messages are being handled.
+type BookManager struct {
+	mutex      sync.RWMutex
+	sender     sender
+	depth      int
+	books      map[string]*managedBook
+	channels   map[int64]string
+	precision  map[string]bookPrecision
+	latency    *LatencyHistogram
+	staleAfter time.Duration
+	now        func() time.Time
+}
+
+// NewBookManager creates a BookManager that resubscribes through client when a book gets out of sync.
+// The client may be nil, in which case out of sync books are only reported.
+func NewBookManager(client *Client, depth int) *BookManager {
+	manager := &BookManager{
+		depth:     depth,
+		books:     make(map[string]*managedBook),
+		channels:  make(map[int64]string),
+		precision: make(map[string]bookPrecision),
+		latency:   NewLatencyHistogram(1000),
+		now:       time.Now,
+	}
+
+	if client != nil {
+		manager.sender = client
+	}
+
+	return manager
+}
+
+// SetPrecision enables checksum validation of updates for pair.
+func (manager *BookManager) SetPrecision(pair string, priceDecimals, volumeDecimals int) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.precision[pair] = bookPrecision{price: priceDecimals, volume: volumeDecimals}
+}
+
+// SetStaleAfter sets the window after which a book that has not received any update is considered stale.
+// A zero window disables stale detection.
+func (manager *BookManager) SetStaleAfter(window time.Duration) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.staleAfter = window
+}
+
+// Latency returns the histogram of the time between the exchange timestamps of received levels and their arrival.
+func (manager *BookManager) Latency() *LatencyHistogram {
+	return manager.latency
+}
+
+func (manager *BookManager) Subscribe(pairs []string) error {
+	if manager.sender == nil {
+		return fmt.Errorf("book manager has no client")
+	}
+
+	return manager.sender.Send(Subscribe{
+		Pair:         pairs,
+		Subscription: Subscription{Name: "book", Depth: manager.depth},
+	})
+}
+
+// Handle processes a message received from Listen(). Messages other than Book and BookUpdate are ignored.
+func (manager *BookManager) Handle(rawMessage interface{}) error {
+	return manager.HandleAt(rawMessage, manager.now())
+}
+
+// HandleAt is like Handle, but with the time the message was received for latency measurement.
+func (manager *BookManager) HandleAt(rawMessage interface{}, receivedAt time.Time) error {
+	switch message := rawMessage.(type) {
+	case Book:
+		// levels of a snapshot can be old, so only updates are used for latency
+		manager.handleSnapshot(message, receivedAt)
+		return nil
+	case BookUpdate:
+		manager.observeLatency(message.LastUpdate(), receivedAt)
+		return manager.handleUpdate(message, receivedAt)
+	default:
+		return nil
+	}
+}
+
+func (manager *BookManager) observeLatency(exchangeTime time.Time, receivedAt time.Time) {
+	if !exchangeTime.IsZero() {
+		manager.latency.Observe(receivedAt.Sub(exchangeTime))
+	}
+}
+
+func (manager *BookManager) handleSnapshot(snapshot Book, receivedAt time.Time) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	book := snapshot.Copy()
+	book.Truncate(channelDepth(book.ChannelName))
+
+	manager.books[book.Pair] = &managedBook{book: book, synced: true, receivedAt: receivedAt}
+	manager.channels[book.ChannelID] = book.Pair
+}
+
+func (manager *BookManager) handleUpdate(update BookUpdate, receivedAt time.Time) error {
+	manager.mutex.Lock()
+
+	pair := update.Pair
+	if pair == "" {
+		pair = manager.channels[update.ChannelID]
+	}
+
+	managed, ok := manager.books[pair]
+	if ok && managed.resyncing {
+		manager.mutex.Unlock()
+		return nil
+	}
+
+	var reason string
+
+	switch {
+	case !ok || !managed.synced:
+		reason = "update received before snapshot"
+	case managed.book.ChannelID != update.ChannelID:
+		reason = fmt.Sprintf("update for channel %d, expected %d", update.ChannelID, managed.book.ChannelID)
+	default:
+		managed.book.Update(update)
+		managed.receivedAt = receivedAt
+		managed.book.Truncate(channelDepth(update.ChannelName))
+
+		precision, hasPrecision := manager.precision[pair]
+		if hasPrecision && update.Data.Checksum != 0 {
+			checksum := managed.book.Checksum(precision.price, precision.volume)
+			if int64(checksum) != int64(update.Data.Checksum) {
+				reason = fmt.Sprintf("checksum %d does not match expected %d", checksum, update.Data.Checksum)
+			}
+		}
+	}
+
+	if reason == "" {
+		manager.mutex.Unlock()
+		return nil
+	}
+
+	if !ok && pair != "" {
+		managed = &managedBook{}
+		manager.books[pair] = managed
+	}
+
+	if managed != nil {
+		managed.synced = false
+		managed.resyncing = true
+	}
+	manager.mutex.Unlock()
+
+	err := manager.resync(pair, reason)
+
+	// retry with the next update if resubscribing failed
+	if _, outOfSync := err.(BookOutOfSyncError); !outOfSync && managed != nil {
+		manager.mutex.Lock()
+		managed.resyncing = false
+		manager.mutex.Unlock()
+	}
+	return err
+}
+
+func (manager *BookManager) resync(pair string, reason string) error {
+	outOfSync := BookOutOfSyncError{Pair: pair, Reason: reason}
+
+	if manager.sender == nil || pair == "" {
+		return outOfSync
+	}
+
+	unsubscribe := Unsubscribe{
+		Pair:         []string{pair},
+		Subscription: Subscription{Name: "book", Depth: manager.depth},
+	}
+
+	if err := manager.sender.Send(unsubscribe); err != nil {
+		return fmt.Errorf("%s, unsubscribing failed: %w", outOfSync.Error(), err)
+	}
+
+	if err := manager.Subscribe([]string{pair}); err != nil {
+		return fmt.Errorf("%s, resubscribing failed: %w", outOfSync.Error(), err)
+	}
+
+	return outOfSync
+}
+
+// Snapshot returns a copy of the current book of pair, which the caller is free to modify.
+func (manager *BookManager) Snapshot(pair string) (Book, bool) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	managed, ok := manager.books[pair]
+	if !ok || !managed.synced {
+		return Book{}, false
+	}
+
+	return managed.book.Copy(), true
+}
+
+// LastUpdate returns the exchange timestamp and local receive time of the last change to the book of pair.
+func (manager *BookManager) LastUpdate(pair string) (exchangeTime time.Time, receivedAt time.Time, ok bool) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	managed, ok := manager.books[pair]
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return managed.book.LastUpdate(), managed.receivedAt, true
+}
+
+// IsStale returns whether the book of pair is unknown, out of sync or has not been updated within the stale window.
+func (manager *BookManager) IsStale(pair string) bool {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	managed, ok := manager.books[pair]
+	if !ok || !managed.synced {
+		return true
+	}
+
+	return manager.isStale(managed, manager.now())
+}
+
+func (manager *BookManager) isStale(managed *managedBook, now time.Time) bool {
+	if manager.staleAfter == 0 {
+		return false
+	}
+	return now.Sub(managed.receivedAt) > manager.staleAfter
+}
+
+func (manager *BookManager) StalePairs() []string {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	now := manager.now()
+
+	var pairs []string
+	for pair, managed := range manager.books {
+		if !managed.synced || manager.isStale(managed, now) {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	sort.Strings(pairs)
+	return pairs
+}
+
+func (manager *BookManager) Pairs() []string {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	var pairs []string
+	for pair := range manager.books {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Strings(pairs)
+	return pairs
+}
+
+func channelDepth(channelName string) int {
+	split := strings.Split(channelName, "-")
+	if len(split) != 2 {
+		return 0
+	}
+
+	depth, err := strconv.Atoi(split[1])
+	if err != nil {
+		return 0
+	}
+	return depth
+}
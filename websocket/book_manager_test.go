@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSender struct {
+	sent []interface{}
+}
+
+func (sender *fakeSender) Send(rawMessage interface{}) error {
+	sender.sent = append(sender.sent, rawMessage)
+	return nil
+}
+
+func newTestBookManager() (*BookManager, *fakeSender) {
+	sender := &fakeSender{}
+	manager := NewBookManager(nil, 10)
+	manager.sender = sender
+	return manager, sender
+}
+
+var testBookSnapshot = Book{
+	ChannelID:   42,
+	ChannelName: "book-10",
+	Pair:        "XBT/EUR",
+	Data: BookData{
+		Asks: []PriceLevel{{Price: 0.05005, Volume: 0.000005}, {Price: 0.0501, Volume: 0.000005}},
+		Bids: []PriceLevel{{Price: 0.05, Volume: 0.000005}, {Price: 0.04995, Volume: 0.000005}},
+	},
+}
+
+func TestBookManager(t *testing.T) {
+
+	t.Run("snapshotThenUpdate", func(t *testing.T) {
+		manager, sender := newTestBookManager()
+
+		assert.Nil(t, manager.Handle(testBookSnapshot))
+		assert.Nil(t, manager.Handle(BookUpdate{
+			ChannelID:   42,
+			ChannelName: "book-10",
+			Pair:        "XBT/EUR",
+			Data:        BookUpdateData{Asks: []PriceLevel{{Price: 0.05005, Volume: 0}}},
+		}))
+
+		book, ok := manager.Snapshot("XBT/EUR")
+		assert.True(t, ok)
+		assert.Equal(t, []PriceLevel{{Price: 0.0501, Volume: 0.000005}}, book.Data.Asks)
+		assert.Equal(t, []string{"XBT/EUR"}, manager.Pairs())
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("updateBeforeSnapshot", func(t *testing.T) {
+		manager, sender := newTestBookManager()
+
+		err := manager.Handle(BookUpdate{ChannelID: 42, ChannelName: "book-10", Pair: "XBT/EUR"})
+		assert.Equal(t, BookOutOfSyncError{Pair: "XBT/EUR", Reason: "update received before snapshot"}, err)
+
+		assert.Equal(t, []interface{}{
+			Unsubscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "book", Depth: 10}},
+			Subscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "book", Depth: 10}},
+		}, sender.sent)
+
+		assert.Nil(t, manager.Handle(BookUpdate{ChannelID: 42, ChannelName: "book-10", Pair: "XBT/EUR"}))
+		assert.Len(t, sender.sent, 2)
+
+		assert.Nil(t, manager.Handle(testBookSnapshot))
+		_, ok := manager.Snapshot("XBT/EUR")
+		assert.True(t, ok)
+	})
+
+	t.Run("checksumMismatch", func(t *testing.T) {
+		manager, sender := newTestBookManager()
+		manager.SetPrecision("XBT/EUR", 5, 8)
+
+		assert.Nil(t, manager.Handle(testBookSnapshot))
+
+		update := BookUpdate{
+			ChannelID:   42,
+			ChannelName: "book-10",
+			Pair:        "XBT/EUR",
+			Data:        BookUpdateData{Bids: []PriceLevel{{Price: 0.05, Volume: 1}}},
+		}
+
+		expected := testBookSnapshot.Copy()
+		expected.Update(update)
+		update.Data.Checksum = Int64String(expected.Checksum(5, 8))
+
+		assert.Nil(t, manager.Handle(update))
+
+		update.Data.Checksum++
+		err := manager.Handle(update)
+		assert.IsType(t, BookOutOfSyncError{}, err)
+		assert.Len(t, sender.sent, 2)
+
+		// updates in flight are dropped without resubscribing again
+		assert.Nil(t, manager.Handle(update))
+		assert.Nil(t, manager.Handle(update))
+		assert.Len(t, sender.sent, 2)
+
+		_, ok := manager.Snapshot("XBT/EUR")
+		assert.False(t, ok)
+
+		assert.Nil(t, manager.Handle(testBookSnapshot))
+		_, ok = manager.Snapshot("XBT/EUR")
+		assert.True(t, ok)
+	})
+
+	t.Run("snapshotIsCopy", func(t *testing.T) {
+		manager, _ := newTestBookManager()
+		assert.Nil(t, manager.Handle(testBookSnapshot))
+
+		book, _ := manager.Snapshot("XBT/EUR")
+		book.Data.Asks[0].Volume = 123
+
+		book, _ = manager.Snapshot("XBT/EUR")
+		assert.Equal(t, Float64String(0.000005), book.Data.Asks[0].Volume)
+	})
+}
+
+func TestBookManagerStale(t *testing.T) {
+	manager, _ := newTestBookManager()
+	manager.SetStaleAfter(time.Second)
+
+	now := time.Unix(1000, 0)
+	manager.now = func() time.Time { return now }
+
+	assert.True(t, manager.IsStale("XBT/EUR"))
+
+	snapshot := testBookSnapshot.Copy()
+	snapshot.Data.Asks[0].Timestamp = UnixTime(now.Add(-20 * time.Millisecond))
+
+	assert.Nil(t, manager.Handle(snapshot))
+	assert.False(t, manager.IsStale("XBT/EUR"))
+	assert.Empty(t, manager.StalePairs())
+
+	// snapshot levels can be old, they don't count towards latency
+	assert.Equal(t, 0, manager.Latency().Count())
+
+	exchangeTime, receivedAt, ok := manager.LastUpdate("XBT/EUR")
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-20*time.Millisecond), exchangeTime)
+	assert.Equal(t, now, receivedAt)
+
+	now = now.Add(2 * time.Second)
+	assert.True(t, manager.IsStale("XBT/EUR"))
+	assert.Equal(t, []string{"XBT/EUR"}, manager.StalePairs())
+
+	assert.Nil(t, manager.Handle(BookUpdate{ChannelID: 42, ChannelName: "book-10", Pair: "XBT/EUR", Data: BookUpdateData{
+		Bids: []PriceLevel{{Price: 0.04, Volume: 1, Timestamp: UnixTime(now.Add(-30 * time.Millisecond))}},
+	}}))
+	assert.False(t, manager.IsStale("XBT/EUR"))
+	assert.Equal(t, 30*time.Millisecond, manager.Latency().Percentile(0.5))
+}
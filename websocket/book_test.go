@@ -1,7 +1,9 @@
 package websocket
 
 import (
+	"hash/crc32"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -75,3 +77,48 @@ func TestBookUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestBookChecksum(t *testing.T) {
+	book := Book{Data: BookData{
+		Asks: []PriceLevel{
+			{Price: 0.05005, Volume: 0.00000500},
+			{Price: 0.05010, Volume: 0.00000500},
+		},
+		Bids: []PriceLevel{
+			{Price: 0.05000, Volume: 0.00000500},
+			{Price: 0.04995, Volume: 0.00000500},
+		},
+	}}
+
+	assert.Equal(t, "5005500501050050005004995500", book.checksumInput(5, 8))
+	assert.Equal(t, crc32.ChecksumIEEE([]byte("5005500501050050005004995500")), book.Checksum(5, 8))
+}
+
+func TestBookTruncate(t *testing.T) {
+	book := Book{Data: BookData{
+		Asks: []PriceLevel{{Price: 1}, {Price: 2}, {Price: 3}},
+		Bids: []PriceLevel{{Price: 1}},
+	}}
+
+	book.Truncate(2)
+
+	assert.Equal(t, Book{Data: BookData{
+		Asks: []PriceLevel{{Price: 1}, {Price: 2}},
+		Bids: []PriceLevel{{Price: 1}},
+	}}, book)
+}
+
+func TestBookLastUpdate(t *testing.T) {
+	book := Book{Data: BookData{
+		Asks: []PriceLevel{{Price: 2, Volume: 1, Timestamp: UnixTime(time.Unix(100, 0))}},
+		Bids: []PriceLevel{{Price: 1, Volume: 1, Timestamp: UnixTime(time.Unix(200, 0))}},
+	}}
+
+	assert.Equal(t, time.Unix(200, 0), book.LastUpdate())
+
+	book.Update(BookUpdate{Data: BookUpdateData{
+		Asks: []PriceLevel{{Price: 2, Volume: 0, Timestamp: UnixTime(time.Unix(300, 0))}},
+	}})
+
+	assert.Equal(t, time.Unix(300, 0), book.LastUpdate())
+}
@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conn is a websocket connection to Kraken. It is used by Client and by the v2 client in package websocketv2.
+type Conn struct {
+	name       string
+	ws         *websocket.Conn
+	writeMutex sync.Mutex
+	verbose    bool
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+// Dial connects to url, name is used in logs and in the DisconnectError when the connection closes.
+func Dial(url string, name string) (*Conn, error) {
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s websocket: %w", name, err)
+	}
+
+	return &Conn{name: name, ws: ws, closed: make(chan struct{})}, nil
+}
+
+func (conn *Conn) Name() string {
+	return conn.name
+}
+
+func (conn *Conn) SetVerbose(verbose bool) {
+	conn.verbose = verbose
+}
+
+// WriteJSON sends message, it is safe to call from multiple go-routines.
+func (conn *Conn) WriteJSON(message interface{}) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if conn.verbose {
+		log.Printf("SEND %7s: %s", conn.name, string(bytes))
+	}
+
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	return conn.ws.WriteMessage(websocket.TextMessage, bytes)
+}
+
+// ReadLoop passes received text frames to handleFrame until the connection fails.
+// It then passes a DisconnectError to handleError and returns.
+func (conn *Conn) ReadLoop(handleFrame func(frame []byte), handleError func(err error)) {
+	log.Printf("listening on %s websocket", conn.name)
+	defer conn.markClosed()
+
+	for {
+		messageType, message, err := conn.ws.ReadMessage()
+
+		if err != nil {
+			// reading after an error is not possible, so any error ends the connection
+			log.Printf("RECV %7s: disconnect %s", conn.name, err.Error())
+			handleError(DisconnectError{error: err, PublicPrivate: conn.name})
+			return
+		}
+
+		if messageType != websocket.TextMessage {
+			handleError(errBinaryMessage)
+			continue
+		}
+
+		if conn.verbose {
+			log.Printf("RECV %7s: %s", conn.name, string(message))
+		}
+
+		handleFrame(message)
+	}
+}
+
+// KeepAlive sends the message returned by ping every interval until the connection is closed.
+func (conn *Conn) KeepAlive(interval time.Duration, ping func() interface{}, handleError func(err error)) {
+	conn.keepAlive(interval, func() error {
+		return conn.WriteJSON(ping())
+	}, handleError)
+}
+
+// KeepAlivePing sends a websocket ping frame every interval until the connection is closed.
+func (conn *Conn) KeepAlivePing(interval time.Duration, handleError func(err error)) {
+	conn.keepAlive(interval, func() error {
+		conn.writeMutex.Lock()
+		defer conn.writeMutex.Unlock()
+
+		return conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval))
+	}, handleError)
+}
+
+func (conn *Conn) keepAlive(interval time.Duration, write func() error, handleError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.closed:
+			return
+		case <-ticker.C:
+			if err := write(); err != nil {
+				handleError(fmt.Errorf("keep alive failed: %w", err))
+				return
+			}
+		}
+	}
+}
+
+func (conn *Conn) markClosed() {
+	conn.closeOnce.Do(func() {
+		close(conn.closed)
+	})
+}
+
+func (conn *Conn) Close() error {
+	conn.markClosed()
+	return conn.ws.Close()
+}
@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"errors"
+	"reflect"
+)
+
+type handler struct {
+	pairs  map[string]struct{}
+	handle func(message interface{})
+}
+
+func (handler handler) matches(pair string) bool {
+	if handler.pairs == nil {
+		return true
+	}
+
+	_, ok := handler.pairs[pair]
+	return ok
+}
+
+func (client *Client) addHandler(messageType reflect.Type, pairs []string, handle func(message interface{})) {
+	added := handler{handle: handle}
+
+	if len(pairs) != 0 {
+		added.pairs = make(map[string]struct{})
+		for _, pair := range pairs {
+			added.pairs[pair] = struct{}{}
+		}
+	}
+
+	client.handlersMutex.Lock()
+	defer client.handlersMutex.Unlock()
+
+	if client.handlers == nil {
+		client.handlers = make(map[reflect.Type][]handler)
+	}
+	client.handlers[messageType] = append(client.handlers[messageType], added)
+}
+
+// dispatchToHandlers calls the handlers registered for the type of message from the listener go-routine.
+func (client *Client) dispatchToHandlers(message interface{}) bool {
+	_, pair, _ := messageChannel(message)
+
+	client.handlersMutex.RLock()
+	handlers := client.handlers[reflect.TypeOf(message)]
+	client.handlersMutex.RUnlock()
+
+	handled := false
+	for _, handler := range handlers {
+		if handler.matches(pair) {
+			handler.handle(message)
+			handled = true
+		}
+	}
+
+	return handled
+}
+
+// dispatchErrorToHandlers calls OnDisconnect handlers for disconnects and OnError handlers for other errors.
+func (client *Client) dispatchErrorToHandlers(err error) bool {
+	var disconnectErr DisconnectError
+	if errors.As(err, &disconnectErr) && client.dispatchToHandlers(disconnectErr) {
+		return true
+	}
+
+	client.handlersMutex.RLock()
+	handlers := client.handlers[reflect.TypeOf((*error)(nil)).Elem()]
+	client.handlersMutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler.handle(err)
+	}
+
+	return len(handlers) != 0
+}
+
+// OnTicker registers a handler for Ticker messages of pairs, or of all pairs if none are passed.
+// Handlers are called from the listener go-routines and messages they handle are not sent through Listen().
+func (client *Client) OnTicker(handle func(Ticker), pairs ...string) {
+	client.addHandler(reflect.TypeOf(Ticker{}), pairs, func(message interface{}) {
+		handle(message.(Ticker))
+	})
+}
+
+func (client *Client) OnOHLC(handle func(OHLC), pairs ...string) {
+	client.addHandler(reflect.TypeOf(OHLC{}), pairs, func(message interface{}) {
+		handle(message.(OHLC))
+	})
+}
+
+func (client *Client) OnTrade(handle func(Trade), pairs ...string) {
+	client.addHandler(reflect.TypeOf(Trade{}), pairs, func(message interface{}) {
+		handle(message.(Trade))
+	})
+}
+
+func (client *Client) OnSpread(handle func(Spread), pairs ...string) {
+	client.addHandler(reflect.TypeOf(Spread{}), pairs, func(message interface{}) {
+		handle(message.(Spread))
+	})
+}
+
+func (client *Client) OnBook(handle func(Book), pairs ...string) {
+	client.addHandler(reflect.TypeOf(Book{}), pairs, func(message interface{}) {
+		handle(message.(Book))
+	})
+}
+
+func (client *Client) OnBookUpdate(handle func(BookUpdate), pairs ...string) {
+	client.addHandler(reflect.TypeOf(BookUpdate{}), pairs, func(message interface{}) {
+		handle(message.(BookUpdate))
+	})
+}
+
+func (client *Client) OnOwnTrades(handle func(OwnTrades)) {
+	client.addHandler(reflect.TypeOf(OwnTrades{}), nil, func(message interface{}) {
+		handle(message.(OwnTrades))
+	})
+}
+
+func (client *Client) OnOpenOrders(handle func(OpenOrders)) {
+	client.addHandler(reflect.TypeOf(OpenOrders{}), nil, func(message interface{}) {
+		handle(message.(OpenOrders))
+	})
+}
+
+func (client *Client) OnSubscriptionStatus(handle func(SubscriptionStatus)) {
+	client.addHandler(reflect.TypeOf(SubscriptionStatus{}), nil, func(message interface{}) {
+		handle(message.(SubscriptionStatus))
+	})
+}
+
+func (client *Client) OnSequenceGap(handle func(SequenceGap)) {
+	client.addHandler(reflect.TypeOf(SequenceGap{}), nil, func(message interface{}) {
+		handle(message.(SequenceGap))
+	})
+}
+
+// OnError registers a handler for errors, errors are then no longer sent through Errors() or Listen().
+func (client *Client) OnError(handle func(error)) {
+	client.addHandler(reflect.TypeOf((*error)(nil)).Elem(), nil, func(message interface{}) {
+		handle(message.(error))
+	})
+}
+
+// OnDisconnect registers a handler for a closed connection, which is then no longer passed to OnError handlers.
+func (client *Client) OnDisconnect(handle func(DisconnectError)) {
+	client.addHandler(reflect.TypeOf(DisconnectError{}), nil, func(message interface{}) {
+		handle(message.(DisconnectError))
+	})
+}
@@ -0,0 +1,58 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlers(t *testing.T) {
+	client := newClient()
+
+	var tickers []Ticker
+	client.OnTicker(func(ticker Ticker) {
+		tickers = append(tickers, ticker)
+	}, "XBT/EUR")
+
+	var bookUpdates []BookUpdate
+	client.OnBookUpdate(func(bookUpdate BookUpdate) {
+		bookUpdates = append(bookUpdates, bookUpdate)
+	})
+
+	client.process(Ticker{ChannelName: "ticker", Pair: "XBT/EUR"})
+	client.process(BookUpdate{ChannelName: "book-10", Pair: "XRP/EUR"})
+
+	assert.Equal(t, []Ticker{{ChannelName: "ticker", Pair: "XBT/EUR"}}, tickers)
+	assert.Equal(t, []BookUpdate{{ChannelName: "book-10", Pair: "XRP/EUR"}}, bookUpdates)
+
+	// not matching the pair filter, so it goes to Listen()
+	go client.process(Ticker{ChannelName: "ticker", Pair: "XRP/EUR"})
+	assert.Equal(t, Ticker{ChannelName: "ticker", Pair: "XRP/EUR"}, <-client.Listen())
+}
+
+func TestErrorHandlers(t *testing.T) {
+	client := newClient()
+
+	var errs []error
+	client.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	client.deliverError(errBinaryMessage)
+	assert.Equal(t, []error{errBinaryMessage}, errs)
+
+	disconnect := DisconnectError{PublicPrivate: "public", error: errors.New("closed")}
+
+	client.deliverError(disconnect)
+	assert.Equal(t, []error{errBinaryMessage, disconnect}, errs)
+
+	var disconnects []DisconnectError
+	client.OnDisconnect(func(err DisconnectError) {
+		disconnects = append(disconnects, err)
+	})
+
+	client.deliverError(disconnect)
+	assert.Equal(t, []DisconnectError{disconnect}, disconnects)
+	assert.Len(t, errs, 2)
+}
@@ -0,0 +1,126 @@
+package websocket
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var defaultLatencyBuckets = []time.Duration{
+	1 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+}
+
+type LatencyBucket struct {
+	// UpperBound is inclusive, the last bucket has no upper bound and holds a zero UpperBound
+	UpperBound time.Duration
+	Count      int
+}
+
+// LatencyHistogram keeps the last samples of a latency measurement.
+type LatencyHistogram struct {
+	mutex   sync.Mutex
+	bounds  []time.Duration
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// NewLatencyHistogram creates a histogram over the last windowSize samples.
+// When no bounds are passed a default set of buckets between 1ms and 1s is used.
+func NewLatencyHistogram(windowSize int, bounds ...time.Duration) *LatencyHistogram {
+	if len(bounds) == 0 {
+		bounds = defaultLatencyBuckets
+	}
+
+	sortedBounds := append([]time.Duration(nil), bounds...)
+	sort.Slice(sortedBounds, func(i, j int) bool {
+		return sortedBounds[i] < sortedBounds[j]
+	})
+
+	return &LatencyHistogram{
+		bounds:  sortedBounds,
+		samples: make([]time.Duration, windowSize),
+	}
+}
+
+func (histogram *LatencyHistogram) Observe(latency time.Duration) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	if len(histogram.samples) == 0 {
+		return
+	}
+
+	histogram.samples[histogram.next] = latency
+	histogram.next++
+
+	if histogram.next == len(histogram.samples) {
+		histogram.next = 0
+		histogram.full = true
+	}
+}
+
+func (histogram *LatencyHistogram) window() []time.Duration {
+	if histogram.full {
+		return histogram.samples
+	}
+	return histogram.samples[:histogram.next]
+}
+
+func (histogram *LatencyHistogram) Count() int {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	return len(histogram.window())
+}
+
+func (histogram *LatencyHistogram) Buckets() []LatencyBucket {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	buckets := make([]LatencyBucket, len(histogram.bounds)+1)
+	for index, bound := range histogram.bounds {
+		buckets[index].UpperBound = bound
+	}
+
+	for _, sample := range histogram.window() {
+		index := sort.Search(len(histogram.bounds), func(i int) bool {
+			return sample <= histogram.bounds[i]
+		})
+		buckets[index].Count++
+	}
+
+	return buckets
+}
+
+// Percentile returns the latency below which the fraction p (between 0 and 1) of the samples fall.
+func (histogram *LatencyHistogram) Percentile(p float64) time.Duration {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	sorted := append([]time.Duration(nil), histogram.window()...)
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	index := int(p * float64(len(sorted)-1))
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
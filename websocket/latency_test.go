@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+
+	t.Run("empty", func(t *testing.T) {
+		histogram := NewLatencyHistogram(3)
+		assert.Equal(t, 0, histogram.Count())
+		assert.Equal(t, time.Duration(0), histogram.Percentile(0.5))
+	})
+
+	t.Run("buckets", func(t *testing.T) {
+		histogram := NewLatencyHistogram(10, 100*time.Millisecond, 10*time.Millisecond)
+
+		histogram.Observe(5 * time.Millisecond)
+		histogram.Observe(10 * time.Millisecond)
+		histogram.Observe(50 * time.Millisecond)
+		histogram.Observe(time.Second)
+
+		assert.Equal(t, []LatencyBucket{
+			{UpperBound: 10 * time.Millisecond, Count: 2},
+			{UpperBound: 100 * time.Millisecond, Count: 1},
+			{Count: 1},
+		}, histogram.Buckets())
+	})
+
+	t.Run("rollingWindow", func(t *testing.T) {
+		histogram := NewLatencyHistogram(3)
+
+		for _, latency := range []time.Duration{100, 1, 2, 3} {
+			histogram.Observe(latency * time.Millisecond)
+		}
+
+		assert.Equal(t, 3, histogram.Count())
+		assert.Equal(t, 1*time.Millisecond, histogram.Percentile(0))
+		assert.Equal(t, 2*time.Millisecond, histogram.Percentile(0.5))
+		assert.Equal(t, 3*time.Millisecond, histogram.Percentile(1))
+	})
+}
@@ -238,6 +238,9 @@ type Book struct {
 	Data        BookData
 	ChannelName string
 	Pair        string
+
+	// exchange timestamp of the most recent update, which may have removed its level
+	lastUpdate time.Time
 }
 
 type BookData struct {
@@ -253,8 +256,9 @@ type BookUpdate struct {
 }
 
 type BookUpdateData struct {
-	Asks []PriceLevel `json:"a"`
-	Bids []PriceLevel `json:"b"`
+	Asks     []PriceLevel `json:"a"`
+	Bids     []PriceLevel `json:"b"`
+	Checksum Int64String  `json:"c"`
 }
 
 type PriceLevel struct {
@@ -270,9 +274,12 @@ type Error struct {
 }
 
 type OwnTrades struct {
-	Trades      []map[string]OwnTrade
+	Trades      OwnTradeList
 	ChannelName string
 	Sequence    Sequence
+
+	// Snapshot is set by the Client on the first message after subscribing, which contains recent trades
+	Snapshot bool
 }
 
 type OwnTrade struct {
@@ -297,6 +304,9 @@ type OpenOrders struct {
 	Orders      map[string]OpenOrder
 	ChannelName string
 	Sequence    Sequence
+
+	// JSON keys present per order, nil when not unmarshalled from JSON
+	fields map[string][]string
 }
 
 type OpenOrder struct {
@@ -353,6 +363,15 @@ type AddOrder struct {
 	TradingAgreement string `json:"trading_agreement"`
 }
 
+type AddOrderStatus struct {
+	Event         string `json:"event"`
+	ReqID         int    `json:"reqid"`
+	Status        string `json:"status"`
+	TransactionID string `json:"txid"`
+	Description   string `json:"descr"`
+	ErrorMessage  string `json:"errorMessage"`
+}
+
 type CancelOrder struct {
 	Event         string   `json:"event"`
 	Token         string   `json:"token"`
@@ -371,3 +390,11 @@ type CancelAll struct {
 	Event string `json:"event"`
 	Token string `json:"token"`
 }
+
+type CancelAllStatus struct {
+	Event        string `json:"event"`
+	ReqID        int    `json:"reqid"`
+	Status       string `json:"status"`
+	Count        int    `json:"count"`
+	ErrorMessage string `json:"errorMessage"`
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -185,23 +186,38 @@ func (bookUpdate *BookUpdate) UnmarshalJSON(bytes []byte) error {
 	}
 
 	bookUpdate.Data.Bids = separateBids.Bids
+	bookUpdate.Data.Checksum = separateBids.Checksum
 	return nil
 }
 
 func (ownTrades *OwnTrades) UnmarshalJSON(bytes []byte) error {
 
+	// every trade is sent as a separate map of trade ID to trade
+	var tradeMaps []map[string]OwnTrade
+
 	slice := []interface{}{
-		&ownTrades.Trades,
+		&tradeMaps,
 		&ownTrades.ChannelName,
 		&ownTrades.Sequence,
 	}
-	return json.Unmarshal(bytes, &slice)
+	if err := json.Unmarshal(bytes, &slice); err != nil {
+		return err
+	}
+
+	ownTrades.Trades = nil
+	for _, tradeMap := range tradeMaps {
+		for tradeID, trade := range tradeMap {
+			ownTrades.Trades = append(ownTrades.Trades, OwnTradeEntry{TradeID: tradeID, Trade: trade})
+		}
+	}
+	ownTrades.Trades.sort()
+	return nil
 }
 
 func (openOrders *OpenOrders) UnmarshalJSON(bytes []byte) error {
 
 	// we want to combine all these maps into one for convenience
-	var ordersMapSlice []map[string]OpenOrder
+	var ordersMapSlice []map[string]json.RawMessage
 
 	slice := []interface{}{
 		&ordersMapSlice,
@@ -214,9 +230,29 @@ func (openOrders *OpenOrders) UnmarshalJSON(bytes []byte) error {
 	}
 
 	openOrders.Orders = make(map[string]OpenOrder)
+	openOrders.fields = make(map[string][]string)
+
 	for _, ordersMap := range ordersMapSlice {
-		for key, value := range ordersMap {
-			openOrders.Orders[key] = value
+		for key, rawOrder := range ordersMap {
+			var order OpenOrder
+			if err := json.Unmarshal(rawOrder, &order); err != nil {
+				return err
+			}
+
+			// updates only contain changed fields, so we remember which ones were sent
+			var rawFields map[string]json.RawMessage
+			if err := json.Unmarshal(rawOrder, &rawFields); err != nil {
+				return err
+			}
+
+			fields := make([]string, 0, len(rawFields))
+			for field := range rawFields {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+
+			openOrders.Orders[key] = order
+			openOrders.fields[key] = fields
 		}
 	}
 
@@ -235,7 +271,8 @@ func getMessageType(bytes []byte) (string, error) {
 		return strings.Split(array.ChannelName, "-")[0], nil
 	}
 
-	if event.Status == "error" {
+	// failed subscriptions keep their pair and subscription for the registry, they become a SubscriptionFailedError
+	if event.Status == "error" && event.Event != "subscriptionStatus" {
 		return "error", nil
 	}
 
@@ -264,6 +301,8 @@ func unmarshalReceivedMessage(bytes []byte) (interface{}, error) {
 	}
 
 	targetMap := map[string]interface{}{
+		"addOrderStatus":     &AddOrderStatus{},
+		"cancelAllStatus":    &CancelAllStatus{},
 		"cancelOrderStatus":  &CancelOrderStatus{},
 		"error":              &Error{},
 		"heartbeat":          &HeartBeat{},
@@ -55,6 +55,21 @@ func TestUnmarshalReceivedMessage(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name:  "subscriptionStatusError",
+			bytes: []byte(`{"errorMessage":"Subscription depth not supported","event":"subscriptionStatus","pair":"XBT/USD","status":"error","subscription":{"depth":42,"name":"book"}}`),
+			expectedModel: SubscriptionStatus{
+				ErrorMessage: "Subscription depth not supported",
+				Event:        "subscriptionStatus",
+				Pair:         "XBT/USD",
+				Status:       "error",
+				Subscription: SubscriptionDetails{
+					Depth: 42,
+					Name:  "book",
+				},
+			},
+			expectedError: nil,
+		},
 		{
 			name: "ticker",
 			bytes: []byte(`[916,{"a":["0.42700000",16169,"16169.08316400"],"b":["0.42690000",1000,"1000.00000000"],"c":` +
@@ -202,6 +217,7 @@ func TestUnmarshalReceivedMessage(t *testing.T) {
 							Timestamp: UnixTime(time.Unix(1534614248, 456737995)),
 						},
 					},
+					Checksum: 974942666,
 				},
 			},
 			expectedError: nil,
@@ -228,6 +244,7 @@ func TestUnmarshalReceivedMessage(t *testing.T) {
 							Timestamp: UnixTime(time.Unix(1608240638, 875818014)),
 						},
 					},
+					Checksum: 751501448,
 				},
 			},
 			expectedError: nil,
@@ -247,8 +264,8 @@ func TestUnmarshalReceivedMessage(t *testing.T) {
 			bytes: []byte(`[[{"3HA3PV-3HA3P-3HA3PV":{"cost":"99.90000737","fee":"0.09792001","margin":"0.00000000","ordertxid":"ORWUAU-YUFW6-PSGDHG","ordertype":"limit","pair":"XRP/EUR",
 				"postxid":"TKH2SE-M7IF5-CFI7LT","price":"0.47957000","time":"1237535943.237535","type":"sell","vol":"123.456789"}}],"ownTrades",{"sequence":1}]`),
 			expectedModel: OwnTrades{
-				Trades: []map[string]OwnTrade{
-					{"3HA3PV-3HA3P-3HA3PV": OwnTrade{
+				Trades: OwnTradeList{
+					{TradeID: "3HA3PV-3HA3P-3HA3PV", Trade: OwnTrade{
 						Cost:               99.90000737,
 						Fee:                0.09792001,
 						Margin:             0.00000000,
@@ -310,6 +327,10 @@ func TestUnmarshalReceivedMessage(t *testing.T) {
 				Sequence: Sequence{
 					Sequence: 234,
 				},
+				fields: map[string][]string{
+					"OGTT3Y-C6I3P-XRI6HX": {"cost", "descr", "expiretm", "fee", "limitprice", "misc", "oflags", "opentm",
+						"price", "refid", "starttm", "status", "stopprice", "userref", "vol", "vol_exec"},
+				},
 			},
 			expectedError: nil,
 		},
@@ -322,6 +343,28 @@ func TestUnmarshalReceivedMessage(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name:  "addOrderStatus",
+			bytes: []byte(`{"descr":"buy 0.01770000 XBTEUR @ limit 4000.0","event":"addOrderStatus","status":"ok","txid":"ONPNXH-KMKMU-F4MR5V","reqid":3}`),
+			expectedModel: AddOrderStatus{
+				Event:         "addOrderStatus",
+				ReqID:         3,
+				Status:        "ok",
+				TransactionID: "ONPNXH-KMKMU-F4MR5V",
+				Description:   "buy 0.01770000 XBTEUR @ limit 4000.0",
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "cancelAllStatus",
+			bytes: []byte(`{"count":2,"event":"cancelAllStatus","status":"ok"}`),
+			expectedModel: CancelAllStatus{
+				Event:  "cancelAllStatus",
+				Status: "ok",
+				Count:  2,
+			},
+			expectedError: nil,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -8,13 +8,24 @@ func (openOrders *OpenOrders) DeleteInactiveOrders() {
 	}
 }
 
+// HasField returns whether the JSON message of orderID contained field.
+// For messages that were not unmarshalled from JSON it returns false.
+func (openOrders *OpenOrders) HasField(orderID string, field string) bool {
+	for _, present := range openOrders.fields[orderID] {
+		if present == field {
+			return true
+		}
+	}
+	return false
+}
+
 func (openOrders *OpenOrders) Update(update OpenOrders) {
 	for orderID, orderUpdate := range update.Orders {
-		openOrders.updateOrder(orderID, orderUpdate)
+		openOrders.updateOrder(orderID, orderUpdate, update.fields[orderID])
 	}
 }
 
-func (openOrders *OpenOrders) updateOrder(orderID string, update OpenOrder) {
+func (openOrders *OpenOrders) updateOrder(orderID string, update OpenOrder, fields []string) {
 
 	current, ok := openOrders.Orders[orderID]
 	if !ok {
@@ -26,79 +37,93 @@ func (openOrders *OpenOrders) updateOrder(orderID string, update OpenOrder) {
 		return
 	}
 
+	// When we know which fields were sent we use those, which allows resetting fields to their zero value.
+	// Otherwise we can only take over the fields that differ from the zero value.
+	present := func(field string, isZero bool) bool {
+		if fields == nil {
+			return !isZero
+		}
+
+		for _, sentField := range fields {
+			if sentField == field {
+				return true
+			}
+		}
+		return false
+	}
+
 	var zeroValue OpenOrder
 
-	if update.Cost != zeroValue.Cost {
+	if present("cost", update.Cost == zeroValue.Cost) {
 		current.Cost = update.Cost
 	}
 
-	if update.Description != zeroValue.Description {
+	if present("descr", update.Description == zeroValue.Description) {
 		current.Description = update.Description
 	}
 
-	if update.ExpirationTime != zeroValue.ExpirationTime {
+	if present("expiretm", update.ExpirationTime == zeroValue.ExpirationTime) {
 		current.ExpirationTime = update.ExpirationTime
 	}
 
-	if update.Fee != zeroValue.Fee {
+	if present("fee", update.Fee == zeroValue.Fee) {
 		current.Fee = update.Fee
 	}
 
-	if update.LimitPrice != zeroValue.LimitPrice {
+	if present("limitprice", update.LimitPrice == zeroValue.LimitPrice) {
 		current.LimitPrice = update.LimitPrice
 	}
 
-	if update.Miscellaneous != zeroValue.Miscellaneous {
+	if present("misc", update.Miscellaneous == zeroValue.Miscellaneous) {
 		current.Miscellaneous = update.Miscellaneous
 	}
 
-	if update.OFlags != zeroValue.OFlags {
+	if present("oflags", update.OFlags == zeroValue.OFlags) {
 		current.OFlags = update.OFlags
 	}
 
-	if update.OpenTime != zeroValue.OpenTime {
+	if present("opentm", update.OpenTime == zeroValue.OpenTime) {
 		current.OpenTime = update.OpenTime
 	}
 
-	if update.Price != zeroValue.Price {
+	if present("price", update.Price == zeroValue.Price) {
 		current.Price = update.Price
 	}
 
-	if update.ReferenceID != zeroValue.ReferenceID {
+	if present("refid", update.ReferenceID == zeroValue.ReferenceID) {
 		current.ReferenceID = update.ReferenceID
 	}
 
-	if update.StartTime != zeroValue.StartTime {
+	if present("starttm", update.StartTime == zeroValue.StartTime) {
 		current.StartTime = update.StartTime
 	}
 
-	if update.Status != zeroValue.Status {
+	if present("status", update.Status == zeroValue.Status) {
 		current.Status = update.Status
 	}
 
-	if update.StopPrice != zeroValue.StopPrice {
+	if present("stopprice", update.StopPrice == zeroValue.StopPrice) {
 		current.StopPrice = update.StopPrice
 	}
 
-	if update.UserReference != zeroValue.UserReference {
+	if present("userref", update.UserReference == zeroValue.UserReference) {
 		current.UserReference = update.UserReference
 	}
 
-	if update.Volume != zeroValue.Volume {
+	if present("vol", update.Volume == zeroValue.Volume) {
 		current.Volume = update.Volume
 	}
 
-	if update.VolumeExecuted != zeroValue.VolumeExecuted {
+	if present("vol_exec", update.VolumeExecuted == zeroValue.VolumeExecuted) {
 		current.VolumeExecuted = update.VolumeExecuted
 	}
 
-	if update.AveragePrice != zeroValue.AveragePrice {
+	if present("avg_price", update.AveragePrice == zeroValue.AveragePrice) {
 		current.AveragePrice = update.AveragePrice
 	}
 
-	if update.CancelReason != zeroValue.CancelReason {
+	if present("cancel_reason", update.CancelReason == zeroValue.CancelReason) {
 		current.CancelReason = update.CancelReason
-
 	}
 
 	openOrders.Orders[orderID] = current
@@ -143,3 +143,24 @@ func TestOpenOrdersUpdate(t *testing.T) {
 		assert.Equal(t, expected, state)
 	})
 }
+
+func TestOpenOrdersUpdateResetsSentFields(t *testing.T) {
+	var state OpenOrders
+
+	state.Update(OpenOrders{Orders: map[string]OpenOrder{
+		"OLFCT6-43DXW-EABUVM": {Status: "open", Volume: 10, LimitPrice: 3, CancelReason: "foo"},
+	}})
+
+	message, err := unmarshalReceivedMessage([]byte(`[[{"OLFCT6-43DXW-EABUVM":{"limitprice":"0.00000","vol_exec":"1.00000000"}}],"openOrders",{"sequence":2}]`))
+	assert.Nil(t, err)
+
+	update := message.(OpenOrders)
+	assert.True(t, update.HasField("OLFCT6-43DXW-EABUVM", "limitprice"))
+	assert.False(t, update.HasField("OLFCT6-43DXW-EABUVM", "status"))
+
+	state.Update(update)
+
+	assert.Equal(t, map[string]OpenOrder{
+		"OLFCT6-43DXW-EABUVM": {Status: "open", Volume: 10, VolumeExecuted: 1, CancelReason: "foo"},
+	}, state.Orders)
+}
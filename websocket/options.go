@@ -0,0 +1,41 @@
+package websocket
+
+type Option func(client *Client)
+
+// WithBufferSize buffers up to size received messages for Listen() and for each typed stream,
+// so the websocket is still read while the consumer is busy. Without it, delivery is unbuffered.
+func WithBufferSize(size int) Option {
+	return func(client *Client) {
+		client.bufferSize = size
+	}
+}
+
+// WithOverflowPolicy sets what happens when a message of channelType ("ticker", "book", "ownTrades", ...)
+// does not fit in the buffer. The default policy is Block. Dropping book updates makes books inconsistent,
+// the BookManager detects this with checksums and resubscribes.
+func WithOverflowPolicy(channelType string, policy OverflowPolicy) Option {
+	return func(client *Client) {
+		client.policies[channelType] = policy
+	}
+}
+
+// WithRecorder records every received frame, see NewReplayClient() to replay the recording.
+func WithRecorder(recorder *Recorder) Option {
+	return func(client *Client) {
+		client.recorder = recorder
+	}
+}
+
+// WithPublicURL connects to url instead of the public Kraken websocket, for example a mockserver.Server.
+func WithPublicURL(url string) Option {
+	return func(client *Client) {
+		client.publicURL = url
+	}
+}
+
+// WithPrivateURL connects to url instead of the private Kraken websocket.
+func WithPrivateURL(url string) Option {
+	return func(client *Client) {
+		client.privateURL = url
+	}
+}
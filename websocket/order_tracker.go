@@ -0,0 +1,451 @@
+package websocket
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+)
+
+type OrderState string
+
+const (
+	OrderUnknown         OrderState = "unknown"
+	OrderPending         OrderState = "pending"
+	OrderOpen            OrderState = "open"
+	OrderPartiallyFilled OrderState = "partiallyFilled"
+	OrderClosed          OrderState = "closed"
+	OrderCanceled        OrderState = "canceled"
+	OrderExpired         OrderState = "expired"
+)
+
+func (state OrderState) IsFinal() bool {
+	return state == OrderClosed || state == OrderCanceled || state == OrderExpired
+}
+
+type TrackedOrder struct {
+	TransactionID string
+	State         OrderState
+	Order         OpenOrder
+	Fills         OwnTradeList
+}
+
+func (order TrackedOrder) fillTotals() (volume float64, cost float64, fee float64) {
+	for _, fill := range order.Fills {
+		volume += float64(fill.Trade.Volume)
+		cost += float64(fill.Trade.Cost)
+		fee += float64(fill.Trade.Fee)
+	}
+	return volume, cost, fee
+}
+
+// FilledVolume returns the executed volume, taking into account both ownTrades and openOrders updates.
+func (order TrackedOrder) FilledVolume() float64 {
+	volume, _, _ := order.fillTotals()
+	if executed := float64(order.Order.VolumeExecuted); executed > volume {
+		return executed
+	}
+	return volume
+}
+
+func (order TrackedOrder) RemainingVolume() float64 {
+	remaining := float64(order.Order.Volume) - order.FilledVolume()
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// AveragePrice returns the average price of the fills, or the average price reported by openOrders if there are none.
+func (order TrackedOrder) AveragePrice() float64 {
+	volume, cost, _ := order.fillTotals()
+	if volume == 0 {
+		return float64(order.Order.AveragePrice)
+	}
+	return cost / volume
+}
+
+func (order TrackedOrder) Fees() float64 {
+	_, _, fee := order.fillTotals()
+	if fee == 0 {
+		return float64(order.Order.Fee)
+	}
+	return fee
+}
+
+func (order TrackedOrder) copy() TrackedOrder {
+	order.Fills = append(OwnTradeList(nil), order.Fills...)
+	return order
+}
+
+func (order TrackedOrder) computeState() OrderState {
+	switch order.Order.Status {
+	case "pending":
+		return OrderPending
+	case "open":
+		if order.FilledVolume() > 0 {
+			return OrderPartiallyFilled
+		}
+		return OrderOpen
+	case "closed":
+		return OrderClosed
+	case "canceled":
+		return OrderCanceled
+	case "expired":
+		return OrderExpired
+	}
+
+	if len(order.Fills) > 0 {
+		return OrderPartiallyFilled
+	}
+	return OrderUnknown
+}
+
+type OrderStateChanged struct {
+	TransactionID string
+	From          OrderState
+	To            OrderState
+	Order         TrackedOrder
+}
+
+type OrderFilled struct {
+	TransactionID string
+	Fill          OwnTradeEntry
+	Order         TrackedOrder
+}
+
+// OrderTracker maintains the lifecycle of our orders from the openOrders and ownTrades channels.
+type OrderTracker struct {
+	mutex      sync.RWMutex
+	openOrders OpenOrders
+	orders     map[string]*TrackedOrder
+	trades     *OwnTradeStore
+}
+
+func NewOrderTracker() *OrderTracker {
+	return &OrderTracker{
+		orders: make(map[string]*TrackedOrder),
+		trades: NewOwnTradeStore(),
+	}
+}
+
+// Handle processes a message received from Listen() and returns the resulting OrderStateChanged and OrderFilled
+// events. Messages other than OpenOrders and OwnTrades are ignored.
+func (tracker *OrderTracker) Handle(rawMessage interface{}) []interface{} {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	switch message := rawMessage.(type) {
+	case OpenOrders:
+		return tracker.handleOpenOrders(message)
+	case OwnTrades:
+		return tracker.handleOwnTrades(message)
+	default:
+		return nil
+	}
+}
+
+func (tracker *OrderTracker) getOrder(transactionID string) *TrackedOrder {
+	order, ok := tracker.orders[transactionID]
+	if !ok {
+		order = &TrackedOrder{TransactionID: transactionID, State: OrderUnknown}
+		tracker.orders[transactionID] = order
+	}
+	return order
+}
+
+func (tracker *OrderTracker) updateState(order *TrackedOrder, events []interface{}) []interface{} {
+	newState := order.computeState()
+
+	// ownTrades and openOrders are separate channels, a late fill should not reopen a final order
+	if order.State.IsFinal() || newState == order.State {
+		return events
+	}
+
+	oldState := order.State
+	order.State = newState
+
+	return append(events, OrderStateChanged{
+		TransactionID: order.TransactionID,
+		From:          oldState,
+		To:            newState,
+		Order:         order.copy(),
+	})
+}
+
+func (tracker *OrderTracker) handleOpenOrders(message OpenOrders) []interface{} {
+	tracker.openOrders.Update(message)
+
+	transactionIDs := make([]string, 0, len(message.Orders))
+	for transactionID := range message.Orders {
+		transactionIDs = append(transactionIDs, transactionID)
+	}
+	sort.Strings(transactionIDs)
+
+	var events []interface{}
+
+	for _, transactionID := range transactionIDs {
+		order := tracker.getOrder(transactionID)
+		description := order.Order.Description
+		order.Order = tracker.openOrders.Orders[transactionID]
+
+		// the first openOrders update of an order that was filled before may only contain its status
+		if order.Order.Description.Pair == "" {
+			order.Order.Description.Pair = description.Pair
+			order.Order.Description.Type = description.Type
+			order.Order.Description.OrderType = description.OrderType
+		}
+		events = tracker.updateState(order, events)
+	}
+
+	tracker.openOrders.DeleteInactiveOrders()
+	return events
+}
+
+func (tracker *OrderTracker) handleOwnTrades(message OwnTrades) []interface{} {
+	var events []interface{}
+
+	for _, fill := range tracker.trades.Add(message) {
+		// the snapshot holds the recent trades of any order, only missed fills of tracked orders are applied
+		if _, ok := tracker.orders[fill.Trade.OrderTransactionID]; message.Snapshot && !ok {
+			continue
+		}
+
+		order := tracker.getOrder(fill.Trade.OrderTransactionID)
+		order.Fills = append(order.Fills, fill)
+
+		if order.Order.Description.Pair == "" {
+			order.Order.Description.Pair = fill.Trade.Pair
+			order.Order.Description.Type = fill.Trade.Type
+			order.Order.Description.OrderType = fill.Trade.OrderType
+		}
+
+		events = append(events, OrderFilled{
+			TransactionID: order.TransactionID,
+			Fill:          fill,
+			Order:         order.copy(),
+		})
+		events = tracker.updateState(order, events)
+	}
+
+	return events
+}
+
+func (tracker *OrderTracker) Order(transactionID string) (TrackedOrder, bool) {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	order, ok := tracker.orders[transactionID]
+	if !ok {
+		return TrackedOrder{}, false
+	}
+	return order.copy(), true
+}
+
+// Orders returns all tracked orders, including final ones, sorted by transaction ID.
+func (tracker *OrderTracker) Orders() []TrackedOrder {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	orders := make([]TrackedOrder, 0, len(tracker.orders))
+	for _, order := range tracker.orders {
+		orders = append(orders, order.copy())
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].TransactionID < orders[j].TransactionID
+	})
+	return orders
+}
+
+// DeleteFinalOrders stops tracking closed, canceled and expired orders and forgets their trades. It should be
+// called periodically, as tracked orders and trades are otherwise kept forever.
+func (tracker *OrderTracker) DeleteFinalOrders() {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	for transactionID, order := range tracker.orders {
+		if !order.State.IsFinal() {
+			continue
+		}
+
+		for _, fill := range order.Fills {
+			tracker.trades.Delete(fill.TradeID)
+		}
+		delete(tracker.orders, transactionID)
+	}
+}
+
+// OrderSource is implemented by rest.Client and is used to resynchronise an OrderTracker.
+type OrderSource interface {
+	OpenOrders() (rest.OpenOrders, error)
+	QueryOrders(transactionIDs []string) (map[string]rest.Order, error)
+	TradesHistory(start time.Time, offset int) (rest.TradesHistory, error)
+}
+
+var openOrderFields = []string{"avg_price", "cancel_reason", "cost", "descr", "expiretm", "fee", "limitprice", "misc",
+	"oflags", "opentm", "price", "refid", "starttm", "status", "stopprice", "userref", "vol", "vol_exec"}
+
+func openOrderFromREST(order rest.Order) OpenOrder {
+	var averagePrice Float64String
+	if order.VolumeExecuted != 0 {
+		averagePrice = Float64String(float64(order.Cost) / float64(order.VolumeExecuted))
+	}
+
+	return OpenOrder{
+		Cost: Float64String(order.Cost),
+		Description: OpenOrderDescription{
+			ConditionalClose: order.Description.Close,
+			Leverage:         order.Description.Leverage,
+			Order:            order.Description.Order,
+			OrderType:        order.Description.OrderType,
+			Pair:             order.Description.Pair,
+			Price:            Float64String(order.Description.Price),
+			Price2:           Float64String(order.Description.Price2),
+			Type:             order.Description.Type,
+		},
+		ExpirationTime: UnixTime(order.ExpireTime),
+		Fee:            Float64String(order.Fee),
+		LimitPrice:     Float64String(order.LimitPrice),
+		Miscellaneous:  order.Miscellaneous,
+		OFlags:         order.OFlags,
+		OpenTime:       UnixTime(order.OpenTime),
+		Price:          Float64String(order.Price),
+		ReferenceID:    order.ReferenceID,
+		StartTime:      UnixTime(order.StartTime),
+		Status:         order.Status,
+		StopPrice:      Float64String(order.StopPrice),
+		UserReference:  order.UserReference,
+		Volume:         Float64String(order.Volume),
+		VolumeExecuted: Float64String(order.VolumeExecuted),
+		AveragePrice:   averagePrice,
+		CancelReason:   order.Reason,
+	}
+}
+
+func ownTradeFromREST(trade rest.TradeInfo) OwnTrade {
+	return OwnTrade{
+		Cost:               Float64String(trade.Cost),
+		Fee:                Float64String(trade.Fee),
+		Margin:             Float64String(trade.Margin),
+		OrderTransactionID: trade.OrderTransactionID,
+		OrderType:          trade.OrderType,
+		Pair:               trade.Pair,
+		PosTransactionID:   trade.PosTransactionID,
+		Price:              Float64String(trade.Price),
+		Time:               UnixTime(trade.Time),
+		Type:               trade.Type,
+		Volume:             Float64String(trade.Volume),
+	}
+}
+
+// missedTrades returns the trades of tracked orders and orders that are open according to REST, that are not
+// older than the last fill that was seen of their order.
+func (tracker *OrderTracker) missedTrades(source OrderSource, orders map[string]rest.Order) (OwnTrades, error) {
+	lastSeen := make(map[string]time.Time)
+	for _, order := range tracker.Orders() {
+		seen := time.Time(order.Order.OpenTime)
+		for _, fill := range order.Fills {
+			if fillTime := time.Time(fill.Trade.Time); fillTime.After(seen) {
+				seen = fillTime
+			}
+		}
+		lastSeen[order.TransactionID] = seen
+	}
+	for transactionID, order := range orders {
+		if _, ok := lastSeen[transactionID]; !ok {
+			lastSeen[transactionID] = time.Time(order.OpenTime)
+		}
+	}
+
+	var start time.Time
+	for _, seen := range lastSeen {
+		if seen.IsZero() {
+			start = time.Time{}
+			break
+		}
+		if start.IsZero() || seen.Before(start) {
+			start = seen
+		}
+	}
+	if !start.IsZero() {
+		// start is exclusive and in whole seconds
+		start = start.Add(-time.Second)
+	}
+
+	ownTrades := OwnTrades{ChannelName: "ownTrades"}
+
+	for offset := 0; ; {
+		history, err := source.TradesHistory(start, offset)
+		if err != nil {
+			return ownTrades, fmt.Errorf("could not get trades history: %w", err)
+		}
+
+		for tradeID, trade := range history.Trades {
+			seen, ok := lastSeen[trade.OrderTransactionID]
+			if !ok || time.Time(trade.Time).Before(seen) {
+				continue
+			}
+			ownTrades.Trades = append(ownTrades.Trades, OwnTradeEntry{TradeID: tradeID, Trade: ownTradeFromREST(trade)})
+		}
+
+		offset += len(history.Trades)
+		if len(history.Trades) == 0 || offset >= history.Count {
+			return ownTrades, nil
+		}
+	}
+}
+
+// Resync fetches open orders, tracked orders that are no longer open and their missed trades from the REST API.
+// It should be called after a SequenceGap, it returns the events caused by the messages that were missed.
+func (tracker *OrderTracker) Resync(source OrderSource) ([]interface{}, error) {
+	openOrders, err := source.OpenOrders()
+	if err != nil {
+		return nil, fmt.Errorf("could not get open orders: %w", err)
+	}
+
+	orders := openOrders.Open
+	if orders == nil {
+		orders = make(map[string]rest.Order)
+	}
+
+	var missing []string
+	for _, order := range tracker.Orders() {
+		if _, ok := orders[order.TransactionID]; !ok && !order.State.IsFinal() {
+			missing = append(missing, order.TransactionID)
+		}
+	}
+
+	if len(missing) != 0 {
+		queried, err := source.QueryOrders(missing)
+		if err != nil {
+			return nil, fmt.Errorf("could not query orders: %w", err)
+		}
+
+		for transactionID, order := range queried {
+			orders[transactionID] = order
+		}
+	}
+
+	ownTrades, err := tracker.missedTrades(source, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	// REST returns complete orders, so all fields are taken over
+	openOrdersMessage := OpenOrders{
+		Orders:      make(map[string]OpenOrder),
+		ChannelName: "openOrders",
+		fields:      make(map[string][]string),
+	}
+
+	for transactionID, order := range orders {
+		openOrdersMessage.Orders[transactionID] = openOrderFromREST(order)
+		openOrdersMessage.fields[transactionID] = openOrderFields
+	}
+
+	events := tracker.Handle(ownTrades)
+	return append(events, tracker.Handle(openOrdersMessage)...), nil
+}
@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderTracker(t *testing.T) {
+	tracker := NewOrderTracker()
+
+	events := tracker.Handle(OpenOrders{Orders: map[string]OpenOrder{
+		"OLFCT6-43DXW-EABUVM": {
+			Status:      "pending",
+			Volume:      10,
+			Description: OpenOrderDescription{Pair: "XRP/EUR", Type: "buy", OrderType: "limit", Price: 0.5},
+		},
+	}})
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, OrderUnknown, events[0].(OrderStateChanged).From)
+	assert.Equal(t, OrderPending, events[0].(OrderStateChanged).To)
+
+	events = tracker.Handle(OpenOrders{Orders: map[string]OpenOrder{"OLFCT6-43DXW-EABUVM": {Status: "open"}}})
+	assert.Len(t, events, 1)
+	assert.Equal(t, OrderOpen, events[0].(OrderStateChanged).To)
+
+	ownTrades := OwnTrades{Trades: OwnTradeList{
+		{TradeID: "TDLH43-DVQXD-2KHVYY", Trade: OwnTrade{OrderTransactionID: "OLFCT6-43DXW-EABUVM", Price: 0.5, Volume: 4, Cost: 2, Fee: 0.01}},
+		{TradeID: "TDLH43-DVQXD-2KHVYZ", Trade: OwnTrade{OrderTransactionID: "OLFCT6-43DXW-EABUVM", Price: 0.4, Volume: 1, Cost: 0.4, Fee: 0.01}},
+	}}
+
+	events = tracker.Handle(ownTrades)
+	assert.Len(t, events, 3)
+	assert.Equal(t, "TDLH43-DVQXD-2KHVYY", events[0].(OrderFilled).Fill.TradeID)
+	assert.Equal(t, OrderPartiallyFilled, events[1].(OrderStateChanged).To)
+	assert.Equal(t, "TDLH43-DVQXD-2KHVYZ", events[2].(OrderFilled).Fill.TradeID)
+
+	// trades that were seen before are ignored
+	assert.Empty(t, tracker.Handle(ownTrades))
+
+	order, ok := tracker.Order("OLFCT6-43DXW-EABUVM")
+	assert.True(t, ok)
+	assert.Equal(t, OrderPartiallyFilled, order.State)
+	assert.InDelta(t, 5, order.FilledVolume(), 1e-9)
+	assert.InDelta(t, 5, order.RemainingVolume(), 1e-9)
+	assert.InDelta(t, 0.48, order.AveragePrice(), 1e-9)
+	assert.InDelta(t, 0.02, order.Fees(), 1e-9)
+
+	events = tracker.Handle(OpenOrders{Orders: map[string]OpenOrder{
+		"OLFCT6-43DXW-EABUVM": {Status: "canceled", VolumeExecuted: 5},
+	}})
+	assert.Len(t, events, 1)
+	assert.Equal(t, OrderPartiallyFilled, events[0].(OrderStateChanged).From)
+	assert.Equal(t, OrderCanceled, events[0].(OrderStateChanged).To)
+	assert.Equal(t, "XRP/EUR", events[0].(OrderStateChanged).Order.Order.Description.Pair)
+
+	tracker.DeleteFinalOrders()
+	assert.Empty(t, tracker.Orders())
+	assert.Empty(t, tracker.trades.Trades())
+}
+
+func TestOrderTrackerFillBeforeOrder(t *testing.T) {
+	tracker := NewOrderTracker()
+
+	events := tracker.Handle(OwnTrades{Trades: OwnTradeList{
+		{TradeID: "TDLH43-DVQXD-2KHVYY", Trade: OwnTrade{OrderTransactionID: "OLFCT6-43DXW-EABUVM", Pair: "XRP/EUR", Volume: 4, Cost: 2}},
+	}})
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, OrderPartiallyFilled, events[1].(OrderStateChanged).To)
+
+	events = tracker.Handle(OpenOrders{Orders: map[string]OpenOrder{
+		"OLFCT6-43DXW-EABUVM": {Status: "closed", Volume: 4, VolumeExecuted: 4},
+	}})
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, OrderClosed, events[0].(OrderStateChanged).To)
+	assert.Equal(t, 0.0, events[0].(OrderStateChanged).Order.RemainingVolume())
+	assert.Equal(t, "XRP/EUR", events[0].(OrderStateChanged).Order.Order.Description.Pair)
+}
+
+func TestOrderTrackerSnapshot(t *testing.T) {
+	tracker := NewOrderTracker()
+
+	tracker.Handle(OpenOrders{Orders: map[string]OpenOrder{"OLFCT6-43DXW-EABUVM": {Status: "open", Volume: 10}}})
+
+	events := tracker.Handle(OwnTrades{Snapshot: true, Trades: OwnTradeList{
+		{TradeID: "TCCCTY-WE2O6-P3NB37", Trade: OwnTrade{OrderTransactionID: "OMMDB2-FSB6Z-7W3HPO", Volume: 1, Cost: 1}},
+		{TradeID: "TDLH43-DVQXD-2KHVYY", Trade: OwnTrade{OrderTransactionID: "OLFCT6-43DXW-EABUVM", Volume: 4, Cost: 2}},
+	}})
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, "TDLH43-DVQXD-2KHVYY", events[0].(OrderFilled).Fill.TradeID)
+	assert.Equal(t, OrderPartiallyFilled, events[1].(OrderStateChanged).To)
+
+	// trades of orders that are not tracked are history, they are not applied when sent again
+	_, ok := tracker.Order("OMMDB2-FSB6Z-7W3HPO")
+	assert.False(t, ok)
+	assert.Empty(t, tracker.Handle(OwnTrades{Trades: OwnTradeList{
+		{TradeID: "TCCCTY-WE2O6-P3NB37", Trade: OwnTrade{OrderTransactionID: "OMMDB2-FSB6Z-7W3HPO", Volume: 1, Cost: 1}},
+	}}))
+}
+
+type fakeOrderSource struct {
+	open    map[string]rest.Order
+	queried map[string]rest.Order
+	trades  map[string]rest.TradeInfo
+	queries [][]string
+	offsets []int
+}
+
+func (source *fakeOrderSource) OpenOrders() (rest.OpenOrders, error) {
+	return rest.OpenOrders{Open: source.open}, nil
+}
+
+func (source *fakeOrderSource) QueryOrders(transactionIDs []string) (map[string]rest.Order, error) {
+	source.queries = append(source.queries, transactionIDs)
+	return source.queried, nil
+}
+
+// TradesHistory returns a single trade per page.
+func (source *fakeOrderSource) TradesHistory(start time.Time, offset int) (rest.TradesHistory, error) {
+	source.offsets = append(source.offsets, offset)
+
+	tradeIDs := make([]string, 0, len(source.trades))
+	for tradeID := range source.trades {
+		tradeIDs = append(tradeIDs, tradeID)
+	}
+	sort.Strings(tradeIDs)
+
+	history := rest.TradesHistory{Trades: make(map[string]rest.TradeInfo), Count: len(tradeIDs)}
+	if offset < len(tradeIDs) {
+		history.Trades[tradeIDs[offset]] = source.trades[tradeIDs[offset]]
+	}
+	return history, nil
+}
+
+func TestOrderTrackerResync(t *testing.T) {
+	tracker := NewOrderTracker()
+
+	tracker.Handle(OpenOrders{Orders: map[string]OpenOrder{
+		"OLFCT6-43DXW-EABUVM": {Status: "open", Volume: 10},
+		"OGTT3Y-C6I3P-XRI6HX": {Status: "open", Volume: 10, LimitPrice: 3, OpenTime: UnixTime(time.Unix(200, 0))},
+	}})
+
+	source := &fakeOrderSource{
+		open: map[string]rest.Order{
+			"OGTT3Y-C6I3P-XRI6HX": {Status: "open", Volume: 10},
+		},
+		queried: map[string]rest.Order{
+			"OLFCT6-43DXW-EABUVM": {Status: "closed", Volume: 10, VolumeExecuted: 10, Cost: 5},
+		},
+		trades: map[string]rest.TradeInfo{
+			"TDLH43-DVQXD-2KHVYY": {OrderTransactionID: "OLFCT6-43DXW-EABUVM", Volume: 10, Cost: 5, Price: 0.5},
+			// trades of other orders and from before an order was seen are not missed
+			"TCCCTY-WE2O6-P3NB37": {OrderTransactionID: "OMMDB2-FSB6Z-7W3HPO", Volume: 1, Cost: 1, Price: 1},
+			"THVRQM-33VKH-UCI7BS": {OrderTransactionID: "OGTT3Y-C6I3P-XRI6HX", Volume: 1, Cost: 1, Price: 1,
+				Time: rest.UnixTime(time.Unix(100, 0))},
+		},
+	}
+
+	events, err := tracker.Resync(source)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"OLFCT6-43DXW-EABUVM"}}, source.queries)
+	assert.Equal(t, []int{0, 1, 2}, source.offsets)
+
+	assert.Len(t, events, 3)
+	assert.IsType(t, OrderFilled{}, events[0])
+	assert.Equal(t, OrderPartiallyFilled, events[1].(OrderStateChanged).To)
+	assert.Equal(t, OrderClosed, events[2].(OrderStateChanged).To)
+
+	// REST orders are complete, so fields missing from it are reset
+	order, _ := tracker.Order("OGTT3Y-C6I3P-XRI6HX")
+	assert.Equal(t, Float64String(0), order.Order.LimitPrice)
+	assert.Equal(t, OrderOpen, order.State)
+	assert.Empty(t, order.Fills)
+
+	_, ok := tracker.Order("OMMDB2-FSB6Z-7W3HPO")
+	assert.False(t, ok)
+}
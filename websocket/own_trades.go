@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type OwnTradeEntry struct {
+	TradeID string
+	Trade   OwnTrade
+}
+
+// OwnTradeList is a list of own trades ordered by time.
+type OwnTradeList []OwnTradeEntry
+
+func (list OwnTradeList) sort() {
+	sort.Slice(list, func(i, j int) bool {
+		iTime := time.Time(list[i].Trade.Time)
+		jTime := time.Time(list[j].Trade.Time)
+
+		if iTime.Equal(jTime) {
+			return list[i].TradeID < list[j].TradeID
+		}
+		return iTime.Before(jTime)
+	})
+}
+
+// List returns the trades of an ownTrades message ordered by time, messages that were not received from Kraken
+// may have them in any order.
+func (ownTrades *OwnTrades) List() OwnTradeList {
+	list := append(OwnTradeList(nil), ownTrades.Trades...)
+	list.sort()
+	return list
+}
+
+// OwnTradeStore remembers own trades by trade ID, so trades that are sent again are not counted twice.
+// This happens with the snapshot of recent trades that Kraken sends on every (re)subscribe.
+type OwnTradeStore struct {
+	mutex  sync.RWMutex
+	trades map[string]OwnTrade
+}
+
+func NewOwnTradeStore() *OwnTradeStore {
+	return &OwnTradeStore{trades: make(map[string]OwnTrade)}
+}
+
+// Add stores the trades of ownTrades and returns the ones that were not seen before.
+func (store *OwnTradeStore) Add(ownTrades OwnTrades) OwnTradeList {
+	return store.AddList(ownTrades.List())
+}
+
+func (store *OwnTradeStore) AddList(list OwnTradeList) OwnTradeList {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var added OwnTradeList
+
+	for _, entry := range list {
+		if _, ok := store.trades[entry.TradeID]; ok {
+			continue
+		}
+
+		store.trades[entry.TradeID] = entry.Trade
+		added = append(added, entry)
+	}
+
+	return added
+}
+
+func (store *OwnTradeStore) Contains(tradeID string) bool {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	_, ok := store.trades[tradeID]
+	return ok
+}
+
+func (store *OwnTradeStore) Delete(tradeIDs ...string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, tradeID := range tradeIDs {
+		delete(store.trades, tradeID)
+	}
+}
+
+func (store *OwnTradeStore) Trades() OwnTradeList {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	list := make(OwnTradeList, 0, len(store.trades))
+	for tradeID, trade := range store.trades {
+		list = append(list, OwnTradeEntry{TradeID: tradeID, Trade: trade})
+	}
+
+	list.sort()
+	return list
+}
+
+// snapshotMarker sets OwnTrades.Snapshot on the first ownTrades message after a subscription succeeded.
+type snapshotMarker struct {
+	mutex   sync.Mutex
+	pending bool
+}
+
+func (marker *snapshotMarker) mark(message interface{}) interface{} {
+	marker.mutex.Lock()
+	defer marker.mutex.Unlock()
+
+	switch message := message.(type) {
+	case SubscriptionStatus:
+		if message.ChannelName == "ownTrades" && message.Status == "subscribed" {
+			marker.pending = true
+		}
+	case OwnTrades:
+		message.Snapshot = marker.pending
+		marker.pending = false
+		return message
+	}
+	return message
+}
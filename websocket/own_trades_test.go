@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testOwnTrades = OwnTrades{
+	Trades: OwnTradeList{
+		{TradeID: "TDLH43-DVQXD-2KHVYY", Trade: OwnTrade{Time: UnixTime(time.Unix(200, 0)), Volume: 2}},
+		{TradeID: "TDLH43-DVQXD-2KHVYZ", Trade: OwnTrade{Time: UnixTime(time.Unix(100, 0)), Volume: 1}},
+		{TradeID: "TDLH43-DVQXD-2KHVYX", Trade: OwnTrade{Time: UnixTime(time.Unix(300, 0)), Volume: 3}},
+	},
+	ChannelName: "ownTrades",
+}
+
+func TestOwnTradesList(t *testing.T) {
+	assert.Equal(t, OwnTradeList{
+		{TradeID: "TDLH43-DVQXD-2KHVYZ", Trade: OwnTrade{Time: UnixTime(time.Unix(100, 0)), Volume: 1}},
+		{TradeID: "TDLH43-DVQXD-2KHVYY", Trade: OwnTrade{Time: UnixTime(time.Unix(200, 0)), Volume: 2}},
+		{TradeID: "TDLH43-DVQXD-2KHVYX", Trade: OwnTrade{Time: UnixTime(time.Unix(300, 0)), Volume: 3}},
+	}, testOwnTrades.List())
+
+	var ownTrades OwnTrades
+	assert.Nil(t, json.Unmarshal([]byte(`[[{"TDLH43-DVQXD-2KHVYY":{"time":"200.000000"}},{"TDLH43-DVQXD-2KHVYZ":{"time":"100.000000"}}],"ownTrades",{"sequence":1}]`), &ownTrades))
+	assert.Equal(t, OwnTradeList{
+		{TradeID: "TDLH43-DVQXD-2KHVYZ", Trade: OwnTrade{Time: UnixTime(time.Unix(100, 0))}},
+		{TradeID: "TDLH43-DVQXD-2KHVYY", Trade: OwnTrade{Time: UnixTime(time.Unix(200, 0))}},
+	}, ownTrades.Trades)
+}
+
+func TestOwnTradeStore(t *testing.T) {
+	store := NewOwnTradeStore()
+
+	assert.Len(t, store.Add(testOwnTrades), 3)
+
+	// resubscribing sends the same trades again, along with a new one
+	resent := OwnTrades{
+		Trades: append(OwnTradeList{
+			{TradeID: "TDLH43-DVQXD-2KHVYW", Trade: OwnTrade{Time: UnixTime(time.Unix(400, 0)), Volume: 4}},
+		}, testOwnTrades.Trades...),
+		Snapshot: true,
+	}
+
+	added := store.Add(resent)
+	assert.Equal(t, OwnTradeList{
+		{TradeID: "TDLH43-DVQXD-2KHVYW", Trade: OwnTrade{Time: UnixTime(time.Unix(400, 0)), Volume: 4}},
+	}, added)
+
+	assert.True(t, store.Contains("TDLH43-DVQXD-2KHVYX"))
+	assert.False(t, store.Contains("foo"))
+	assert.Len(t, store.Trades(), 4)
+
+	store.Delete("TDLH43-DVQXD-2KHVYX", "foo")
+	assert.False(t, store.Contains("TDLH43-DVQXD-2KHVYX"))
+	assert.Len(t, store.Trades(), 3)
+}
+
+func TestSnapshotMarker(t *testing.T) {
+	var marker snapshotMarker
+
+	assert.False(t, marker.mark(OwnTrades{}).(OwnTrades).Snapshot)
+
+	marker.mark(SubscriptionStatus{ChannelName: "ownTrades", Status: "subscribed"})
+	assert.True(t, marker.mark(OwnTrades{}).(OwnTrades).Snapshot)
+	assert.False(t, marker.mark(OwnTrades{}).(OwnTrades).Snapshot)
+}
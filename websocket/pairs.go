@@ -0,0 +1,49 @@
+package websocket
+
+import "github.com/lk16/kraken/assets"
+
+// AddPairs adds pairs to a Subscribe request by their websocket name.
+func (subscribe *Subscribe) AddPairs(pairs ...assets.Pair) {
+	subscribe.Pair = append(subscribe.Pair, assets.WSNames(pairs...)...)
+}
+
+// ParsedPairs returns the canonical form of the pairs of a Subscribe request.
+func (subscribe Subscribe) ParsedPairs() ([]assets.Pair, error) {
+	pairs := make([]assets.Pair, 0, len(subscribe.Pair))
+	for _, name := range subscribe.Pair {
+		pair, err := assets.ParsePair(name)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+func (ticker Ticker) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(ticker.Pair)
+}
+
+func (ohlc OHLC) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(ohlc.Pair)
+}
+
+func (trade Trade) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(trade.Pair)
+}
+
+func (spread Spread) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(spread.Pair)
+}
+
+func (book Book) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(book.Pair)
+}
+
+func (update BookUpdate) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(update.Pair)
+}
+
+func (trade OwnTrade) ParsedPair() (assets.Pair, error) {
+	return assets.ParsePair(trade.Pair)
+}
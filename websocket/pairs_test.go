@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/lk16/kraken/assets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairs(t *testing.T) {
+	xbteur := assets.Pair{Base: "XBT", Quote: "EUR"}
+
+	subscribe := Subscribe{Subscription: Subscription{Name: "ticker"}}
+	subscribe.AddPairs(assets.NewPair("BTC", "EUR"), assets.NewPair("XETH", "ZUSD"))
+	assert.Equal(t, []string{"XBT/EUR", "ETH/USD"}, subscribe.Pair)
+
+	pairs, err := subscribe.ParsedPairs()
+	assert.Nil(t, err)
+	assert.Equal(t, []assets.Pair{xbteur, {Base: "ETH", Quote: "USD"}}, pairs)
+
+	_, err = Subscribe{Pair: []string{"XBT"}}.ParsedPairs()
+	assert.Error(t, err)
+
+	pair, err := Ticker{Pair: "XBT/EUR"}.ParsedPair()
+	assert.Nil(t, err)
+	assert.Equal(t, xbteur, pair)
+
+	pair, err = OwnTrade{Pair: "XBT/EUR"}.ParsedPair()
+	assert.Nil(t, err)
+	assert.Equal(t, xbteur, pair)
+}
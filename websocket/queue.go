@@ -0,0 +1,164 @@
+package websocket
+
+import (
+	"strings"
+	"sync"
+)
+
+type OverflowPolicy int
+
+const (
+	// Block stops reading from the websocket until the consumer catches up
+	Block OverflowPolicy = iota
+	// DropOldest discards the oldest queued message of the same channel type
+	DropOldest
+	// DropNewest discards the message that does not fit in the queue
+	DropNewest
+	// ConflateByPair replaces a queued message of the same channel type and pair when the queue is full, which suits
+	// ticker and spread. Book snapshots and updates have to be applied in order, so book messages block instead.
+	ConflateByPair
+)
+
+// messageKind returns the channel type of market data and private messages, or an empty string for other messages.
+func messageKind(message interface{}) (kind string, pair string) {
+	channelName, pair, ok := messageChannel(message)
+	if !ok {
+		return "", ""
+	}
+	return strings.Split(channelName, "-")[0], pair
+}
+
+type queueItem struct {
+	kind    string
+	pair    string
+	message interface{}
+}
+
+type messageQueue struct {
+	mutex    sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	items    []queueItem
+	capacity int
+	policies map[string]OverflowPolicy
+	onDrop   func(kind string)
+	closed   bool
+}
+
+func newMessageQueue(capacity int, policies map[string]OverflowPolicy, onDrop func(kind string)) *messageQueue {
+	queue := &messageQueue{
+		capacity: capacity,
+		policies: policies,
+		onDrop:   onDrop,
+	}
+	queue.notEmpty = sync.NewCond(&queue.mutex)
+	queue.notFull = sync.NewCond(&queue.mutex)
+	return queue
+}
+
+func (queue *messageQueue) findOldest(kind string, pair string, matchPair bool) int {
+	for index, item := range queue.items {
+		if item.kind == kind && (!matchPair || item.pair == pair) {
+			return index
+		}
+	}
+	return -1
+}
+
+func (queue *messageQueue) remove(index int) {
+	queue.items = append(queue.items[:index], queue.items[index+1:]...)
+}
+
+// push adds message to the queue, applying the overflow policy of its channel type when the queue is full.
+// Messages that are not market data or private channel messages, such as errors and status events, always block.
+func (queue *messageQueue) push(message interface{}) {
+	kind, pair := messageKind(message)
+
+	policy := Block
+	if kind != "" {
+		policy = queue.policies[kind]
+	}
+	if policy == ConflateByPair && kind == "book" {
+		policy = Block
+	}
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	for len(queue.items) >= queue.capacity && !queue.closed {
+		if policy == DropNewest {
+			queue.onDrop(kind)
+			return
+		}
+
+		if policy == DropOldest || policy == ConflateByPair {
+			// conflation drops the oldest message of the same pair, or of the channel type if there is none
+			index := queue.findOldest(kind, pair, true)
+			if policy == DropOldest || index == -1 {
+				index = queue.findOldest(kind, "", false)
+			}
+
+			if index != -1 {
+				queue.remove(index)
+				queue.onDrop(kind)
+				break
+			}
+		}
+
+		// the queue is full of other channel types, we have no choice but to wait
+		queue.notFull.Wait()
+	}
+
+	if queue.closed {
+		return
+	}
+
+	queue.items = append(queue.items, queueItem{kind: kind, pair: pair, message: message})
+	queue.notEmpty.Signal()
+}
+
+// pop waits for a message, it returns false when the queue was closed.
+func (queue *messageQueue) pop() (interface{}, bool) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	for len(queue.items) == 0 && !queue.closed {
+		queue.notEmpty.Wait()
+	}
+
+	if queue.closed {
+		return nil, false
+	}
+
+	item := queue.items[0]
+	queue.items = queue.items[1:]
+	queue.notFull.Signal()
+	return item.message, true
+}
+
+func (queue *messageQueue) len() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return len(queue.items)
+}
+
+func (queue *messageQueue) close() {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	queue.closed = true
+	queue.notEmpty.Broadcast()
+	queue.notFull.Broadcast()
+}
+
+// pump passes messages from the queue to deliver until the queue is closed.
+func (queue *messageQueue) pump(deliver func(message interface{})) {
+	for {
+		message, ok := queue.pop()
+		if !ok {
+			return
+		}
+		deliver(message)
+	}
+}
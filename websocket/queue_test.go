@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageQueue(t *testing.T) {
+
+	policies := map[string]OverflowPolicy{
+		"ticker": ConflateByPair,
+		"trade":  DropNewest,
+		"spread": DropOldest,
+		"book":   ConflateByPair,
+	}
+
+	newQueue := func(dropped map[string]int) *messageQueue {
+		return newMessageQueue(3, policies, func(kind string) { dropped[kind]++ })
+	}
+
+	popAll := func(queue *messageQueue) []interface{} {
+		var messages []interface{}
+		for queue.len() != 0 {
+			message, _ := queue.pop()
+			messages = append(messages, message)
+		}
+		return messages
+	}
+
+	t.Run("conflateByPair", func(t *testing.T) {
+		dropped := make(map[string]int)
+		queue := newQueue(dropped)
+
+		// messages are only conflated when the queue is full
+		queue.push(Ticker{ChannelName: "ticker", Pair: "XBT/EUR", ChannelID: 1})
+		queue.push(Ticker{ChannelName: "ticker", Pair: "XRP/EUR", ChannelID: 2})
+		queue.push(Ticker{ChannelName: "ticker", Pair: "XBT/EUR", ChannelID: 3})
+		queue.push(Ticker{ChannelName: "ticker", Pair: "XBT/EUR", ChannelID: 4})
+
+		assert.Equal(t, []interface{}{
+			Ticker{ChannelName: "ticker", Pair: "XRP/EUR", ChannelID: 2},
+			Ticker{ChannelName: "ticker", Pair: "XBT/EUR", ChannelID: 3},
+			Ticker{ChannelName: "ticker", Pair: "XBT/EUR", ChannelID: 4},
+		}, popAll(queue))
+		assert.Equal(t, map[string]int{"ticker": 1}, dropped)
+	})
+
+	t.Run("conflateBook", func(t *testing.T) {
+		dropped := make(map[string]int)
+		queue := newQueue(dropped)
+
+		for channelID := int64(1); channelID <= 3; channelID++ {
+			queue.push(BookUpdate{ChannelName: "book-10", Pair: "XBT/EUR", ChannelID: channelID})
+		}
+
+		pushed := make(chan struct{})
+		go func() {
+			queue.push(BookUpdate{ChannelName: "book-10", Pair: "XBT/EUR", ChannelID: 4})
+			close(pushed)
+		}()
+
+		select {
+		case <-pushed:
+			t.Fatal("book update was conflated")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		queue.pop()
+		<-pushed
+
+		assert.Len(t, popAll(queue), 3)
+		assert.Empty(t, dropped)
+	})
+
+	t.Run("dropNewest", func(t *testing.T) {
+		dropped := make(map[string]int)
+		queue := newQueue(dropped)
+
+		for channelID := int64(1); channelID <= 4; channelID++ {
+			queue.push(Trade{ChannelName: "trade", ChannelID: channelID})
+		}
+
+		assert.Equal(t, []interface{}{
+			Trade{ChannelName: "trade", ChannelID: 1},
+			Trade{ChannelName: "trade", ChannelID: 2},
+			Trade{ChannelName: "trade", ChannelID: 3},
+		}, popAll(queue))
+		assert.Equal(t, map[string]int{"trade": 1}, dropped)
+	})
+
+	t.Run("dropOldest", func(t *testing.T) {
+		dropped := make(map[string]int)
+		queue := newQueue(dropped)
+
+		queue.push(HeartBeat{Event: "heartbeat"})
+		for channelID := int64(1); channelID <= 3; channelID++ {
+			queue.push(Spread{ChannelName: "spread", ChannelID: channelID})
+		}
+
+		assert.Equal(t, []interface{}{
+			HeartBeat{Event: "heartbeat"},
+			Spread{ChannelName: "spread", ChannelID: 2},
+			Spread{ChannelName: "spread", ChannelID: 3},
+		}, popAll(queue))
+		assert.Equal(t, map[string]int{"spread": 1}, dropped)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		dropped := make(map[string]int)
+		queue := newQueue(dropped)
+
+		for index := 0; index < 3; index++ {
+			queue.push(HeartBeat{})
+		}
+
+		pushed := make(chan struct{})
+		go func() {
+			queue.push(Pong{})
+			close(pushed)
+		}()
+
+		select {
+		case <-pushed:
+			t.Fatal("push did not block")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		queue.pop()
+		<-pushed
+
+		assert.Equal(t, []interface{}{HeartBeat{}, HeartBeat{}, Pong{}}, popAll(queue))
+		assert.Empty(t, dropped)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		queue := newQueue(make(map[string]int))
+		queue.close()
+
+		_, ok := queue.pop()
+		assert.False(t, ok)
+	})
+}
+
+func TestClientBuffer(t *testing.T) {
+	client := newClient(WithBufferSize(2), WithOverflowPolicy("ticker", DropNewest))
+
+	// nobody is listening, yet process does not block
+	for channelID := int64(1); channelID <= 4; channelID++ {
+		client.process(Ticker{ChannelName: "ticker", ChannelID: Int64String(channelID)})
+	}
+
+	var received []interface{}
+	for {
+		select {
+		case message := <-client.Listen():
+			received = append(received, message)
+			continue
+		case <-time.After(10 * time.Millisecond):
+		}
+		break
+	}
+
+	// the pump may or may not have taken a message out of the buffer before it filled up
+	assert.Equal(t, Ticker{ChannelName: "ticker", ChannelID: 1}, received[0])
+	assert.Equal(t, 4, len(received)+int(client.DroppedMessages()["ticker"]))
+	assert.Equal(t, 0, client.QueuedMessages())
+}
@@ -0,0 +1,192 @@
+package websocket
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayMaxSpeed replays frames without waiting between them.
+const ReplayMaxSpeed = 0
+
+// RecordedFrame is a single line of a recording.
+type RecordedFrame struct {
+	ReceivedAt time.Time `json:"received_at"`
+	Connection string    `json:"connection"`
+	Frame      string    `json:"frame"`
+}
+
+// Recorder writes received frames as JSON lines.
+type Recorder struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closers []io.Closer
+}
+
+// NewRecorder writes frames to writer, closing the Recorder does not close writer.
+func NewRecorder(writer io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(writer)}
+}
+
+// CreateRecording creates a recording file at path, which is gzip compressed if path ends with ".gz".
+func CreateRecording(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create recording: %w", err)
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		recorder := NewRecorder(file)
+		recorder.closers = []io.Closer{file}
+		return recorder, nil
+	}
+
+	gzipWriter := gzip.NewWriter(file)
+	recorder := NewRecorder(gzipWriter)
+	recorder.closers = []io.Closer{gzipWriter, file}
+	return recorder, nil
+}
+
+func (recorder *Recorder) Record(connection string, receivedAt time.Time, frame []byte) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return recorder.encoder.Encode(RecordedFrame{
+		ReceivedAt: receivedAt,
+		Connection: connection,
+		Frame:      string(frame),
+	})
+}
+
+func (recorder *Recorder) Close() error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	for _, closer := range recorder.closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayFinished is delivered through Listen() after the last frame of a replay.
+type ReplayFinished struct {
+	Frames int
+}
+
+// NewReplayClient returns a Client that is not connected, but delivers the frames of a recording
+// as if they were received. With speed 1 the original timing is kept, 10 is ten times faster,
+// ReplayMaxSpeed does not wait at all. Plain and gzip compressed recordings are supported.
+func NewReplayClient(reader io.Reader, speed float64, options ...Option) (*Client, error) {
+	return newReplayClient(reader, nil, speed, options...)
+}
+
+// OpenReplay is like NewReplayClient, but reads the recording at path.
+func OpenReplay(path string, speed float64, options ...Option) (*Client, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open recording: %w", err)
+	}
+
+	client, err := newReplayClient(file, file, speed, options...)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// decompressRecording returns reader, or a gzip reader for it if the recording is compressed.
+func decompressRecording(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("could not read gzip recording: %w", err)
+		}
+		return gzipReader, nil
+	}
+	return buffered, nil
+}
+
+// ReadRecording reads all frames of a plain or gzip compressed recording.
+func ReadRecording(reader io.Reader) ([]RecordedFrame, error) {
+	frames, err := decompressRecording(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(frames)
+
+	var recording []RecordedFrame
+	for {
+		var frame RecordedFrame
+		if err := decoder.Decode(&frame); err != nil {
+			if err == io.EOF {
+				return recording, nil
+			}
+			return nil, fmt.Errorf("reading recording failed: %w", err)
+		}
+		recording = append(recording, frame)
+	}
+}
+
+// DecodeFrame decodes a received frame into the message type that Listen() would deliver for it.
+func DecodeFrame(frame []byte) (interface{}, error) {
+	return unmarshalReceivedMessage(frame)
+}
+
+func newReplayClient(reader io.Reader, closer io.Closer, speed float64, options ...Option) (*Client, error) {
+	frames, err := decompressRecording(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newClient(options...)
+	go func() {
+		client.replay(json.NewDecoder(frames), speed)
+		if closer != nil {
+			closer.Close()
+		}
+	}()
+	return client, nil
+}
+
+func (client *Client) replay(decoder *json.Decoder, speed float64) {
+	var firstReceivedAt time.Time
+	var start time.Time
+	var count int
+
+	for {
+		var frame RecordedFrame
+		if err := decoder.Decode(&frame); err != nil {
+			if err != io.EOF {
+				client.deliverError(fmt.Errorf("reading recording failed: %w", err))
+			}
+			break
+		}
+
+		if count == 0 {
+			firstReceivedAt = frame.ReceivedAt
+			start = time.Now()
+		}
+		count++
+
+		if speed > 0 {
+			offset := time.Duration(float64(frame.ReceivedAt.Sub(firstReceivedAt)) / speed)
+			time.Sleep(time.Until(start.Add(offset)))
+		}
+
+		client.handleFrame([]byte(frame.Frame))
+	}
+
+	client.deliver(ReplayFinished{Frames: count})
+}
@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var recordedFrames = []string{
+	`{"event":"heartbeat"}`,
+	`{"connectionID":17978356104855020991,"event":"systemStatus","status":"online","version":"1.5.1"}`,
+	`not json`,
+}
+
+func record(t *testing.T, recorder *Recorder) {
+	start := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	for index, frame := range recordedFrames {
+		receivedAt := start.Add(time.Duration(index) * 10 * time.Millisecond)
+		assert.Nil(t, recorder.Record("public", receivedAt, []byte(frame)))
+	}
+	assert.Nil(t, recorder.Close())
+}
+
+func assertReplayed(t *testing.T, client *Client) {
+	assert.Equal(t, HeartBeat{Event: "heartbeat"}, <-client.Listen())
+	assert.IsType(t, SystemStatus{}, <-client.Listen())
+	assert.Error(t, (<-client.Listen()).(error))
+	assert.Equal(t, ReplayFinished{Frames: 3}, <-client.Listen())
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var buffer bytes.Buffer
+	record(t, NewRecorder(&buffer))
+
+	assert.Contains(t, buffer.String(), `{"received_at":"2021-03-04T12:00:00Z","connection":"public","frame":"{\"event\":\"heartbeat\"}"}`+"\n")
+
+	client, err := NewReplayClient(&buffer, ReplayMaxSpeed)
+	assert.Nil(t, err)
+	assertReplayed(t, client)
+}
+
+func TestRecordAndReplayGzipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+
+	recorder, err := CreateRecording(path)
+	assert.Nil(t, err)
+	record(t, recorder)
+
+	start := time.Now()
+
+	client, err := OpenReplay(path, 2)
+	assert.Nil(t, err)
+	assertReplayed(t, client)
+
+	// the last frame was recorded 20ms after the first one
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
+
+func TestReadRecording(t *testing.T) {
+	var buffer bytes.Buffer
+	record(t, NewRecorder(&buffer))
+
+	frames, err := ReadRecording(&buffer)
+	assert.Nil(t, err)
+	assert.Len(t, frames, 3)
+	assert.Equal(t, time.Date(2021, 3, 4, 12, 0, 0, 20000000, time.UTC), frames[2].ReceivedAt)
+
+	message, err := DecodeFrame([]byte(frames[0].Frame))
+	assert.Nil(t, err)
+	assert.Equal(t, HeartBeat{Event: "heartbeat"}, message)
+
+	_, err = DecodeFrame([]byte(frames[2].Frame))
+	assert.Error(t, err)
+}
@@ -0,0 +1,232 @@
+package websocket
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type SubscriptionState string
+
+const (
+	SubscriptionPending       SubscriptionState = "pending"
+	SubscriptionSubscribed    SubscriptionState = "subscribed"
+	SubscriptionUnsubscribing SubscriptionState = "unsubscribing"
+	SubscriptionUnsubscribed  SubscriptionState = "unsubscribed"
+	SubscriptionError         SubscriptionState = "error"
+)
+
+// SubscriptionKey identifies a subscription by channel name, pair and options.
+// Private channels have no pair.
+type SubscriptionKey struct {
+	Name     string
+	Pair     string
+	Depth    int
+	Interval int
+}
+
+func newSubscriptionKey(name string, pair string, depth int, interval int) SubscriptionKey {
+	// Kraken reports the default depth and interval in subscription status messages
+	switch name {
+	case "book":
+		if depth == 0 {
+			depth = 10
+		}
+		interval = 0
+	case "ohlc":
+		if interval == 0 {
+			interval = 1
+		}
+		depth = 0
+	default:
+		depth = 0
+		interval = 0
+	}
+
+	return SubscriptionKey{Name: name, Pair: pair, Depth: depth, Interval: interval}
+}
+
+type SubscriptionInfo struct {
+	Key          SubscriptionKey
+	Private      bool
+	State        SubscriptionState
+	ChannelID    int
+	ErrorMessage string
+}
+
+type DuplicateSubscriptionError struct {
+	Key   SubscriptionKey
+	State SubscriptionState
+}
+
+func (err DuplicateSubscriptionError) Error() string {
+	return fmt.Sprintf("already %s to %s %s", err.State, err.Key.Name, err.Key.Pair)
+}
+
+// SubscriptionFailedError is delivered instead of a SubscriptionStatus with status "error".
+type SubscriptionFailedError struct {
+	Status SubscriptionStatus
+}
+
+func (err SubscriptionFailedError) Error() string {
+	return fmt.Sprintf("subscribing to %s %s failed: %s", err.Status.Subscription.Name, err.Status.Pair, err.Status.ErrorMessage)
+}
+
+type subscriptionRegistry struct {
+	mutex         sync.RWMutex
+	subscriptions map[SubscriptionKey]*SubscriptionInfo
+	channels      map[int]SubscriptionKey
+}
+
+func newSubscriptionRegistry() *subscriptionRegistry {
+	return &subscriptionRegistry{
+		subscriptions: make(map[SubscriptionKey]*SubscriptionInfo),
+		channels:      make(map[int]SubscriptionKey),
+	}
+}
+
+func subscriptionKeys(pairs []string, subscription Subscription) []SubscriptionKey {
+	if len(pairs) == 0 {
+		return []SubscriptionKey{newSubscriptionKey(subscription.Name, "", subscription.Depth, subscription.Interval)}
+	}
+
+	var keys []SubscriptionKey
+	for _, pair := range pairs {
+		keys = append(keys, newSubscriptionKey(subscription.Name, pair, subscription.Depth, subscription.Interval))
+	}
+	return keys
+}
+
+// subscribe marks the subscriptions as pending, unless any of them is already pending or subscribed.
+func (registry *subscriptionRegistry) subscribe(subscribe Subscribe, private bool) error {
+	keys := subscriptionKeys(subscribe.Pair, subscribe.Subscription)
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, key := range keys {
+		info, ok := registry.subscriptions[key]
+		if ok && (info.State == SubscriptionPending || info.State == SubscriptionSubscribed) {
+			return DuplicateSubscriptionError{Key: key, State: info.State}
+		}
+	}
+
+	for _, key := range keys {
+		registry.subscriptions[key] = &SubscriptionInfo{Key: key, Private: private, State: SubscriptionPending}
+	}
+	return nil
+}
+
+func (registry *subscriptionRegistry) unsubscribe(unsubscribe Unsubscribe) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, key := range subscriptionKeys(unsubscribe.Pair, unsubscribe.Subscription) {
+		if info, ok := registry.subscriptions[key]; ok {
+			info.State = SubscriptionUnsubscribing
+		}
+	}
+}
+
+func (registry *subscriptionRegistry) fail(subscribe Subscribe, err error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, key := range subscriptionKeys(subscribe.Pair, subscribe.Subscription) {
+		if info, ok := registry.subscriptions[key]; ok {
+			info.State = SubscriptionError
+			info.ErrorMessage = err.Error()
+		}
+	}
+}
+
+func (registry *subscriptionRegistry) handleStatus(status SubscriptionStatus) {
+	details := status.Subscription
+	key := newSubscriptionKey(details.Name, status.Pair, details.Depth, details.Interval)
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	info, ok := registry.subscriptions[key]
+	if !ok {
+		info = &SubscriptionInfo{Key: key, Private: key.Name == "ownTrades" || key.Name == "openOrders"}
+		registry.subscriptions[key] = info
+	}
+
+	switch status.Status {
+	case "subscribed":
+		info.State = SubscriptionSubscribed
+		info.ChannelID = status.ChannelID
+		info.ErrorMessage = ""
+		registry.channels[status.ChannelID] = key
+	case "unsubscribed":
+		// we may have resubscribed already, which Kraken handles after unsubscribing
+		if info.State == SubscriptionPending {
+			return
+		}
+		info.State = SubscriptionUnsubscribed
+		delete(registry.channels, info.ChannelID)
+	case "error":
+		info.State = SubscriptionError
+		info.ErrorMessage = status.ErrorMessage
+	}
+}
+
+// disconnect marks all subscriptions of a connection as unsubscribed.
+func (registry *subscriptionRegistry) disconnect(private bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, info := range registry.subscriptions {
+		if info.Private != private {
+			continue
+		}
+
+		if info.State == SubscriptionSubscribed || info.State == SubscriptionPending {
+			info.State = SubscriptionUnsubscribed
+			delete(registry.channels, info.ChannelID)
+		}
+	}
+}
+
+func (registry *subscriptionRegistry) list(state SubscriptionState) []SubscriptionInfo {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	var list []SubscriptionInfo
+	for _, info := range registry.subscriptions {
+		if state == "" || info.State == state {
+			list = append(list, *info)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return fmt.Sprintf("%+v", list[i].Key) < fmt.Sprintf("%+v", list[j].Key)
+	})
+	return list
+}
+
+func (registry *subscriptionRegistry) byChannelID(channelID int) (SubscriptionInfo, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	key, ok := registry.channels[channelID]
+	if !ok {
+		return SubscriptionInfo{}, false
+	}
+	return *registry.subscriptions[key], true
+}
+
+// ActiveSubscriptions returns the subscriptions Kraken confirmed and that were not unsubscribed since.
+func (client *Client) ActiveSubscriptions() []SubscriptionInfo {
+	return client.subscriptions.list(SubscriptionSubscribed)
+}
+
+// Subscriptions returns all subscriptions in any state.
+func (client *Client) Subscriptions() []SubscriptionInfo {
+	return client.subscriptions.list("")
+}
+
+func (client *Client) SubscriptionByChannelID(channelID int) (SubscriptionInfo, bool) {
+	return client.subscriptions.byChannelID(channelID)
+}
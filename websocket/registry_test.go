@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionRegistry(t *testing.T) {
+	registry := newSubscriptionRegistry()
+
+	bookKey := SubscriptionKey{Name: "book", Pair: "XBT/EUR", Depth: 10}
+	tickerKey := SubscriptionKey{Name: "ticker", Pair: "XBT/EUR"}
+
+	subscribeBook := Subscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "book"}}
+	assert.Nil(t, registry.subscribe(subscribeBook, false))
+	assert.Equal(t, DuplicateSubscriptionError{Key: bookKey, State: SubscriptionPending}, registry.subscribe(subscribeBook, false))
+
+	registry.handleStatus(SubscriptionStatus{
+		ChannelID:    42,
+		Pair:         "XBT/EUR",
+		Status:       "subscribed",
+		Subscription: SubscriptionDetails{Name: "book", Depth: 10},
+	})
+
+	assert.Equal(t, []SubscriptionInfo{
+		{Key: bookKey, State: SubscriptionSubscribed, ChannelID: 42},
+	}, registry.list(SubscriptionSubscribed))
+
+	info, ok := registry.byChannelID(42)
+	assert.True(t, ok)
+	assert.Equal(t, bookKey, info.Key)
+
+	// resubscribing right after unsubscribing is not a duplicate
+	registry.unsubscribe(Unsubscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "book", Depth: 10}})
+	assert.Nil(t, registry.subscribe(subscribeBook, false))
+
+	registry.handleStatus(SubscriptionStatus{
+		ChannelID:    42,
+		Pair:         "XBT/EUR",
+		Status:       "unsubscribed",
+		Subscription: SubscriptionDetails{Name: "book", Depth: 10},
+	})
+	assert.Equal(t, SubscriptionPending, registry.list("")[0].State)
+
+	subscribeTicker := Subscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "ticker"}}
+	assert.Nil(t, registry.subscribe(subscribeTicker, false))
+
+	registry.handleStatus(SubscriptionStatus{
+		Pair:         "XBT/EUR",
+		Status:       "error",
+		ErrorMessage: "Subscription depth not supported",
+		Subscription: SubscriptionDetails{Name: "ticker"},
+	})
+
+	assert.Equal(t, []SubscriptionInfo{
+		{Key: bookKey, State: SubscriptionPending},
+		{Key: tickerKey, State: SubscriptionError, ErrorMessage: "Subscription depth not supported"},
+	}, registry.list(""))
+
+	// errors can be retried
+	assert.Nil(t, registry.subscribe(subscribeTicker, false))
+	registry.fail(subscribeTicker, errors.New("not connected"))
+	assert.Equal(t, SubscriptionError, registry.list("")[1].State)
+}
+
+func TestSubscriptionRegistryDisconnect(t *testing.T) {
+	registry := newSubscriptionRegistry()
+
+	assert.Nil(t, registry.subscribe(Subscribe{Subscription: Subscription{Name: "ownTrades"}}, true))
+	assert.Nil(t, registry.subscribe(Subscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "spread"}}, false))
+
+	registry.disconnect(true)
+
+	assert.Equal(t, []SubscriptionInfo{
+		{Key: SubscriptionKey{Name: "ownTrades"}, Private: true, State: SubscriptionUnsubscribed},
+		{Key: SubscriptionKey{Name: "spread", Pair: "XBT/EUR"}, State: SubscriptionPending},
+	}, registry.list(""))
+}
+
+func TestClientRejectsDuplicateSubscribe(t *testing.T) {
+	client := newClient()
+
+	go client.process(SubscriptionStatus{
+		ChannelID:    1,
+		Pair:         "XBT/EUR",
+		Status:       "subscribed",
+		Subscription: SubscriptionDetails{Name: "ticker"},
+	})
+	<-client.Listen()
+
+	assert.Len(t, client.ActiveSubscriptions(), 1)
+
+	err := client.Send(Subscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "ticker"}})
+	assert.IsType(t, DuplicateSubscriptionError{}, err)
+}
@@ -0,0 +1,47 @@
+package websocket
+
+import "sync"
+
+// SequenceGap is sent through Listen() before a private channel message with an unexpected sequence number.
+// Messages were lost, so state built from that channel should be resynchronised.
+type SequenceGap struct {
+	ChannelName string
+	Expected    int64
+	Received    int64
+}
+
+type sequenceChecker struct {
+	mutex sync.Mutex
+	last  map[string]int64
+}
+
+func newSequenceChecker() *sequenceChecker {
+	return &sequenceChecker{last: make(map[string]int64)}
+}
+
+// check returns a non-nil gap when sequence does not follow the previous sequence of channelName.
+// Subscribing restarts the sequence at 1, so that is never considered a gap.
+func (checker *sequenceChecker) check(channelName string, sequence int64) *SequenceGap {
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+
+	last, ok := checker.last[channelName]
+	checker.last[channelName] = sequence
+
+	if !ok || sequence == 1 || sequence == last+1 {
+		return nil
+	}
+
+	return &SequenceGap{ChannelName: channelName, Expected: last + 1, Received: sequence}
+}
+
+func (checker *sequenceChecker) checkMessage(message interface{}) *SequenceGap {
+	switch message := message.(type) {
+	case OpenOrders:
+		return checker.check(message.ChannelName, message.Sequence.Sequence)
+	case OwnTrades:
+		return checker.check(message.ChannelName, message.Sequence.Sequence)
+	default:
+		return nil
+	}
+}
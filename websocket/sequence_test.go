@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceChecker(t *testing.T) {
+
+	type testCase struct {
+		name        string
+		channelName string
+		sequence    int64
+		expectedGap *SequenceGap
+	}
+
+	testCases := []testCase{
+		{"first", "openOrders", 5, nil},
+		{"next", "openOrders", 6, nil},
+		{"otherChannel", "ownTrades", 1, nil},
+		{"gap", "openOrders", 8, &SequenceGap{ChannelName: "openOrders", Expected: 7, Received: 8}},
+		{"afterGap", "openOrders", 9, nil},
+		{"duplicate", "openOrders", 9, &SequenceGap{ChannelName: "openOrders", Expected: 10, Received: 9}},
+		{"resubscribed", "openOrders", 1, nil},
+	}
+
+	checker := newSequenceChecker()
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expectedGap, checker.check(testCase.channelName, testCase.sequence))
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package websocket
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type stream struct {
+	channelName string
+	pairs       map[string]struct{}
+	mutex       sync.RWMutex
+	done        chan struct{}
+	stopOnce    sync.Once
+	closed      bool
+	send        func(message interface{}, done <-chan struct{})
+	close       func()
+	queue       *messageQueue
+}
+
+func (stream *stream) matches(channelName string, pair string) bool {
+	if stream.channelName != channelName {
+		return false
+	}
+
+	if stream.pairs == nil {
+		return true
+	}
+
+	_, ok := stream.pairs[pair]
+	return ok
+}
+
+func (stream *stream) deliver(message interface{}) {
+	if stream.queue != nil {
+		stream.queue.push(message)
+		return
+	}
+	stream.deliverNow(message)
+}
+
+func (stream *stream) deliverNow(message interface{}) {
+	stream.mutex.RLock()
+	defer stream.mutex.RUnlock()
+
+	if !stream.closed {
+		stream.send(message, stream.done)
+	}
+}
+
+func (stream *stream) stop() {
+	stream.stopOnce.Do(func() {
+		// unblocks a pending send, so we can take the write lock
+		close(stream.done)
+
+		if stream.queue != nil {
+			stream.queue.close()
+		}
+
+		stream.mutex.Lock()
+		defer stream.mutex.Unlock()
+
+		stream.closed = true
+		stream.close()
+	})
+}
+
+// SubscriptionHandle is returned by the typed Subscribe methods of Client.
+type SubscriptionHandle struct {
+	client      *Client
+	stream      *stream
+	unsubscribe Unsubscribe
+	private     bool
+}
+
+// Unsubscribe unsubscribes from the channel and closes the typed channel.
+func (handle *SubscriptionHandle) Unsubscribe() error {
+	handle.client.removeStream(handle.stream)
+	handle.stream.stop()
+
+	if handle.private {
+		return handle.client.SendPrivate(handle.unsubscribe)
+	}
+	return handle.client.Send(handle.unsubscribe)
+}
+
+// messageChannel returns the channel name and pair of market data and private messages.
+func messageChannel(message interface{}) (channelName string, pair string, ok bool) {
+	switch message := message.(type) {
+	case Ticker:
+		return message.ChannelName, message.Pair, true
+	case OHLC:
+		return message.ChannelName, message.Pair, true
+	case Trade:
+		return message.ChannelName, message.Pair, true
+	case Spread:
+		return message.ChannelName, message.Pair, true
+	case Book:
+		return message.ChannelName, message.Pair, true
+	case BookUpdate:
+		return message.ChannelName, message.Pair, true
+	case OwnTrades:
+		return message.ChannelName, "", true
+	case OpenOrders:
+		return message.ChannelName, "", true
+	default:
+		return "", "", false
+	}
+}
+
+// subscriptionChannelName returns the channel name Kraken uses in messages for subscription.
+func subscriptionChannelName(subscription Subscription) string {
+	switch subscription.Name {
+	case "book":
+		depth := subscription.Depth
+		if depth == 0 {
+			depth = 10
+		}
+		return fmt.Sprintf("book-%d", depth)
+	case "ohlc":
+		interval := subscription.Interval
+		if interval == 0 {
+			interval = 1
+		}
+		return fmt.Sprintf("ohlc-%d", interval)
+	default:
+		return subscription.Name
+	}
+}
+
+func (client *Client) dispatchToStreams(message interface{}) bool {
+	channelName, pair, ok := messageChannel(message)
+	if !ok {
+		return false
+	}
+
+	client.streamsMutex.RLock()
+	var matching []*stream
+	for _, stream := range client.streams {
+		if stream.matches(channelName, pair) {
+			matching = append(matching, stream)
+		}
+	}
+	client.streamsMutex.RUnlock()
+
+	for _, stream := range matching {
+		stream.deliver(message)
+	}
+
+	return len(matching) != 0
+}
+
+func (client *Client) removeStream(removed *stream) {
+	client.streamsMutex.Lock()
+	defer client.streamsMutex.Unlock()
+
+	for index, stream := range client.streams {
+		if stream == removed {
+			client.streams = append(client.streams[:index], client.streams[index+1:]...)
+			return
+		}
+	}
+}
+
+func (client *Client) addStream(
+	subscribe Subscribe,
+	send func(message interface{}, done <-chan struct{}),
+	close func(),
+) *stream {
+
+	stream := &stream{
+		channelName: subscriptionChannelName(subscribe.Subscription),
+		done:        make(chan struct{}),
+		send:        send,
+		close:       close,
+	}
+
+	if len(subscribe.Pair) != 0 {
+		stream.pairs = make(map[string]struct{})
+		for _, pair := range subscribe.Pair {
+			stream.pairs[pair] = struct{}{}
+		}
+	}
+
+	if client.bufferSize > 0 {
+		stream.queue = client.newMessageQueue()
+		go stream.queue.pump(stream.deliverNow)
+	}
+
+	client.streamsMutex.Lock()
+	client.streams = append(client.streams, stream)
+	client.streamsMutex.Unlock()
+
+	return stream
+}
+
+func (client *Client) subscribeStream(
+	subscribe Subscribe,
+	private bool,
+	send func(message interface{}, done <-chan struct{}),
+	close func(),
+) (*SubscriptionHandle, error) {
+
+	stream := client.addStream(subscribe, send, close)
+
+	handle := &SubscriptionHandle{
+		client:  client,
+		stream:  stream,
+		private: private,
+		unsubscribe: Unsubscribe{
+			Pair:         subscribe.Pair,
+			Subscription: subscribe.Subscription,
+		},
+	}
+
+	var err error
+	if private {
+		err = client.SendPrivate(subscribe)
+	} else {
+		err = client.Send(subscribe)
+	}
+
+	if err != nil {
+		client.removeStream(stream)
+		stream.stop()
+		return nil, fmt.Errorf("subscribing to %s %s failed: %w", stream.channelName, strings.Join(subscribe.Pair, ","), err)
+	}
+
+	return handle, nil
+}
+
+func (client *Client) SubscribeTicker(pairs []string) (<-chan Ticker, *SubscriptionHandle, error) {
+	messages := make(chan Ticker)
+
+	subscribe := Subscribe{Pair: pairs, Subscription: Subscription{Name: "ticker"}}
+
+	handle, err := client.subscribeStream(subscribe, false, func(message interface{}, done <-chan struct{}) {
+		select {
+		case messages <- message.(Ticker):
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
+
+// SubscribeBook streams the Book of each pair every time it changes, with updates already applied.
+// A depth of zero uses Kraken's default of 10.
+func (client *Client) SubscribeBook(pairs []string, depth int) (<-chan Book, *SubscriptionHandle, error) {
+	messages := make(chan Book)
+
+	subscribe := Subscribe{Pair: pairs, Subscription: Subscription{Name: "book", Depth: depth}}
+
+	// only accessed from the listener go-routine
+	books := make(map[string]*Book)
+
+	handle, err := client.subscribeStream(subscribe, false, func(message interface{}, done <-chan struct{}) {
+		var book *Book
+
+		switch message := message.(type) {
+		case Book:
+			snapshot := message.Copy()
+			book = &snapshot
+			books[message.Pair] = book
+		case BookUpdate:
+			var ok bool
+			if book, ok = books[message.Pair]; !ok {
+				return
+			}
+			book.Update(message)
+			book.Truncate(channelDepth(message.ChannelName))
+		}
+
+		select {
+		case messages <- book.Copy():
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
+
+func (client *Client) SubscribeTrades(pairs []string) (<-chan Trade, *SubscriptionHandle, error) {
+	messages := make(chan Trade)
+
+	subscribe := Subscribe{Pair: pairs, Subscription: Subscription{Name: "trade"}}
+
+	handle, err := client.subscribeStream(subscribe, false, func(message interface{}, done <-chan struct{}) {
+		select {
+		case messages <- message.(Trade):
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
+
+// SubscribeOHLC streams candles of interval minutes, an interval of zero uses Kraken's default of 1.
+func (client *Client) SubscribeOHLC(pairs []string, interval int) (<-chan OHLC, *SubscriptionHandle, error) {
+	messages := make(chan OHLC)
+
+	subscribe := Subscribe{Pair: pairs, Subscription: Subscription{Name: "ohlc", Interval: interval}}
+
+	handle, err := client.subscribeStream(subscribe, false, func(message interface{}, done <-chan struct{}) {
+		select {
+		case messages <- message.(OHLC):
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
+
+func (client *Client) SubscribeSpread(pairs []string) (<-chan Spread, *SubscriptionHandle, error) {
+	messages := make(chan Spread)
+
+	subscribe := Subscribe{Pair: pairs, Subscription: Subscription{Name: "spread"}}
+
+	handle, err := client.subscribeStream(subscribe, false, func(message interface{}, done <-chan struct{}) {
+		select {
+		case messages <- message.(Spread):
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
+
+// SubscribeOwnTrades requires a private connection and a token loaded with LoadWebsocketToken().
+func (client *Client) SubscribeOwnTrades() (<-chan OwnTrades, *SubscriptionHandle, error) {
+	messages := make(chan OwnTrades)
+
+	subscribe := Subscribe{Subscription: Subscription{Name: "ownTrades"}}
+
+	handle, err := client.subscribeStream(subscribe, true, func(message interface{}, done <-chan struct{}) {
+		select {
+		case messages <- message.(OwnTrades):
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
+
+// SubscribeOpenOrders requires a private connection and a token loaded with LoadWebsocketToken().
+func (client *Client) SubscribeOpenOrders() (<-chan OpenOrders, *SubscriptionHandle, error) {
+	messages := make(chan OpenOrders)
+
+	subscribe := Subscribe{Subscription: Subscription{Name: "openOrders"}}
+
+	handle, err := client.subscribeStream(subscribe, true, func(message interface{}, done <-chan struct{}) {
+		select {
+		case messages <- message.(OpenOrders):
+		case <-done:
+		}
+	}, func() { close(messages) })
+
+	return messages, handle, err
+}
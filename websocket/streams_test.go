@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionChannelName(t *testing.T) {
+
+	type testCase struct {
+		subscription        Subscription
+		expectedChannelName string
+	}
+
+	testCases := []testCase{
+		{Subscription{Name: "ticker"}, "ticker"},
+		{Subscription{Name: "book"}, "book-10"},
+		{Subscription{Name: "book", Depth: 100}, "book-100"},
+		{Subscription{Name: "ohlc"}, "ohlc-1"},
+		{Subscription{Name: "ohlc", Interval: 5}, "ohlc-5"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expectedChannelName, subscriptionChannelName(testCase.subscription))
+	}
+}
+
+func TestDispatchToStreams(t *testing.T) {
+	client := newClient()
+
+	tickers := make(chan Ticker, 10)
+	stream := client.addStream(
+		Subscribe{Pair: []string{"XBT/EUR"}, Subscription: Subscription{Name: "ticker"}},
+		func(message interface{}, done <-chan struct{}) { tickers <- message.(Ticker) },
+		func() { close(tickers) },
+	)
+
+	go client.process(Ticker{ChannelName: "ticker", Pair: "XRP/EUR"})
+	assert.Equal(t, Ticker{ChannelName: "ticker", Pair: "XRP/EUR"}, <-client.Listen())
+
+	client.process(Ticker{ChannelName: "ticker", Pair: "XBT/EUR"})
+	assert.Equal(t, Ticker{ChannelName: "ticker", Pair: "XBT/EUR"}, <-tickers)
+
+	client.removeStream(stream)
+	stream.stop()
+
+	_, open := <-tickers
+	assert.False(t, open)
+
+	go client.process(Ticker{ChannelName: "ticker", Pair: "XBT/EUR"})
+	assert.Equal(t, Ticker{ChannelName: "ticker", Pair: "XBT/EUR"}, <-client.Listen())
+}
+
+func TestStreamStopUnblocksSend(t *testing.T) {
+	client := newClient()
+
+	messages := make(chan Spread)
+	stream := client.addStream(
+		Subscribe{Subscription: Subscription{Name: "spread"}},
+		func(message interface{}, done <-chan struct{}) {
+			select {
+			case messages <- message.(Spread):
+			case <-done:
+			}
+		},
+		func() { close(messages) },
+	)
+
+	delivered := make(chan struct{})
+	go func() {
+		client.process(Spread{ChannelName: "spread", Pair: "XBT/EUR"})
+		close(delivered)
+	}()
+
+	stream.stop()
+	<-delivered
+}
+
+func TestErrors(t *testing.T) {
+	client := newClient()
+
+	go client.deliverError(errBinaryMessage)
+	assert.Equal(t, errBinaryMessage, <-client.Listen())
+
+	errors := client.Errors()
+	go client.deliverError(errBinaryMessage)
+	assert.Equal(t, errBinaryMessage, <-errors)
+}
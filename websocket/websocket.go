@@ -1,13 +1,12 @@
 package websocket
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"reflect"
+	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/lk16/kraken/rest"
 )
 
@@ -21,56 +20,104 @@ const (
 var errBinaryMessage = errors.New("unhandled binary message")
 
 type Client struct {
-	publicWs     *websocket.Conn
-	receiveChan  chan interface{}
-	verbose      bool
-	privateToken string
-	privateWs    *websocket.Conn
+	publicWs      *Conn
+	receiveChan   chan interface{}
+	verbose       bool
+	privateToken  string
+	privateWs     *Conn
+	sequences     *sequenceChecker
+	snapshots     snapshotMarker
+	subscriptions *subscriptionRegistry
+	streamsMutex  sync.RWMutex
+	streams       []*stream
+	errorsMutex   sync.Mutex
+	errorChan     chan error
+
+	handlersMutex sync.RWMutex
+	handlers      map[reflect.Type][]handler
+
+	bufferSize   int
+	policies     map[string]OverflowPolicy
+	receiveQueue *messageQueue
+	droppedMutex sync.Mutex
+	dropped      map[string]uint64
+
+	recorder *Recorder
+
+	publicURL  string
+	privateURL string
 }
 
-func NewClient() (*Client, error) {
+func newClient(options ...Option) *Client {
 	client := &Client{
-		receiveChan: make(chan interface{}),
+		receiveChan:   make(chan interface{}),
+		sequences:     newSequenceChecker(),
+		subscriptions: newSubscriptionRegistry(),
+		policies:      make(map[string]OverflowPolicy),
+		dropped:       make(map[string]uint64),
+		publicURL:     publicWsURL,
+		privateURL:    privateWsURL,
 	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	if client.bufferSize > 0 {
+		client.receiveQueue = client.newMessageQueue()
+		go client.receiveQueue.pump(func(message interface{}) {
+			client.receiveChan <- message
+		})
+	}
+
+	return client
+}
+
+func NewClient(options ...Option) (*Client, error) {
+	client := newClient(options...)
 	var err error
 
 	if err = client.ConnectWs("public"); err != nil {
 		return nil, err
 	}
 
-	go client.keepAliveLoop()
-
 	return client, nil
 }
 
 func (client *Client) ConnectWs(publicPrivate string) error {
-	var (
-		connPtr **websocket.Conn
-		url     string
-	)
-
-	if publicPrivate == "public" {
-		connPtr = &client.publicWs
-		url = publicWsURL
-	} else {
-		connPtr = &client.privateWs
-		url = privateWsURL
+	url := client.publicURL
+	if publicPrivate != "public" {
+		url = client.privateURL
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, err := Dial(url, publicPrivate)
 	if err != nil {
-		return fmt.Errorf("could not connect to %s websocket: %w", publicPrivate, err)
+		return err
 	}
 
-	*connPtr = conn
+	conn.SetVerbose(client.verbose)
+
+	if publicPrivate == "public" {
+		client.publicWs = conn
+	} else {
+		client.privateWs = conn
+	}
 
-	go client.wsListener(publicPrivate, conn)
-	go client.privateKeepAliveLoop()
+	go client.wsListener(conn)
+	go conn.KeepAlive(keepAliveDuration, func() interface{} {
+		return Ping{Event: "ping"}
+	}, client.deliverError)
 	return nil
 }
 
 func (client *Client) SetVerbose(verbose bool) {
 	client.verbose = verbose
+
+	for _, conn := range []*Conn{client.publicWs, client.privateWs} {
+		if conn != nil {
+			conn.SetVerbose(verbose)
+		}
+	}
 }
 
 func (client *Client) LoadWebsocketToken(key string, secret string) error {
@@ -89,74 +136,156 @@ func (client *Client) LoadWebsocketToken(key string, secret string) error {
 	return nil
 }
 
-func (client *Client) keepAliveLoop() {
-	ticker := time.NewTicker(keepAliveDuration)
-	for range ticker.C {
-		if err := client.Send(Ping{}); err != nil {
-			client.receiveChan <- fmt.Errorf("keep alive failed: %w", err)
-			return
+// SetWebsocketToken sets a token obtained with rest.Client.GetWebSocketsToken() elsewhere.
+func (client *Client) SetWebsocketToken(token string) {
+	client.privateToken = token
+}
+
+type DisconnectError struct {
+	PublicPrivate string
+	error
+}
+
+func (client *Client) wsListener(conn *Conn) {
+	conn.ReadLoop(func(frame []byte) {
+		if client.recorder != nil {
+			if err := client.recorder.Record(conn.Name(), time.Now(), frame); err != nil {
+				client.deliverError(fmt.Errorf("recording frame failed: %w", err))
+			}
+		}
+
+		client.handleFrame(frame)
+	}, func(err error) {
+		if _, ok := err.(DisconnectError); ok {
+			client.subscriptions.disconnect(conn.Name() == "private")
 		}
+		client.deliverError(err)
+	})
+}
+
+func (client *Client) handleFrame(frame []byte) {
+	model, err := unmarshalReceivedMessage(frame)
+
+	if err != nil {
+		client.deliverError(err)
+		return
 	}
+
+	client.process(model)
 }
 
-// TODO consider refactoring this
-func (client *Client) privateKeepAliveLoop() {
-	ticker := time.NewTicker(keepAliveDuration)
-	for range ticker.C {
-		if err := client.SendPrivate(Ping{}); err != nil {
-			client.receiveChan <- fmt.Errorf("keep alive failed: %w", err)
+func (client *Client) process(model interface{}) {
+	model = client.snapshots.mark(model)
+
+	if status, ok := model.(SubscriptionStatus); ok {
+		client.subscriptions.handleStatus(status)
+
+		if status.Status == "error" {
+			client.deliverError(SubscriptionFailedError{Status: status})
 			return
 		}
 	}
+
+	if gap := client.sequences.checkMessage(model); gap != nil {
+		if !client.dispatchToHandlers(*gap) {
+			client.deliver(*gap)
+		}
+	}
+
+	handledByStreams := client.dispatchToStreams(model)
+	handledByHandlers := client.dispatchToHandlers(model)
+
+	if handledByStreams || handledByHandlers {
+		return
+	}
+
+	client.deliver(model)
 }
 
-type DisconnectError struct {
-	PublicPrivate string
-	error
+func (client *Client) deliver(message interface{}) {
+	if client.receiveQueue != nil {
+		client.receiveQueue.push(message)
+		return
+	}
+	client.receiveChan <- message
 }
 
-func (client *Client) wsListener(publicPrivate string, ws *websocket.Conn) {
-	log.Printf("listening on %s websocket", publicPrivate)
-	for {
-		messageType, message, err := ws.ReadMessage()
+func (client *Client) newMessageQueue() *messageQueue {
+	return newMessageQueue(client.bufferSize, client.policies, func(kind string) {
+		client.droppedMutex.Lock()
+		defer client.droppedMutex.Unlock()
 
-		if err != nil {
-			if _, ok := err.(*websocket.CloseError); ok {
-				log.Printf("RECV %7s: dicconnect %s", publicPrivate, err.Error())
+		client.dropped[kind]++
+	})
+}
 
-				client.receiveChan <- DisconnectError{error: err, PublicPrivate: publicPrivate}
-				return
-			}
-			log.Printf("RECV %7s: error %s", publicPrivate, err.Error())
+// DroppedMessages returns the number of messages per channel type that were dropped or conflated
+// because of the overflow policies.
+func (client *Client) DroppedMessages() map[string]uint64 {
+	client.droppedMutex.Lock()
+	defer client.droppedMutex.Unlock()
 
-			client.receiveChan <- err
-			continue
-		}
+	dropped := make(map[string]uint64)
+	for kind, count := range client.dropped {
+		dropped[kind] = count
+	}
+	return dropped
+}
 
-		if messageType != websocket.TextMessage {
-			client.receiveChan <- errBinaryMessage
-			continue
-		}
+// QueuedMessages returns the number of buffered messages that were not yet consumed, for Listen() and all streams.
+func (client *Client) QueuedMessages() int {
+	var queued int
 
-		if client.verbose {
-			log.Printf("RECV %7s: %s", publicPrivate, string(message))
-		}
+	if client.receiveQueue != nil {
+		queued += client.receiveQueue.len()
+	}
 
-		model, err := unmarshalReceivedMessage(message)
+	client.streamsMutex.RLock()
+	defer client.streamsMutex.RUnlock()
 
-		if err != nil {
-			client.receiveChan <- err
-			continue
+	for _, stream := range client.streams {
+		if stream.queue != nil {
+			queued += stream.queue.len()
 		}
+	}
+	return queued
+}
 
-		client.receiveChan <- model
+func (client *Client) deliverError(err error) {
+	if client.dispatchErrorToHandlers(err) {
+		return
 	}
+
+	client.errorsMutex.Lock()
+	errorChan := client.errorChan
+	client.errorsMutex.Unlock()
+
+	if errorChan != nil {
+		errorChan <- err
+		return
+	}
+
+	client.deliver(err)
 }
 
+// Listen returns the channel through which all received messages and errors are delivered,
+// except for messages routed to a typed stream and errors once Errors() was called.
 func (client *Client) Listen() <-chan interface{} {
 	return client.receiveChan
 }
 
+// Errors returns a channel through which errors are delivered instead of through Listen().
+// This is meant for users of the typed streams such as SubscribeTicker().
+func (client *Client) Errors() <-chan error {
+	client.errorsMutex.Lock()
+	defer client.errorsMutex.Unlock()
+
+	if client.errorChan == nil {
+		client.errorChan = make(chan error)
+	}
+	return client.errorChan
+}
+
 func (client *Client) Send(rawMessage interface{}) error {
 	return client.send(rawMessage, "public")
 }
@@ -168,19 +297,15 @@ func (client *Client) SendPrivate(rawMessage interface{}) error {
 func (client *Client) send(rawMessage interface{}, privatePublic string) error {
 
 	doSend := func(message interface{}) error {
-		bytes, err := json.Marshal(message)
-		if err != nil {
-			return err
-		}
-
-		if client.verbose {
-			log.Printf("SEND %7s: %s", privatePublic, string(bytes))
+		conn := client.privateWs
+		if privatePublic == "public" {
+			conn = client.publicWs
 		}
 
-		if privatePublic == "public" {
-			return client.publicWs.WriteJSON(message)
+		if conn == nil {
+			return fmt.Errorf("not connected to %s websocket", privatePublic)
 		}
-		return client.privateWs.WriteJSON(message)
+		return conn.WriteJSON(message)
 	}
 
 	switch message := rawMessage.(type) {
@@ -192,13 +317,28 @@ func (client *Client) send(rawMessage interface{}, privatePublic string) error {
 		if privatePublic == "private" {
 			message.Subscription.Token = client.privateToken
 		}
-		return doSend(message)
+
+		if err := client.subscriptions.subscribe(message, privatePublic == "private"); err != nil {
+			return err
+		}
+
+		if err := doSend(message); err != nil {
+			client.subscriptions.fail(message, err)
+			return err
+		}
+		return nil
 	case Unsubscribe:
 		message.Event = "unsubscribe"
 		if privatePublic == "private" {
 			message.Subscription.Token = client.privateToken
 		}
-		return doSend(message)
+
+		if err := doSend(message); err != nil {
+			return err
+		}
+
+		client.subscriptions.unsubscribe(message)
+		return nil
 	case AddOrder:
 		message.Event = "addOrder"
 		message.Token = client.privateToken
@@ -0,0 +1,53 @@
+package websocketv2
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+)
+
+// Messages converts the message to websocket.Book snapshots or websocket.BookUpdate updates,
+// so it can be passed to a websocket.BookManager. Depth should match the subscription.
+func (message BookMessage) Messages(depth int) []interface{} {
+	channelName := fmt.Sprintf("book-%d", depth)
+
+	var messages []interface{}
+	for _, data := range message.Data {
+		if message.Type == "snapshot" {
+			messages = append(messages, websocket.Book{
+				ChannelName: channelName,
+				Pair:        data.Symbol,
+				Data: websocket.BookData{
+					Asks: priceLevels(data.Asks, data.Timestamp),
+					Bids: priceLevels(data.Bids, data.Timestamp),
+				},
+			})
+			continue
+		}
+
+		messages = append(messages, websocket.BookUpdate{
+			ChannelName: channelName,
+			Pair:        data.Symbol,
+			Data: websocket.BookUpdateData{
+				Asks:     priceLevels(data.Asks, data.Timestamp),
+				Bids:     priceLevels(data.Bids, data.Timestamp),
+				Checksum: websocket.Int64String(data.Checksum),
+			},
+		})
+	}
+	return messages
+}
+
+func priceLevels(levels []BookLevel, timestamp time.Time) []websocket.PriceLevel {
+	// non-nil, so empty snapshot sides are not mistaken for an update
+	converted := make([]websocket.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		converted = append(converted, websocket.PriceLevel{
+			Price:     websocket.Float64String(level.Price),
+			Volume:    websocket.Float64String(level.Qty),
+			Timestamp: websocket.UnixTime(timestamp),
+		})
+	}
+	return converted
+}
@@ -0,0 +1,59 @@
+package websocketv2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookMessageWithBookManager(t *testing.T) {
+	manager := websocket.NewBookManager(nil, 10)
+	manager.SetPrecision("BTC/USD", 1, 8)
+
+	snapshot := BookMessage{Type: "snapshot", Data: []BookData{{
+		Symbol: "BTC/USD",
+		Bids:   []BookLevel{{Price: 100.0, Qty: 1.0}, {Price: 99.5, Qty: 2.0}},
+		Asks:   []BookLevel{{Price: 100.5, Qty: 1.5}},
+	}}}
+
+	for _, message := range snapshot.Messages(10) {
+		assert.Nil(t, manager.Handle(message))
+	}
+
+	update := websocket.BookUpdate{
+		ChannelName: "book-10",
+		Pair:        "BTC/USD",
+		Data:        websocket.BookUpdateData{Bids: []websocket.PriceLevel{{Price: 99.5, Volume: 0}}},
+	}
+	book, ok := manager.Snapshot("BTC/USD")
+	assert.True(t, ok)
+	book.Update(update)
+
+	timestamp := time.Date(2023, 10, 6, 17, 35, 55, 0, time.UTC)
+	updateMessage := BookMessage{Type: "update", Data: []BookData{{
+		Symbol:    "BTC/USD",
+		Bids:      []BookLevel{{Price: 99.5, Qty: 0}},
+		Asks:      []BookLevel{},
+		Checksum:  book.Checksum(1, 8),
+		Timestamp: timestamp,
+	}}}
+
+	for _, message := range updateMessage.Messages(10) {
+		assert.Nil(t, manager.Handle(message))
+	}
+
+	book, ok = manager.Snapshot("BTC/USD")
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(book.Data.Bids))
+
+	exchangeTime, _, ok := manager.LastUpdate("BTC/USD")
+	assert.True(t, ok)
+	assert.Equal(t, timestamp, exchangeTime)
+
+	updateMessage.Data[0].Checksum++
+	for _, message := range updateMessage.Messages(10) {
+		assert.IsType(t, websocket.BookOutOfSyncError{}, manager.Handle(message))
+	}
+}
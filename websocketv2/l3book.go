@@ -0,0 +1,396 @@
+package websocketv2
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+)
+
+// L3Book maintains the individual orders of a level3 subscription.
+// Orders at the same price are kept in time priority.
+type L3Book struct {
+	Symbol string
+	orders map[string]*l3Entry
+	bids   map[float64][]string
+	asks   map[float64][]string
+
+	hasPrecision  bool
+	priceDecimals int
+	qtyDecimals   int
+
+	undo *l3Undo
+}
+
+type l3Entry struct {
+	side  string
+	order Level3Order
+}
+
+type l3Level struct {
+	side  string
+	price float64
+}
+
+// l3Undo holds the state before a message of the orders and levels it touched, nil if they did not exist.
+// Queues are replaced rather than modified in place, so saving the slice is enough.
+type l3Undo struct {
+	orders map[string]*l3Entry
+	levels map[l3Level][]string
+	reset  *L3Book
+}
+
+// QueuePosition describes how much resting volume is ahead of an order at its price level.
+type QueuePosition struct {
+	Side        string
+	Price       float64
+	OrdersAhead int
+	VolumeAhead float64
+	Volume      float64
+}
+
+func NewL3Book(symbol string) *L3Book {
+	book := &L3Book{Symbol: symbol}
+	book.reset()
+	return book
+}
+
+func (book *L3Book) reset() {
+	book.orders = make(map[string]*l3Entry)
+	book.bids = make(map[float64][]string)
+	book.asks = make(map[float64][]string)
+}
+
+// SetPrecision enables checksum validation with the price and quantity decimals of the symbol.
+func (book *L3Book) SetPrecision(priceDecimals, qtyDecimals int) {
+	book.hasPrecision = true
+	book.priceDecimals = priceDecimals
+	book.qtyDecimals = qtyDecimals
+}
+
+func (book *L3Book) touchOrder(orderID string) {
+	if book.undo == nil || book.undo.reset != nil {
+		return
+	}
+	if _, ok := book.undo.orders[orderID]; ok {
+		return
+	}
+
+	var saved *l3Entry
+	if entry, ok := book.orders[orderID]; ok {
+		copied := *entry
+		saved = &copied
+	}
+	book.undo.orders[orderID] = saved
+}
+
+func (book *L3Book) touchLevel(side string, price float64) {
+	if book.undo == nil || book.undo.reset != nil {
+		return
+	}
+
+	level := l3Level{side: side, price: price}
+	if _, ok := book.undo.levels[level]; !ok {
+		book.undo.levels[level] = book.side(side)[price]
+	}
+}
+
+func (book *L3Book) rollback() {
+	undo := book.undo
+	if undo.reset != nil {
+		book.orders, book.bids, book.asks = undo.reset.orders, undo.reset.bids, undo.reset.asks
+	}
+
+	for orderID, entry := range undo.orders {
+		if entry == nil {
+			delete(book.orders, orderID)
+		} else {
+			book.orders[orderID] = entry
+		}
+	}
+
+	for level, queue := range undo.levels {
+		if queue == nil {
+			delete(book.side(level.side), level.price)
+		} else {
+			book.side(level.side)[level.price] = queue
+		}
+	}
+}
+
+func (book *L3Book) side(side string) map[float64][]string {
+	if side == "bid" {
+		return book.bids
+	}
+	return book.asks
+}
+
+// Handle applies a level3 message, data of other symbols is ignored. The message is applied completely or not at
+// all, a checksum mismatch returns a websocket.BookOutOfSyncError after which the book should be resubscribed.
+func (book *L3Book) Handle(message Level3Message) error {
+	book.undo = &l3Undo{orders: make(map[string]*l3Entry), levels: make(map[l3Level][]string)}
+	defer func() { book.undo = nil }()
+
+	if err := book.handle(message); err != nil {
+		book.rollback()
+		return err
+	}
+	return nil
+}
+
+func (book *L3Book) handle(message Level3Message) error {
+	for _, data := range message.Data {
+		if data.Symbol != book.Symbol {
+			continue
+		}
+
+		if err := book.applyData(message.Type, data); err != nil {
+			return err
+		}
+
+		if book.hasPrecision && data.Checksum != 0 {
+			if checksum := book.Checksum(book.priceDecimals, book.qtyDecimals); checksum != data.Checksum {
+				reason := fmt.Sprintf("checksum %d does not match expected %d", checksum, data.Checksum)
+				return websocket.BookOutOfSyncError{Pair: book.Symbol, Reason: reason}
+			}
+		}
+	}
+	return nil
+}
+
+func (book *L3Book) applyData(messageType string, data Level3Data) error {
+	if messageType == "snapshot" {
+		if book.undo != nil && book.undo.reset == nil {
+			book.undo.reset = &L3Book{orders: book.orders, bids: book.bids, asks: book.asks}
+		}
+		book.reset()
+
+		book.addSorted("bid", data.Bids)
+		book.addSorted("ask", data.Asks)
+		return nil
+	}
+
+	for _, order := range data.Bids {
+		if err := book.apply("bid", order); err != nil {
+			return err
+		}
+	}
+
+	for _, order := range data.Asks {
+		if err := book.apply("ask", order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (book *L3Book) addSorted(side string, orders []Level3Order) {
+	sorted := append([]Level3Order(nil), orders...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	for _, order := range sorted {
+		book.add(side, order)
+	}
+}
+
+func (book *L3Book) apply(side string, order Level3Order) error {
+	switch order.Event {
+	case "add", "":
+		book.add(side, order)
+		return nil
+	case "modify":
+		entry, ok := book.orders[order.OrderID]
+		if !ok {
+			return fmt.Errorf("modify of unknown order %s", order.OrderID)
+		}
+
+		// a modify keeps the time priority
+		book.touchOrder(order.OrderID)
+		entry.order.OrderQty = order.OrderQty
+		if !order.Timestamp.IsZero() {
+			entry.order.Timestamp = order.Timestamp
+		}
+		return nil
+	case "delete":
+		if !book.remove(order.OrderID) {
+			return fmt.Errorf("delete of unknown order %s", order.OrderID)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown level3 event %s", order.Event)
+	}
+}
+
+func (book *L3Book) add(side string, order Level3Order) {
+	book.remove(order.OrderID)
+
+	order.Event = ""
+	book.touchOrder(order.OrderID)
+	book.touchLevel(side, order.LimitPrice)
+	book.orders[order.OrderID] = &l3Entry{side: side, order: order}
+
+	levels := book.side(side)
+	levels[order.LimitPrice] = append(levels[order.LimitPrice], order.OrderID)
+}
+
+func (book *L3Book) remove(orderID string) bool {
+	entry, ok := book.orders[orderID]
+	if !ok {
+		return false
+	}
+
+	book.touchOrder(orderID)
+	book.touchLevel(entry.side, entry.order.LimitPrice)
+	delete(book.orders, orderID)
+
+	levels := book.side(entry.side)
+	price := entry.order.LimitPrice
+
+	queue := levels[price]
+	for index, id := range queue {
+		if id == orderID {
+			queue = append(queue[:index:index], queue[index+1:]...)
+			break
+		}
+	}
+
+	if len(queue) == 0 {
+		delete(levels, price)
+	} else {
+		levels[price] = queue
+	}
+	return true
+}
+
+// Order returns the order and its side, which is "bid" or "ask".
+func (book *L3Book) Order(orderID string) (Level3Order, string, bool) {
+	entry, ok := book.orders[orderID]
+	if !ok {
+		return Level3Order{}, "", false
+	}
+	return entry.order, entry.side, true
+}
+
+// Level returns the orders at price in time priority.
+func (book *L3Book) Level(side string, price float64) []Level3Order {
+	var orders []Level3Order
+	for _, orderID := range book.side(side)[price] {
+		orders = append(orders, book.orders[orderID].order)
+	}
+	return orders
+}
+
+// QueuePosition estimates the queue position of a resting order from the orders ahead of it at its price.
+func (book *L3Book) QueuePosition(orderID string) (QueuePosition, bool) {
+	entry, ok := book.orders[orderID]
+	if !ok {
+		return QueuePosition{}, false
+	}
+
+	position := QueuePosition{
+		Side:   entry.side,
+		Price:  entry.order.LimitPrice,
+		Volume: entry.order.OrderQty,
+	}
+
+	for _, id := range book.side(entry.side)[entry.order.LimitPrice] {
+		if id == orderID {
+			break
+		}
+		position.OrdersAhead++
+		position.VolumeAhead += book.orders[id].order.OrderQty
+	}
+	return position, true
+}
+
+// OwnQueuePositions returns the queue position of each open order that rests in the book, keyed by txid.
+func (book *L3Book) OwnQueuePositions(openOrders *websocket.OpenOrders) map[string]QueuePosition {
+	positions := make(map[string]QueuePosition)
+
+	for txid := range openOrders.Orders {
+		if position, ok := book.QueuePosition(txid); ok {
+			positions[txid] = position
+		}
+	}
+	return positions
+}
+
+// Book aggregates the orders to price levels, depth 0 includes all levels.
+func (book *L3Book) Book(depth int) websocket.Book {
+	return websocket.Book{
+		ChannelName: fmt.Sprintf("book-%d", depth),
+		Pair:        book.Symbol,
+		Data: websocket.BookData{
+			Asks: book.aggregate(book.asks, depth, func(a, b float64) bool { return a < b }),
+			Bids: book.aggregate(book.bids, depth, func(a, b float64) bool { return a > b }),
+		},
+	}
+}
+
+func sortedPrices(levels map[float64][]string, depth int, less func(a, b float64) bool) []float64 {
+	prices := make([]float64, 0, len(levels))
+	for price := range levels {
+		prices = append(prices, price)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return less(prices[i], prices[j])
+	})
+
+	if depth > 0 && len(prices) > depth {
+		prices = prices[:depth]
+	}
+	return prices
+}
+
+func (book *L3Book) aggregate(levels map[float64][]string, depth int, less func(a, b float64) bool) []websocket.PriceLevel {
+	prices := sortedPrices(levels, depth, less)
+
+	aggregated := make([]websocket.PriceLevel, 0, len(prices))
+	for _, price := range prices {
+		var volume float64
+		var timestamp time.Time
+
+		for _, orderID := range levels[price] {
+			order := book.orders[orderID].order
+			volume += order.OrderQty
+
+			if order.Timestamp.After(timestamp) {
+				timestamp = order.Timestamp
+			}
+		}
+
+		aggregated = append(aggregated, websocket.PriceLevel{
+			Price:     websocket.Float64String(price),
+			Volume:    websocket.Float64String(volume),
+			Timestamp: websocket.UnixTime(timestamp),
+		})
+	}
+	return aggregated
+}
+
+// Checksum computes the CRC32 checksum Kraken sends with level3 data, over the orders of the best 10 ask and
+// bid levels in time priority.
+func (book *L3Book) Checksum(priceDecimals, qtyDecimals int) uint32 {
+	var builder strings.Builder
+
+	write := func(levels map[float64][]string, less func(a, b float64) bool) {
+		for _, price := range sortedPrices(levels, 10, less) {
+			for _, orderID := range levels[price] {
+				order := book.orders[orderID].order
+				builder.WriteString(websocket.ChecksumField(order.LimitPrice, priceDecimals))
+				builder.WriteString(websocket.ChecksumField(order.OrderQty, qtyDecimals))
+			}
+		}
+	}
+
+	write(book.asks, func(a, b float64) bool { return a < b })
+	write(book.bids, func(a, b float64) bool { return a > b })
+
+	return crc32.ChecksumIEEE([]byte(builder.String()))
+}
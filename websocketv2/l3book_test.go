@@ -0,0 +1,156 @@
+package websocketv2
+
+import (
+	"errors"
+	"hash/crc32"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestL3Book(t *testing.T) {
+	t0 := time.Date(2023, 10, 6, 17, 35, 55, 0, time.UTC)
+
+	book := NewL3Book("BTC/USD")
+
+	err := book.Handle(Level3Message{Type: "snapshot", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Bids: []Level3Order{
+			{OrderID: "B2", LimitPrice: 100, OrderQty: 2, Timestamp: t0.Add(2 * time.Second)},
+			{OrderID: "B1", LimitPrice: 100, OrderQty: 1, Timestamp: t0.Add(time.Second)},
+			{OrderID: "B3", LimitPrice: 99, OrderQty: 5, Timestamp: t0},
+		},
+		Asks: []Level3Order{
+			{OrderID: "A1", LimitPrice: 101, OrderQty: 3, Timestamp: t0},
+		},
+	}}})
+	assert.Nil(t, err)
+
+	err = book.Handle(Level3Message{Type: "update", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Bids: []Level3Order{
+			{Event: "add", OrderID: "OWN-ORDER", LimitPrice: 100, OrderQty: 4, Timestamp: t0.Add(3 * time.Second)},
+			{Event: "modify", OrderID: "B1", LimitPrice: 100, OrderQty: 0.5, Timestamp: t0.Add(4 * time.Second)},
+		},
+		Asks: []Level3Order{
+			{Event: "delete", OrderID: "A1", LimitPrice: 101},
+			{Event: "add", OrderID: "A2", LimitPrice: 102, OrderQty: 1, Timestamp: t0},
+		},
+	}}})
+	assert.Nil(t, err)
+
+	position, ok := book.QueuePosition("OWN-ORDER")
+	assert.True(t, ok)
+	assert.Equal(t, QueuePosition{Side: "bid", Price: 100, OrdersAhead: 2, VolumeAhead: 2.5, Volume: 4}, position)
+
+	openOrders := &websocket.OpenOrders{Orders: map[string]websocket.OpenOrder{"OWN-ORDER": {}, "OTHER": {}}}
+	assert.Equal(t, map[string]QueuePosition{"OWN-ORDER": position}, book.OwnQueuePositions(openOrders))
+
+	assert.Equal(t, websocket.Book{
+		ChannelName: "book-10",
+		Pair:        "BTC/USD",
+		Data: websocket.BookData{
+			Asks: []websocket.PriceLevel{{Price: 102, Volume: 1, Timestamp: websocket.UnixTime(t0)}},
+			Bids: []websocket.PriceLevel{
+				{Price: 100, Volume: 6.5, Timestamp: websocket.UnixTime(t0.Add(4 * time.Second))},
+				{Price: 99, Volume: 5, Timestamp: websocket.UnixTime(t0)},
+			},
+		},
+	}, book.Book(10))
+
+	// deleting an order ahead moves ours forward
+	assert.Nil(t, book.Handle(Level3Message{Type: "update", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Bids:   []Level3Order{{Event: "delete", OrderID: "B1"}},
+	}}}))
+
+	position, _ = book.QueuePosition("OWN-ORDER")
+	assert.Equal(t, 1, position.OrdersAhead)
+	assert.Equal(t, []string{"B2", "OWN-ORDER"}, levelIDs(book.Level("bid", 100)))
+
+	err = book.Handle(Level3Message{Type: "update", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Asks:   []Level3Order{{Event: "delete", OrderID: "A1"}},
+	}}})
+	assert.Equal(t, errors.New("delete of unknown order A1"), err)
+}
+
+func TestL3BookChecksum(t *testing.T) {
+	t0 := time.Date(2023, 10, 6, 17, 35, 55, 0, time.UTC)
+
+	book := NewL3Book("BTC/USD")
+	book.SetPrecision(1, 8)
+
+	// asks first, then bids, each order in time priority
+	checksum := crc32.ChecksumIEEE([]byte("1010300000000" + "1000100000000" + "1000200000000"))
+
+	err := book.Handle(Level3Message{Type: "snapshot", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Bids: []Level3Order{
+			{OrderID: "B2", LimitPrice: 100, OrderQty: 2, Timestamp: t0.Add(time.Second)},
+			{OrderID: "B1", LimitPrice: 100, OrderQty: 1, Timestamp: t0},
+		},
+		Asks:     []Level3Order{{OrderID: "A1", LimitPrice: 101, OrderQty: 3, Timestamp: t0}},
+		Checksum: checksum,
+	}}})
+	assert.Nil(t, err)
+	assert.Equal(t, checksum, book.Checksum(1, 8))
+
+	// a mismatch leaves the book as it was
+	err = book.Handle(Level3Message{Type: "update", Data: []Level3Data{{
+		Symbol:   "BTC/USD",
+		Bids:     []Level3Order{{Event: "delete", OrderID: "B1"}},
+		Checksum: checksum,
+	}}})
+	assert.IsType(t, websocket.BookOutOfSyncError{}, err)
+	assert.Equal(t, []string{"B1", "B2"}, levelIDs(book.Level("bid", 100)))
+
+	// as does an event that can not be applied
+	err = book.Handle(Level3Message{Type: "update", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Bids:   []Level3Order{{Event: "delete", OrderID: "B1"}},
+		Asks:   []Level3Order{{Event: "modify", OrderID: "A2", OrderQty: 1}},
+	}}})
+	assert.Equal(t, errors.New("modify of unknown order A2"), err)
+	assert.Equal(t, []string{"B1", "B2"}, levelIDs(book.Level("bid", 100)))
+
+	before := book.Book(0)
+
+	// added orders, new levels and modified quantities are undone
+	err = book.Handle(Level3Message{Type: "update", Data: []Level3Data{{
+		Symbol: "BTC/USD",
+		Bids: []Level3Order{
+			{Event: "modify", OrderID: "B2", OrderQty: 0.5},
+			{Event: "add", OrderID: "B3", LimitPrice: 100, OrderQty: 1, Timestamp: t0.Add(2 * time.Second)},
+		},
+		Asks: []Level3Order{
+			{Event: "add", OrderID: "A2", LimitPrice: 102, OrderQty: 1, Timestamp: t0},
+			{Event: "delete", OrderID: "A3"},
+		},
+	}}})
+	assert.Equal(t, errors.New("delete of unknown order A3"), err)
+	assert.Equal(t, before, book.Book(0))
+	assert.Equal(t, []string{"B1", "B2"}, levelIDs(book.Level("bid", 100)))
+	_, _, ok := book.Order("A2")
+	assert.False(t, ok)
+
+	// as is a snapshot
+	err = book.Handle(Level3Message{Type: "snapshot", Data: []Level3Data{{
+		Symbol:   "BTC/USD",
+		Asks:     []Level3Order{{OrderID: "A4", LimitPrice: 105, OrderQty: 1, Timestamp: t0}},
+		Checksum: checksum,
+	}}})
+	assert.IsType(t, websocket.BookOutOfSyncError{}, err)
+	assert.Equal(t, before, book.Book(0))
+	assert.Equal(t, checksum, book.Checksum(1, 8))
+}
+
+func levelIDs(orders []Level3Order) []string {
+	var ids []string
+	for _, order := range orders {
+		ids = append(ids, order.OrderID)
+	}
+	return ids
+}
@@ -0,0 +1,293 @@
+package websocketv2
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Request struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+	ReqID  int64       `json:"req_id,omitempty"`
+}
+
+type SubscribeParams struct {
+	Channel      string   `json:"channel"`
+	Symbol       []string `json:"symbol,omitempty"`
+	Depth        int      `json:"depth,omitempty"`
+	Interval     int      `json:"interval,omitempty"`
+	EventTrigger string   `json:"event_trigger,omitempty"`
+	Snapshot     *bool    `json:"snapshot,omitempty"`
+	SnapOrders   *bool    `json:"snap_orders,omitempty"`
+	SnapTrades   *bool    `json:"snap_trades,omitempty"`
+	Token        string   `json:"token,omitempty"`
+}
+
+type AddOrderParams struct {
+	OrderType    string  `json:"order_type"`
+	Side         string  `json:"side"`
+	OrderQty     float64 `json:"order_qty"`
+	Symbol       string  `json:"symbol"`
+	LimitPrice   float64 `json:"limit_price,omitempty"`
+	TimeInForce  string  `json:"time_in_force,omitempty"`
+	PostOnly     bool    `json:"post_only,omitempty"`
+	ReduceOnly   bool    `json:"reduce_only,omitempty"`
+	Margin       bool    `json:"margin,omitempty"`
+	ClOrdID      string  `json:"cl_ord_id,omitempty"`
+	OrderUserRef int64   `json:"order_userref,omitempty"`
+	Validate     bool    `json:"validate,omitempty"`
+	Token        string  `json:"token"`
+}
+
+type AmendOrderParams struct {
+	OrderID    string  `json:"order_id,omitempty"`
+	ClOrdID    string  `json:"cl_ord_id,omitempty"`
+	OrderQty   float64 `json:"order_qty"`
+	LimitPrice float64 `json:"limit_price,omitempty"`
+	PostOnly   bool    `json:"post_only,omitempty"`
+	Token      string  `json:"token"`
+}
+
+type CancelOrderParams struct {
+	OrderID      []string `json:"order_id,omitempty"`
+	ClOrdID      []string `json:"cl_ord_id,omitempty"`
+	OrderUserRef []int64  `json:"order_userref,omitempty"`
+	Token        string   `json:"token"`
+}
+
+type BatchOrder struct {
+	OrderType    string  `json:"order_type"`
+	Side         string  `json:"side"`
+	OrderQty     float64 `json:"order_qty"`
+	LimitPrice   float64 `json:"limit_price,omitempty"`
+	TimeInForce  string  `json:"time_in_force,omitempty"`
+	PostOnly     bool    `json:"post_only,omitempty"`
+	ReduceOnly   bool    `json:"reduce_only,omitempty"`
+	ClOrdID      string  `json:"cl_ord_id,omitempty"`
+	OrderUserRef int64   `json:"order_userref,omitempty"`
+}
+
+type BatchAddParams struct {
+	Symbol   string       `json:"symbol"`
+	Orders   []BatchOrder `json:"orders"`
+	Validate bool         `json:"validate,omitempty"`
+	Token    string       `json:"token"`
+}
+
+// Response is the reply to a request, Result depends on the method.
+type Response struct {
+	Method  string          `json:"method"`
+	Result  json.RawMessage `json:"result"`
+	Success bool            `json:"success"`
+	Error   string          `json:"error"`
+	ReqID   int64           `json:"req_id"`
+	TimeIn  time.Time       `json:"time_in"`
+	TimeOut time.Time       `json:"time_out"`
+}
+
+type OrderResult struct {
+	OrderID      string `json:"order_id"`
+	ClOrdID      string `json:"cl_ord_id"`
+	OrderUserRef int64  `json:"order_userref"`
+}
+
+type Heartbeat struct{}
+
+type Status struct {
+	Type string       `json:"type"`
+	Data []StatusData `json:"data"`
+}
+
+type StatusData struct {
+	APIVersion   string `json:"api_version"`
+	ConnectionID uint64 `json:"connection_id"`
+	System       string `json:"system"`
+	Version      string `json:"version"`
+}
+
+type TickerMessage struct {
+	Type string   `json:"type"`
+	Data []Ticker `json:"data"`
+}
+
+type Ticker struct {
+	Symbol    string  `json:"symbol"`
+	Bid       float64 `json:"bid"`
+	BidQty    float64 `json:"bid_qty"`
+	Ask       float64 `json:"ask"`
+	AskQty    float64 `json:"ask_qty"`
+	Last      float64 `json:"last"`
+	Volume    float64 `json:"volume"`
+	VWAP      float64 `json:"vwap"`
+	Low       float64 `json:"low"`
+	High      float64 `json:"high"`
+	Change    float64 `json:"change"`
+	ChangePct float64 `json:"change_pct"`
+}
+
+type BookMessage struct {
+	Type string     `json:"type"`
+	Data []BookData `json:"data"`
+}
+
+type BookData struct {
+	Symbol    string      `json:"symbol"`
+	Bids      []BookLevel `json:"bids"`
+	Asks      []BookLevel `json:"asks"`
+	Checksum  uint32      `json:"checksum"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+type BookLevel struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+type Level3Message struct {
+	Type string       `json:"type"`
+	Data []Level3Data `json:"data"`
+}
+
+type Level3Data struct {
+	Symbol   string        `json:"symbol"`
+	Bids     []Level3Order `json:"bids"`
+	Asks     []Level3Order `json:"asks"`
+	Checksum uint32        `json:"checksum"`
+}
+
+type Level3Order struct {
+	Event      string    `json:"event"`
+	OrderID    string    `json:"order_id"`
+	LimitPrice float64   `json:"limit_price"`
+	OrderQty   float64   `json:"order_qty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+type OHLCMessage struct {
+	Type string `json:"type"`
+	Data []OHLC `json:"data"`
+}
+
+type OHLC struct {
+	Symbol        string    `json:"symbol"`
+	Open          float64   `json:"open"`
+	High          float64   `json:"high"`
+	Low           float64   `json:"low"`
+	Close         float64   `json:"close"`
+	Trades        int64     `json:"trades"`
+	Volume        float64   `json:"volume"`
+	VWAP          float64   `json:"vwap"`
+	IntervalBegin time.Time `json:"interval_begin"`
+	Interval      int       `json:"interval"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type TradeMessage struct {
+	Type string  `json:"type"`
+	Data []Trade `json:"data"`
+}
+
+type Trade struct {
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"`
+	Price     float64   `json:"price"`
+	Qty       float64   `json:"qty"`
+	OrdType   string    `json:"ord_type"`
+	TradeID   int64     `json:"trade_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type InstrumentMessage struct {
+	Type string         `json:"type"`
+	Data InstrumentData `json:"data"`
+}
+
+type InstrumentData struct {
+	Assets []InstrumentAsset `json:"assets"`
+	Pairs  []InstrumentPair  `json:"pairs"`
+}
+
+type InstrumentAsset struct {
+	ID               string  `json:"id"`
+	Status           string  `json:"status"`
+	Precision        int     `json:"precision"`
+	PrecisionDisplay int     `json:"precision_display"`
+	Borrowable       bool    `json:"borrowable"`
+	CollateralValue  float64 `json:"collateral_value"`
+	MarginRate       float64 `json:"margin_rate"`
+}
+
+type InstrumentPair struct {
+	Symbol         string  `json:"symbol"`
+	Base           string  `json:"base"`
+	Quote          string  `json:"quote"`
+	Status         string  `json:"status"`
+	QtyPrecision   int     `json:"qty_precision"`
+	QtyIncrement   float64 `json:"qty_increment"`
+	QtyMin         float64 `json:"qty_min"`
+	PricePrecision int     `json:"price_precision"`
+	PriceIncrement float64 `json:"price_increment"`
+	CostPrecision  int     `json:"cost_precision"`
+	CostMin        float64 `json:"cost_min"`
+	TickSize       float64 `json:"tick_size"`
+	Marginable     bool    `json:"marginable"`
+	HasIndex       bool    `json:"has_index"`
+}
+
+type ExecutionsMessage struct {
+	Type     string      `json:"type"`
+	Data     []Execution `json:"data"`
+	Sequence int64       `json:"sequence"`
+}
+
+type Execution struct {
+	ExecType     string    `json:"exec_type"`
+	OrderID      string    `json:"order_id"`
+	ClOrdID      string    `json:"cl_ord_id"`
+	OrderUserRef int64     `json:"order_userref"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"`
+	OrderType    string    `json:"order_type"`
+	OrderQty     float64   `json:"order_qty"`
+	LimitPrice   float64   `json:"limit_price"`
+	TimeInForce  string    `json:"time_in_force"`
+	OrderStatus  string    `json:"order_status"`
+	CumQty       float64   `json:"cum_qty"`
+	CumCost      float64   `json:"cum_cost"`
+	AvgPrice     float64   `json:"avg_price"`
+	ExecID       string    `json:"exec_id"`
+	TradeID      int64     `json:"trade_id"`
+	LastQty      float64   `json:"last_qty"`
+	LastPrice    float64   `json:"last_price"`
+	Cost         float64   `json:"cost"`
+	LiquidityInd string    `json:"liquidity_ind"`
+	Fees         []Fee     `json:"fees"`
+	Reason       string    `json:"reason"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+type Fee struct {
+	Asset string  `json:"asset"`
+	Qty   float64 `json:"qty"`
+}
+
+type BalancesMessage struct {
+	Type     string    `json:"type"`
+	Data     []Balance `json:"data"`
+	Sequence int64     `json:"sequence"`
+}
+
+// Balance holds a balance from the snapshot, or a ledger entry from an update.
+type Balance struct {
+	Asset      string    `json:"asset"`
+	AssetClass string    `json:"asset_class"`
+	Balance    float64   `json:"balance"`
+	LedgerID   string    `json:"ledger_id"`
+	RefID      string    `json:"ref_id"`
+	Type       string    `json:"type"`
+	Category   string    `json:"category"`
+	WalletType string    `json:"wallet_type"`
+	Amount     float64   `json:"amount"`
+	Fee        float64   `json:"fee"`
+	Timestamp  time.Time `json:"timestamp"`
+}
@@ -0,0 +1,53 @@
+package websocketv2
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type envelope struct {
+	Method  string `json:"method"`
+	Channel string `json:"channel"`
+}
+
+func unmarshalReceivedMessage(bytes []byte) (interface{}, error) {
+	var header envelope
+
+	if err := json.Unmarshal(bytes, &header); err != nil {
+		return nil, fmt.Errorf("parsing message failed: %w", err)
+	}
+
+	if header.Method != "" {
+		var response Response
+		if err := json.Unmarshal(bytes, &response); err != nil {
+			return nil, fmt.Errorf("parsing %s response failed: %w", header.Method, err)
+		}
+		return response, nil
+	}
+
+	targetMap := map[string]interface{}{
+		"balances":   &BalancesMessage{},
+		"book":       &BookMessage{},
+		"executions": &ExecutionsMessage{},
+		"heartbeat":  &Heartbeat{},
+		"instrument": &InstrumentMessage{},
+		"level3":     &Level3Message{},
+		"ohlc":       &OHLCMessage{},
+		"status":     &Status{},
+		"ticker":     &TickerMessage{},
+		"trade":      &TradeMessage{},
+	}
+
+	target, ok := targetMap[header.Channel]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown channel %s", header.Channel)
+	}
+
+	if err := json.Unmarshal(bytes, target); err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", header.Channel, err)
+	}
+
+	return reflect.Indirect(reflect.ValueOf(target)).Interface(), nil
+}
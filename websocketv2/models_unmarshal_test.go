@@ -0,0 +1,185 @@
+package websocketv2
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalReceivedMessage(t *testing.T) {
+
+	type testCase struct {
+		name          string
+		bytes         []byte
+		expectedModel interface{}
+		expectedError error
+	}
+
+	timestamp := time.Date(2023, 10, 6, 17, 35, 55, 440295000, time.UTC)
+
+	testCases := []testCase{
+		{
+			name:          "heartbeat",
+			bytes:         []byte(`{"channel":"heartbeat"}`),
+			expectedModel: Heartbeat{},
+		},
+		{
+			name:  "status",
+			bytes: []byte(`{"channel":"status","type":"update","data":[{"version":"2.0.0","system":"online","api_version":"v2","connection_id":8628615390848610000}]}`),
+			expectedModel: Status{
+				Type: "update",
+				Data: []StatusData{{APIVersion: "v2", ConnectionID: 8628615390848610000, System: "online", Version: "2.0.0"}},
+			},
+		},
+		{
+			name: "subscribe response",
+			bytes: []byte(`{"method":"subscribe","result":{"channel":"ticker","snapshot":true,"symbol":"ALGO/USD"},"success":true,` +
+				`"time_in":"2023-09-25T09:04:31.742599Z","time_out":"2023-09-25T09:04:31.742648Z","req_id":3}`),
+			expectedModel: Response{
+				Method:  "subscribe",
+				Result:  json.RawMessage(`{"channel":"ticker","snapshot":true,"symbol":"ALGO/USD"}`),
+				Success: true,
+				ReqID:   3,
+				TimeIn:  time.Date(2023, 9, 25, 9, 4, 31, 742599000, time.UTC),
+				TimeOut: time.Date(2023, 9, 25, 9, 4, 31, 742648000, time.UTC),
+			},
+		},
+		{
+			name: "ticker",
+			bytes: []byte(`{"channel":"ticker","type":"snapshot","data":[{"symbol":"ALGO/USD","bid":0.10025,"bid_qty":740.0,"ask":0.10036,` +
+				`"ask_qty":1361.44813783,"last":0.10035,"volume":997038.98383185,"vwap":0.10148,"low":0.09979,"high":0.10285,"change":-0.00017,"change_pct":-0.17}]}`),
+			expectedModel: TickerMessage{
+				Type: "snapshot",
+				Data: []Ticker{{
+					Symbol: "ALGO/USD", Bid: 0.10025, BidQty: 740.0, Ask: 0.10036, AskQty: 1361.44813783, Last: 0.10035,
+					Volume: 997038.98383185, VWAP: 0.10148, Low: 0.09979, High: 0.10285, Change: -0.00017, ChangePct: -0.17,
+				}},
+			},
+		},
+		{
+			name: "book update",
+			bytes: []byte(`{"channel":"book","type":"update","data":[{"symbol":"MATIC/USD","bids":[{"price":0.5657,"qty":1098.3947558}],` +
+				`"asks":[],"checksum":2114181697,"timestamp":"2023-10-06T17:35:55.440295Z"}]}`),
+			expectedModel: BookMessage{
+				Type: "update",
+				Data: []BookData{{
+					Symbol:    "MATIC/USD",
+					Bids:      []BookLevel{{Price: 0.5657, Qty: 1098.3947558}},
+					Asks:      []BookLevel{},
+					Checksum:  2114181697,
+					Timestamp: timestamp,
+				}},
+			},
+		},
+		{
+			name: "level3",
+			bytes: []byte(`{"channel":"level3","type":"update","data":[{"checksum":281817320,"symbol":"BTC/USD","bids":[{"event":"delete",` +
+				`"order_id":"O7SO4Y-RHRAK-GGAHJE","limit_price":36140.0,"order_qty":0.5,"timestamp":"2023-10-06T17:35:55.440295Z"}],"asks":[]}]}`),
+			expectedModel: Level3Message{
+				Type: "update",
+				Data: []Level3Data{{
+					Symbol:   "BTC/USD",
+					Bids:     []Level3Order{{Event: "delete", OrderID: "O7SO4Y-RHRAK-GGAHJE", LimitPrice: 36140.0, OrderQty: 0.5, Timestamp: timestamp}},
+					Asks:     []Level3Order{},
+					Checksum: 281817320,
+				}},
+			},
+		},
+		{
+			name: "trade",
+			bytes: []byte(`{"channel":"trade","type":"update","data":[{"symbol":"MATIC/USD","side":"buy","price":0.5147,"qty":6423.46326,` +
+				`"ord_type":"limit","trade_id":4665846,"timestamp":"2023-10-06T17:35:55.440295Z"}]}`),
+			expectedModel: TradeMessage{
+				Type: "update",
+				Data: []Trade{{Symbol: "MATIC/USD", Side: "buy", Price: 0.5147, Qty: 6423.46326, OrdType: "limit", TradeID: 4665846, Timestamp: timestamp}},
+			},
+		},
+		{
+			name: "instrument",
+			bytes: []byte(`{"channel":"instrument","type":"snapshot","data":{"assets":[{"id":"USD","status":"enabled","precision":4,` +
+				`"precision_display":2,"borrowable":true,"collateral_value":1.0,"margin_rate":0.0}],"pairs":[{"symbol":"EUR/USD","base":"EUR",` +
+				`"quote":"USD","status":"online","qty_precision":8,"qty_increment":0.00000001,"price_precision":5,"cost_precision":5,` +
+				`"marginable":false,"has_index":true,"cost_min":0.5,"tick_size":0.00001,"price_increment":0.00001,"qty_min":0.5}]}}`),
+			expectedModel: InstrumentMessage{
+				Type: "snapshot",
+				Data: InstrumentData{
+					Assets: []InstrumentAsset{{ID: "USD", Status: "enabled", Precision: 4, PrecisionDisplay: 2, Borrowable: true, CollateralValue: 1.0}},
+					Pairs: []InstrumentPair{{
+						Symbol: "EUR/USD", Base: "EUR", Quote: "USD", Status: "online", QtyPrecision: 8, QtyIncrement: 0.00000001,
+						QtyMin: 0.5, PricePrecision: 5, PriceIncrement: 0.00001, CostPrecision: 5, CostMin: 0.5, TickSize: 0.00001, HasIndex: true,
+					}},
+				},
+			},
+		},
+		{
+			name: "executions",
+			bytes: []byte(`{"channel":"executions","type":"update","data":[{"order_id":"OK4GJX-KSTLS-7DZZO5","symbol":"BTC/USD","exec_type":"trade",` +
+				`"exec_id":"TEL2GI-5LHBX-N7Z5UX","trade_id":1234,"side":"buy","order_type":"limit","last_qty":0.1,"last_price":26500.0,` +
+				`"cost":2650.0,"liquidity_ind":"m","fees":[{"asset":"USD","qty":4.24}],"order_status":"filled","cum_qty":0.1,` +
+				`"avg_price":26500.0,"timestamp":"2023-10-06T17:35:55.440295Z"}],"sequence":8}`),
+			expectedModel: ExecutionsMessage{
+				Type: "update",
+				Data: []Execution{{
+					ExecType: "trade", OrderID: "OK4GJX-KSTLS-7DZZO5", Symbol: "BTC/USD", Side: "buy", OrderType: "limit",
+					OrderStatus: "filled", CumQty: 0.1, AvgPrice: 26500.0, ExecID: "TEL2GI-5LHBX-N7Z5UX", TradeID: 1234,
+					LastQty: 0.1, LastPrice: 26500.0, Cost: 2650.0, LiquidityInd: "m", Fees: []Fee{{Asset: "USD", Qty: 4.24}},
+					Timestamp: timestamp,
+				}},
+				Sequence: 8,
+			},
+		},
+		{
+			name: "balances",
+			bytes: []byte(`{"channel":"balances","type":"update","data":[{"ledger_id":"DATKX6-PEHL1-HZKND8","ref_id":"LKAKN2-N3UCI-PYBHTK",` +
+				`"timestamp":"2023-10-06T17:35:55.440295Z","type":"trade","asset":"USD","asset_class":"currency","category":"trade",` +
+				`"wallet_type":"spot","amount":-19.9743,"fee":0.0,"balance":118.0}],"sequence":4}`),
+			expectedModel: BalancesMessage{
+				Type: "update",
+				Data: []Balance{{
+					Asset: "USD", AssetClass: "currency", Balance: 118.0, LedgerID: "DATKX6-PEHL1-HZKND8", RefID: "LKAKN2-N3UCI-PYBHTK",
+					Type: "trade", Category: "trade", WalletType: "spot", Amount: -19.9743, Timestamp: timestamp,
+				}},
+				Sequence: 4,
+			},
+		},
+		{
+			name:          "unknown channel",
+			bytes:         []byte(`{"channel":"foo"}`),
+			expectedError: errors.New("unknown channel foo"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			model, err := unmarshalReceivedMessage(testCase.bytes)
+
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedModel, model)
+		})
+	}
+}
+
+func TestResponseOrderResult(t *testing.T) {
+	response := Response{Method: "add_order", Success: true, Result: json.RawMessage(`{"order_id":"OK4GJX-KSTLS-7DZZO5","order_userref":3}`)}
+
+	result, err := response.OrderResult()
+	assert.Nil(t, err)
+	assert.Equal(t, OrderResult{OrderID: "OK4GJX-KSTLS-7DZZO5", OrderUserRef: 3}, result)
+
+	response = Response{Method: "add_order", Error: "EOrder:Insufficient funds"}
+	_, err = response.OrderResult()
+	assert.EqualError(t, err, "add_order failed: EOrder:Insufficient funds")
+}
+
+func TestClientRequiresConnection(t *testing.T) {
+	client := newClient()
+
+	_, err := client.AddOrder(AddOrderParams{OrderType: "market", Side: "buy", OrderQty: 1, Symbol: "BTC/USD"})
+	assert.EqualError(t, err, "not connected to private websocket")
+
+	_, err = client.Subscribe(SubscribeParams{Channel: "ticker", Symbol: []string{"BTC/USD"}})
+	assert.EqualError(t, err, "not connected to public websocket")
+}
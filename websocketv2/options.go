@@ -0,0 +1,24 @@
+package websocketv2
+
+type Option func(client *Client)
+
+// WithPublicURL connects to url instead of the public Kraken websocket, for example a mockserver.Server.
+func WithPublicURL(url string) Option {
+	return func(client *Client) {
+		client.publicURL = url
+	}
+}
+
+// WithPrivateURL connects to url instead of the private Kraken websocket.
+func WithPrivateURL(url string) Option {
+	return func(client *Client) {
+		client.privateURL = url
+	}
+}
+
+// WithLevel3URL connects to url instead of the level3 Kraken websocket.
+func WithLevel3URL(url string) Option {
+	return func(client *Client) {
+		client.level3URL = url
+	}
+}
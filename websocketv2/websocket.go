@@ -0,0 +1,234 @@
+package websocketv2
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/lk16/kraken/rest"
+	"github.com/lk16/kraken/websocket"
+)
+
+const (
+	publicWsURL       = "wss://ws.kraken.com/v2"
+	privateWsURL      = "wss://ws-auth.kraken.com/v2"
+	level3WsURL       = "wss://ws-l3.kraken.com/v2"
+	keepAliveDuration = 10 * time.Second
+)
+
+// channelConnections maps channels that are not public to their connection, level3 has its own endpoint
+var channelConnections = map[string]string{
+	"balances":   "private",
+	"executions": "private",
+	"level3":     "level3",
+}
+
+type Client struct {
+	publicWs     *websocket.Conn
+	privateWs    *websocket.Conn
+	level3Ws     *websocket.Conn
+	receiveChan  chan interface{}
+	verbose      bool
+	privateToken string
+	lastReqID    int64
+
+	publicURL  string
+	privateURL string
+	level3URL  string
+}
+
+func newClient(options ...Option) *Client {
+	client := &Client{
+		receiveChan: make(chan interface{}),
+		publicURL:   publicWsURL,
+		privateURL:  privateWsURL,
+		level3URL:   level3WsURL,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+func NewClient(options ...Option) (*Client, error) {
+	client := newClient(options...)
+
+	if err := client.ConnectWs("public"); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// ConnectWs connects to the "public", "private" or "level3" websocket, the latter two require a token.
+func (client *Client) ConnectWs(connection string) error {
+	url := client.publicURL
+	switch connection {
+	case "private":
+		url = client.privateURL
+	case "level3":
+		url = client.level3URL
+	}
+
+	conn, err := websocket.Dial(url, connection)
+	if err != nil {
+		return err
+	}
+
+	conn.SetVerbose(client.verbose)
+
+	switch connection {
+	case "public":
+		client.publicWs = conn
+	case "level3":
+		client.level3Ws = conn
+	default:
+		client.privateWs = conn
+	}
+
+	go client.wsListener(conn)
+	go conn.KeepAlive(keepAliveDuration, func() interface{} {
+		return Request{Method: "ping", ReqID: client.nextReqID()}
+	}, client.deliverError)
+	return nil
+}
+
+func (client *Client) SetVerbose(verbose bool) {
+	client.verbose = verbose
+
+	for _, conn := range []*websocket.Conn{client.publicWs, client.privateWs, client.level3Ws} {
+		if conn != nil {
+			conn.SetVerbose(verbose)
+		}
+	}
+}
+
+func (client *Client) LoadWebsocketToken(key string, secret string) error {
+	restClient := rest.NewClient()
+	if err := restClient.SetAuth(key, secret); err != nil {
+		return err
+	}
+
+	token, err := restClient.GetWebSocketsToken()
+	if err != nil {
+		return err
+	}
+
+	client.privateToken = token.Token
+	return nil
+}
+
+func (client *Client) wsListener(conn *websocket.Conn) {
+	conn.ReadLoop(func(frame []byte) {
+		model, err := unmarshalReceivedMessage(frame)
+
+		if err != nil {
+			client.deliverError(err)
+			return
+		}
+
+		client.receiveChan <- model
+	}, client.deliverError)
+}
+
+func (client *Client) deliverError(err error) {
+	client.receiveChan <- err
+}
+
+// Listen returns the channel through which all received messages and errors are delivered.
+func (client *Client) Listen() <-chan interface{} {
+	return client.receiveChan
+}
+
+func (client *Client) nextReqID() int64 {
+	return atomic.AddInt64(&client.lastReqID, 1)
+}
+
+// request sends method with params and returns the req_id, which Kraken echoes in the Response.
+func (client *Client) request(method string, params interface{}, connection string) (int64, error) {
+	conn := client.publicWs
+	switch connection {
+	case "private":
+		conn = client.privateWs
+	case "level3":
+		conn = client.level3Ws
+	}
+
+	if conn == nil {
+		return 0, fmt.Errorf("not connected to %s websocket", connection)
+	}
+
+	request := Request{Method: method, Params: params, ReqID: client.nextReqID()}
+	if err := conn.WriteJSON(request); err != nil {
+		return 0, err
+	}
+	return request.ReqID, nil
+}
+
+// Subscribe sends the subscription with the token over the connection of private and level3 channels.
+func (client *Client) Subscribe(params SubscribeParams) (int64, error) {
+	return client.subscription("subscribe", params)
+}
+
+func (client *Client) Unsubscribe(params SubscribeParams) (int64, error) {
+	return client.subscription("unsubscribe", params)
+}
+
+func (client *Client) subscription(method string, params SubscribeParams) (int64, error) {
+	connection := "public"
+	if authenticated, ok := channelConnections[params.Channel]; ok {
+		connection = authenticated
+		params.Token = client.privateToken
+	}
+	return client.request(method, params, connection)
+}
+
+func (client *Client) AddOrder(params AddOrderParams) (int64, error) {
+	params.Token = client.privateToken
+	return client.request("add_order", params, "private")
+}
+
+func (client *Client) AmendOrder(params AmendOrderParams) (int64, error) {
+	params.Token = client.privateToken
+	return client.request("amend_order", params, "private")
+}
+
+func (client *Client) CancelOrder(params CancelOrderParams) (int64, error) {
+	params.Token = client.privateToken
+	return client.request("cancel_order", params, "private")
+}
+
+func (client *Client) BatchAdd(params BatchAddParams) (int64, error) {
+	params.Token = client.privateToken
+	return client.request("batch_add", params, "private")
+}
+
+// OrderResult decodes the result of an add_order, amend_order or cancel_order response.
+func (response Response) OrderResult() (OrderResult, error) {
+	var result OrderResult
+
+	if !response.Success {
+		return result, fmt.Errorf("%s failed: %s", response.Method, response.Error)
+	}
+
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return result, fmt.Errorf("parsing %s result failed: %w", response.Method, err)
+	}
+	return result, nil
+}
+
+// BatchResult decodes the result of a batch_add response.
+func (response Response) BatchResult() ([]OrderResult, error) {
+	var results []OrderResult
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s failed: %s", response.Method, response.Error)
+	}
+
+	if err := json.Unmarshal(response.Result, &results); err != nil {
+		return nil, fmt.Errorf("parsing %s result failed: %w", response.Method, err)
+	}
+	return results, nil
+}
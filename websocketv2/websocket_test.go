@@ -0,0 +1,54 @@
+package websocketv2
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lk16/kraken/mockserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientConnections(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+
+	client, err := NewClient(WithPublicURL(server.PublicURL()), WithPrivateURL(server.PrivateURL()), WithLevel3URL(server.Level3URL()))
+	assert.Nil(t, err)
+	client.privateToken = mockserver.DefaultToken
+
+	// the mock server answers v2 requests with v1 frames, which are not read here
+	go func() {
+		for range client.Listen() {
+		}
+	}()
+
+	_, err = client.Subscribe(SubscribeParams{Channel: "level3", Symbol: []string{"BTC/USD"}})
+	assert.EqualError(t, err, "not connected to level3 websocket")
+
+	assert.Nil(t, client.ConnectWs("level3"))
+	_, err = client.Subscribe(SubscribeParams{Channel: "level3", Symbol: []string{"BTC/USD"}})
+	assert.Nil(t, err)
+	_, err = client.Subscribe(SubscribeParams{Channel: "ticker", Symbol: []string{"BTC/USD"}})
+	assert.Nil(t, err)
+
+	requests := make(map[string]string)
+	for _, request := range waitForRequests(t, server, 2) {
+		requests[request.Connection] = request.Raw
+	}
+	assert.True(t, strings.Contains(requests["level3"], `"token":"mock-token"`))
+	assert.True(t, strings.Contains(requests["public"], `"channel":"ticker"`))
+	assert.False(t, strings.Contains(requests["public"], "token"))
+}
+
+func waitForRequests(t *testing.T, server *mockserver.Server, count int) []mockserver.Request {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if requests := server.Requests(); len(requests) >= count {
+			return requests
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("received %d requests, expected %d", len(server.Requests()), count)
+	return nil
+}